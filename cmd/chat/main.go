@@ -1,12 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/Rasulikus/chat/internal/app"
+	"github.com/Rasulikus/chat/internal/certs"
 	"github.com/Rasulikus/chat/internal/config"
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/tracing"
 
 	_ "github.com/Rasulikus/chat/docs" // swagger docs
 	swaggerFiles "github.com/swaggo/files"
@@ -17,22 +27,106 @@ import (
 // @version 1.0
 // @description Simple chat service with rooms and WebSocket messaging.
 // @BasePath /
+// @securityDefinitions.apikey BotAPIKey
+// @in header
+// @name Authorization
+// @description Bot API key as "Bearer <key>".
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin token (ADMIN_TOKEN) as "Bearer <token>".
 func main() {
-	cfg := config.LoadConfig()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:]))
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chat:", err)
+		os.Exit(2)
+	}
+
+	logger, err := logging.New(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		slog.Error("logger", "err", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+
+	// Фоновые задачи живут, пока процесс не получит сигнал остановки.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		logger.Error("tracing", "err", err)
+		os.Exit(1)
+	}
+	defer func() {
+		// Отправляем накопленные спаны перед выходом.
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("tracing shutdown", "err", err)
+		}
+	}()
 
 	// Инициализируем Gin-роутер через наше приложение.
-	router := app.App(cfg)
+	router, db, err := app.App(ctx, cfg, logger)
+	if err != nil {
+		logger.Error("app", "err", err)
+		os.Exit(1)
+	}
 
 	// Регистрируем Swagger UI по пути /swagger/*any.
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	server := http.Server{
-		Addr:    fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port),
-		Handler: router,
+		Addr:     fmt.Sprintf("%s:%s", cfg.HTTP.Host, cfg.HTTP.Port),
+		Handler:  router,
+		ErrorLog: slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+	if cfg.HTTP.TLS.Enabled() {
+		// Сертификат перечитывается с диска при обновлении файлов, без перезапуска.
+		reloader, err := certs.NewReloader(cfg.HTTP.TLS.CertFile, cfg.HTTP.TLS.KeyFile)
+		if err != nil {
+			logger.Error("tls", "err", err)
+			os.Exit(1)
+		}
+		go reloader.Watch(logging.WithContext(ctx, logger), cfg.HTTP.TLS.ReloadInterval)
+		server.TLSConfig = reloader.TLSConfig()
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		// /readyz уже отвечает "draining"; даём балансировщику время убрать инстанс из ротации.
+		time.Sleep(cfg.HTTP.DrainDelay)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			logger.Error("shutdown", "err", err)
+		}
+	}()
+
+	logger.Info("server started", "addr", server.Addr, "tls", cfg.HTTP.TLS.Enabled())
+	if cfg.HTTP.TLS.Enabled() {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Error("listen", "err", err)
+		os.Exit(1)
 	}
 
-	log.Print("Server start at address: " + server.Addr)
-	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("listen: %v", err)
+	// Соединения с БД закрываем только после того, как завершились текущие запросы.
+	<-shutdownDone
+	if err := db.Close(); err != nil {
+		logger.Error("close db", "err", err)
 	}
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Rasulikus/chat/internal/config"
+	"github.com/Rasulikus/chat/internal/migrator"
+)
+
+// runMigrate handles "chat migrate <command>" with the database settings of the server and returns the exit code.
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dir := fs.String("migrations", "", "read migrations from this directory instead of the ones built into the binary")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: chat migrate [-migrations dir] <command>\n\nCommands:\n%s\nFlags:\n", migrator.CommandUsage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	// Database settings come from CONFIG_FILE and the environment; flags after "migrate" belong to the subcommand.
+	cfg, err := config.Load(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chat migrate:", err)
+		return 2
+	}
+	err = migrator.Command(*migrator.NewOptions(cfg.DB.MigrateURL(), *dir), fs.Args(), os.Stdout)
+	if errors.Is(err, migrator.ErrUsage) {
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chat migrate:", err)
+		return 1
+	}
+	return 0
+}
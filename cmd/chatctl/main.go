@@ -0,0 +1,195 @@
+// Command chatctl operates a running chat server through its admin API and runs database migrations.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	httpapi "github.com/Rasulikus/chat/internal/api/http"
+	"github.com/Rasulikus/chat/internal/config"
+	"github.com/Rasulikus/chat/internal/migrator"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/scheduler"
+	wsruntime "github.com/Rasulikus/chat/internal/ws"
+)
+
+const usage = `Usage: chatctl [flags] <command> [args]
+
+Commands:
+  rooms                 list live rooms and their clients
+  kick <conn-id>        force-disconnect a client
+  announce <text>       send a notice to every connected client
+  delete-room <id>      soft delete a room and disconnect its clients
+  restore-room <id>     restore a deleted room
+  jobs                  list background jobs and their last runs
+  migrate <command>     run a migration command with the DB_* environment:
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	fs := flag.NewFlagSet("chatctl", flag.ExitOnError)
+	server := fs.String("server", envOr("CHATCTL_SERVER", "http://localhost:8081"), "chat server URL ($CHATCTL_SERVER)")
+	token := fs.String("token", os.Getenv("ADMIN_TOKEN"), "admin token ($ADMIN_TOKEN)")
+	caCert := fs.String("cacert", os.Getenv("CHATCTL_CACERT"), "PEM file of CAs to trust for an https server, e.g. a self-signed certificate ($CHATCTL_CACERT)")
+	migrationsDir := fs.String("migrations", "", "migrations directory for migrate (default: the migrations built into the binary)")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		for _, line := range strings.SplitAfter(migrator.CommandUsage, "\n") {
+			if line != "" {
+				fmt.Fprint(fs.Output(), "    "+line)
+			}
+		}
+		fmt.Fprint(fs.Output(), "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(os.Args[1:])
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c, err := newClient(*server, *token, *caCert)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chatctl:", err)
+		os.Exit(1)
+	}
+	err = run(ctx, c, *migrationsDir, fs.Args())
+	if errors.Is(err, errUsage) || errors.Is(err, migrator.ErrUsage) {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "chatctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, c *client, migrationsDir string, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	cmd, args := args[0], args[1:]
+
+	switch cmd {
+	case "rooms":
+		return rooms(ctx, c)
+	case "kick":
+		if len(args) != 1 {
+			return errUsage
+		}
+		if err := c.do(ctx, http.MethodDelete, "/admin/clients/"+url.PathEscape(args[0]), nil, nil); err != nil {
+			return err
+		}
+		fmt.Println("disconnected", args[0])
+		return nil
+	case "announce":
+		text := strings.Join(args, " ")
+		if text == "" {
+			return errUsage
+		}
+		var resp httpapi.AnnouncementResp
+		if err := c.do(ctx, http.MethodPost, "/admin/announcements", httpapi.AnnouncementReq{Text: text}, &resp); err != nil {
+			return err
+		}
+		fmt.Printf("sent to %d clients\n", resp.Recipients)
+		return nil
+	case "delete-room":
+		id, err := roomID(args)
+		if err != nil {
+			return err
+		}
+		if err = c.do(ctx, http.MethodDelete, "/admin/rooms/"+id, nil, nil); err != nil {
+			return err
+		}
+		fmt.Println("deleted room", id)
+		return nil
+	case "restore-room":
+		id, err := roomID(args)
+		if err != nil {
+			return err
+		}
+		var room model.Room
+		if err = c.do(ctx, http.MethodPost, "/admin/rooms/"+id+"/restore", nil, &room); err != nil {
+			return err
+		}
+		fmt.Printf("restored room %d %q\n", room.ID, room.Name)
+		return nil
+	case "jobs":
+		return jobs(ctx, c)
+	case "migrate":
+		cfg, err := config.Load(nil)
+		if err != nil {
+			return err
+		}
+		return migrator.Command(*migrator.NewOptions(cfg.DB.MigrateURL(), migrationsDir), args, os.Stdout)
+	default:
+		return errUsage
+	}
+}
+
+func rooms(ctx context.Context, c *client) error {
+	var rooms []wsruntime.RoomSnapshot
+	if err := c.do(ctx, http.MethodGet, "/admin/rooms", nil, &rooms); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ROOM\tCONN ID\tNICK\tOP\tBOT\tCONNECTED")
+	for _, room := range rooms {
+		for _, cl := range room.Clients {
+			bot := ""
+			if cl.BotID != nil {
+				bot = strconv.FormatInt(*cl.BotID, 10)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%t\t%s\t%s\n", room.ID, cl.ID, cl.Nick, cl.Op, bot, cl.ConnectedAt.Format(time.RFC3339))
+		}
+	}
+	return w.Flush()
+}
+
+func jobs(ctx context.Context, c *client) error {
+	var jobs []scheduler.JobStatus
+	if err := c.do(ctx, http.MethodGet, "/admin/jobs", nil, &jobs); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "JOB\tSCHEDULE\tNEXT RUN\tLAST RUN\tSTATUS\tERROR")
+	for _, job := range jobs {
+		last, status, errText := "-", "-", ""
+		if job.LastRun != nil {
+			last = job.LastRun.StartedAt.Format(time.RFC3339)
+			status = job.LastRun.Status
+			errText = job.LastRun.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", job.Name, job.Schedule, job.NextRunAt.Format(time.RFC3339), last, status, errText)
+	}
+	return w.Flush()
+}
+
+func roomID(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errUsage
+	}
+	if id, err := strconv.ParseInt(args[0], 10, 64); err != nil || id <= 0 {
+		return "", fmt.Errorf("invalid room id %q", args[0])
+	}
+	return args[0], nil
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
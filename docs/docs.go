@@ -15,9 +15,9 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/rooms": {
-            "get": {
-                "description": "Returns a paginated list of rooms with optional ordering and cursor-based pagination.",
+        "/admin/announcements": {
+            "post": {
+                "description": "Sends a \"system\" event to every client joined to a room on this instance. Announcements are not stored.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,63 +25,56 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "admin"
                 ],
-                "summary": "List rooms",
+                "summary": "Server-wide announcement",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Maximum number of rooms to return (1-100)",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Return rooms with IDs less than this value (cursor pagination)",
-                        "name": "before_id",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "created_at desc",
-                            "created_at asc",
-                            "last_activity_at desc",
-                            "last_activity_at asc",
-                            "id desc",
-                            "id asc"
-                        ],
-                        "type": "string",
-                        "description": "Ordering key",
-                        "name": "order",
-                        "in": "query"
+                        "description": "Announcement",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.AnnouncementReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.Room"
-                            }
+                            "$ref": "#/definitions/http.AnnouncementResp"
                         }
                     },
-                    "400": {
-                        "description": "invalid query parameters",
+                    "401": {
+                        "description": "missing or wrong admin token",
                         "schema": {
                             "$ref": "#/definitions/model.PublicError"
                         }
                     },
-                    "500": {
-                        "description": "internal server error",
+                    "403": {
+                        "description": "admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "422": {
+                        "description": "validation error",
                         "schema": {
                             "$ref": "#/definitions/model.PublicError"
                         }
                     }
-                }
-            },
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/admin/bots": {
             "post": {
-                "description": "Creates a new chat room with an optional password.",
+                "description": "Creates a bot account. The API key authenticates the bot on /bot/* endpoints and on /ws\n(Authorization: Bearer \u003ckey\u003e or ?api_key=\u003ckey\u003e). Commands are sent to a connected bot as\n\"command\" WebSocket events, otherwise POSTed to callback_url signed with callback_secret\nlike outgoing webhooks. The API key and callback secret are only returned once.",
                 "consumes": [
                     "application/json"
                 ],
@@ -89,17 +82,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "bots"
                 ],
-                "summary": "Create a new room",
+                "summary": "Register a bot",
                 "parameters": [
                     {
-                        "description": "Room creation payload",
+                        "description": "Bot payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/http.CreateRoomReq"
+                            "$ref": "#/definitions/http.CreateBotReq"
                         }
                     }
                 ],
@@ -107,11 +100,35 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/model.Room"
+                            "$ref": "#/definitions/model.Bot"
                         }
                     },
                     "400": {
-                        "description": "invalid request or validation error",
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "missing or wrong admin token",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "403": {
+                        "description": "admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "409": {
+                        "description": "bot name already taken",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "422": {
+                        "description": "validation error",
                         "schema": {
                             "$ref": "#/definitions/model.PublicError"
                         }
@@ -122,22 +139,153 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.PublicError"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/rooms/{id}": {
+        "/admin/clients/{conn_id}": {
+            "delete": {
+                "description": "Closes the WebSocket connection with the given ID, as listed by GET /admin/rooms.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Disconnect a client",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Connection ID",
+                        "name": "conn_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "missing or wrong admin token",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "403": {
+                        "description": "admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "no such connection on this instance",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/admin/jobs": {
             "get": {
-                "description": "Returns a single room by its numeric identifier.",
-                "consumes": [
+                "description": "Returns every scheduled job with its schedule, the next run on this instance and the last run on any instance.",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List background jobs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/scheduler.JobStatus"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "missing or wrong admin token",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "403": {
+                        "description": "admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/admin/rooms": {
+            "get": {
+                "description": "Returns the rooms with connected clients on this instance, with every client's connection ID, nick and connection time.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "rooms"
+                    "admin"
                 ],
-                "summary": "Get room by ID",
+                "summary": "List live rooms",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ws.RoomSnapshot"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "missing or wrong admin token",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "403": {
+                        "description": "admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
+            }
+        },
+        "/admin/rooms/{id}": {
+            "delete": {
+                "description": "Soft deletes a room; it can be restored until it is purged. Clients connected to it on this instance are disconnected.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete a room",
                 "parameters": [
                     {
                         "type": "integer",
@@ -148,11 +296,8 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/model.Room"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "invalid room ID",
@@ -160,6 +305,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.PublicError"
                         }
                     },
+                    "401": {
+                        "description": "missing or wrong admin token",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "403": {
+                        "description": "admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
                     "404": {
                         "description": "room not found",
                         "schema": {
@@ -172,53 +329,1809 @@ const docTemplate = `{
                             "$ref": "#/definitions/model.PublicError"
                         }
                     }
-                }
+                },
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "/ws": {
-            "get": {
-                "description": "Upgrades the HTTP connection to a WebSocket for real-time chat.\n\nWebSocket message protocol (JSON):\nIncoming events:\n- type: \"join\" | \"message\" | \"load_history\"\n- room_id: number (for \"join\")\n- nick: string (for \"join\")\n- password: string (for \"join\")\n- text: string (for \"message\")\n- before_id: number (for \"load_history\")\n\nOutgoing events:\n- type: \"message\" | \"history\" | \"join\" | \"error\"\n- room_id: number\n- nick: string\n- message: Message (for \"message\")\n- messages: Message[] (for \"load_history\")\n- text: string (for \"error\")",
+        "/admin/rooms/{id}/restore": {
+            "post": {
+                "description": "Restores a soft-deleted room with its history, including password-protected rooms. Rooms can be restored until they are purged.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "ws"
+                    "admin"
+                ],
+                "summary": "Restore a deleted room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "WebSocket endpoint",
                 "responses": {
-                    "101": {
-                        "description": "Switching Protocols"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Room"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid room ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "missing or wrong admin token",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "403": {
+                        "description": "admin API is disabled",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "no deleted room with this ID, or it can no longer be restored",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
                     }
-                }
-            }
-        }
-    },
-    "definitions": {
-        "http.CreateRoomReq": {
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
-                "name": {
-                    "type": "string",
-                    "maxLength": 30,
-                    "minLength": 3
                 },
-                "password": {
-                    "type": "string",
-                    "maxLength": 30
-                }
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ]
             }
         },
-        "model.PublicError": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "details": {},
-                "message": {
+        "/api/v1/rooms": {
+            "get": {
+                "description": "Returns a page of rooms, optionally searched by name and filtered.\nq matches names by prefix or by similarity. Pages are chained by passing next_cursor\nor prev_cursor as cursor; a cursor is only valid with the order it was issued for.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "List rooms",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of rooms to return (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor or prev_cursor of a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at desc",
+                            "created_at asc",
+                            "last_active_at desc",
+                            "last_active_at asc",
+                            "id desc",
+                            "id asc"
+                        ],
+                        "type": "string",
+                        "description": "Ordering key",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search rooms by name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only rooms with (true) or without (false) a password",
+                        "name": "has_password",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rooms active since this RFC 3339 time",
+                        "name": "active_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only rooms with at least this many connected clients",
+                        "name": "min_online",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the number of matching rooms in total",
+                        "name": "include_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.RoomPageResp"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameters or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms/{id}/incoming-webhooks": {
+            "get": {
+                "description": "Returns the incoming webhooks of a room. Tokens are never included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "List incoming webhooks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.IncomingWebhookPageResp"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid room ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms/{id}/messages": {
+            "get": {
+                "description": "Returns the newest messages of a room, or the page a cursor points to.\nMessages are in chronological order; next_cursor leads to older messages and prev_cursor to newer ones.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "messages"
+                ],
+                "summary": "List room messages",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of messages to return (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor or prev_cursor of a previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.MessagePageResp"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameters or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/rooms/{id}/webhooks": {
+            "get": {
+                "description": "Returns the webhooks subscribed to a room. Secrets are never included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List room webhooks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.WebhookPageResp"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid room ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/bot/commands": {
+            "get": {
+                "description": "Returns the slash commands registered by the authenticated bot.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bots"
+                ],
+                "summary": "List bot commands",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BotCommand"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BotAPIKey": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Replaces the slash commands handled by the authenticated bot. Built-in commands\n(/help, /me, /nick, /topic, /kick) always take precedence.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "bots"
+                ],
+                "summary": "Set bot commands",
+                "parameters": [
+                    {
+                        "description": "Commands payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.SetBotCommandsReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.BotCommand"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "409": {
+                        "description": "command registered by another bot",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "422": {
+                        "description": "validation error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BotAPIKey": []
+                    }
+                ]
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Always succeeds while the server can answer requests; dependencies are not checked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/hooks/{token}": {
+            "post": {
+                "description": "Creates a message in the webhook's room as if it was sent over the WebSocket:\nit is persisted, broadcast to connected clients and forwarded to outgoing webhooks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "Post a message through an incoming webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Incoming webhook token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Message payload; nick defaults to the webhook name",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.PostIncomingWebhookReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "unknown token or room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "422": {
+                        "description": "validation error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks the database connection, the schema migration version and the WebSocket hub.\nReports \"draining\" while the server shuts down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    },
+                    "503": {
+                        "description": "not ready",
+                        "schema": {
+                            "$ref": "#/definitions/health.Report"
+                        }
+                    }
+                }
+            }
+        },
+        "/rooms": {
+            "get": {
+                "description": "Returns a page of rooms, optionally searched by name and filtered.\nq matches names by prefix or by similarity. Pages are chained with the opaque cursors\nfrom the X-Next-Cursor and X-Prev-Cursor headers, which are only valid with the same order.\nDeprecated: use /api/v1/rooms, which returns the page in an envelope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "List rooms",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of rooms to return (1-100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor from the X-Next-Cursor or X-Prev-Cursor header",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Deprecated: continue after the room with this ID, use cursor instead",
+                        "name": "before_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at desc",
+                            "created_at asc",
+                            "last_active_at desc",
+                            "last_active_at asc",
+                            "id desc",
+                            "id asc"
+                        ],
+                        "type": "string",
+                        "description": "Ordering key",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search rooms by name",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only rooms with (true) or without (false) a password",
+                        "name": "has_password",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only rooms active since this RFC 3339 time",
+                        "name": "active_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only rooms with at least this many connected clients",
+                        "name": "min_online",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return the number of matching rooms in X-Total-Count",
+                        "name": "include_total",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Room"
+                            }
+                        },
+                        "headers": {
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page, absent on the last page"
+                            },
+                            "X-Prev-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the previous page, absent on the first page"
+                            },
+                            "X-Total-Count": {
+                                "type": "int",
+                                "description": "Number of matching rooms, if include_total is set"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid query parameters or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new chat room with an optional password, topic, description, avatar, custom metadata\nand message retention limits.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Create a new room",
+                "parameters": [
+                    {
+                        "description": "Room creation payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.CreateRoomReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Room"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request or validation error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/rooms/{id}": {
+            "get": {
+                "description": "Returns a single room by its numeric identifier.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Get room by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Room"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid room ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Updates the name, password, topic, description, avatar, metadata or message retention of a room.\nClients in the room receive a \"room_updated\" event; renames and topic changes are also\nrecorded in the history as system messages. An empty password removes the password.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Update a room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Current room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.UpdateRoomReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Room"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "422": {
+                        "description": "validation error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/rooms/{id}/incoming-webhooks": {
+            "get": {
+                "description": "Returns the incoming webhooks of a room. Tokens are never included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "List incoming webhooks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.IncomingWebhook"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid room ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a token that lets bots post messages to the room over HTTP via POST /hooks/{token}.\nThe token is only returned once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "Create an incoming webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Incoming webhook payload; name is the default display nick",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.CreateIncomingWebhookReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.IncomingWebhook"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "422": {
+                        "description": "validation error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/rooms/{id}/incoming-webhooks/{hook_id}": {
+            "delete": {
+                "description": "Revokes the token of an incoming webhook.",
+                "tags": [
+                    "incoming-webhooks"
+                ],
+                "summary": "Delete an incoming webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Incoming webhook ID",
+                        "name": "hook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room or incoming webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/rooms/{id}/restore": {
+            "post": {
+                "description": "Restores a soft-deleted room with its history. Rooms can be restored until they are purged for good.\nOnly password-protected rooms can be restored here, with their password; rooms without one are restored\nby an administrator through POST /admin/rooms/{id}/restore. A system message records the restore.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rooms"
+                ],
+                "summary": "Restore a deleted room",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.Room"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid room ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "403": {
+                        "description": "room has no password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "no deleted room with this ID, or it can no longer be restored",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/rooms/{id}/webhooks": {
+            "get": {
+                "description": "Returns the webhooks subscribed to a room. Secrets are never included.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "List room webhooks",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.Webhook"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid room ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Subscribes a URL to room events. Every event is POSTed as JSON and signed with\nHMAC-SHA256 over \"\u003cX-Chat-Timestamp\u003e.\u003cbody\u003e\" using the returned secret; the signature\nis sent in the X-Chat-Signature header as \"sha256=\u003chex\u003e\". The secret is only returned once.\nFailed deliveries are retried with exponential backoff and eventually dead-lettered.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Create a room webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Webhook payload; events defaults to all event types",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.CreateWebhookReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/model.Webhook"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "422": {
+                        "description": "validation error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/rooms/{id}/webhooks/{webhook_id}": {
+            "delete": {
+                "description": "Unsubscribes a webhook; pending deliveries are discarded.",
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Delete a room webhook",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Room ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Room password, required for protected rooms",
+                        "name": "X-Room-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "invalid ID",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong room password",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "404": {
+                        "description": "room or webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        },
+        "/ws": {
+            "get": {
+                "description": "Upgrades the HTTP connection to a WebSocket for real-time chat.\n\nWebSocket message protocol (JSON):\nIncoming events:\n- type: \"join\" | \"message\" | \"load_history\" | \"command_reply\"\n- room_id: number (for \"join\")\n- nick: string (for \"join\")\n- password: string (for \"join\")\n- text: string (for \"message\", \"command_reply\"); \"/name args\" runs a slash command, \"//\" escapes the slash\n- cursor: string (for \"load_history\"; next_cursor or prev_cursor of an earlier page, omit for the newest messages)\n- before_id: number (for \"load_history\"; deprecated, use cursor; returns the messages right before it, before cursors it returned the oldest ones)\n- invocation_id: string (for \"command_reply\", bots only)\n- ephemeral: bool (for \"command_reply\"; true shows the reply to the invoker only)\n\nOutgoing events:\n- type: \"message\" | \"history\" | \"join\" | \"error\" | \"system\" | \"command\" | \"nick\" | \"room_updated\" | \"kick\"\n- room_id: number\n- nick: string\n- old_nick: string (for \"nick\")\n- message: Message (for \"message\"); message.kind is \"user\", \"bot\" or \"system\", system messages have no nick\n- messages: Message[] (for \"load_history\"), oldest first\n- next_cursor, prev_cursor: string (for \"load_history\"; lead to older and newer messages)\n- command: CommandInvocation (for \"command\", bots only)\n- room: Room (for \"room_updated\")\n- text: string (for \"error\", \"system\", \"kick\"); \"system\" notices target one user and are never stored\n\nServers running with TLS accept the WebSocket at wss:// only.\nBots authenticate with \"Authorization: Bearer \u003capi key\u003e\" or \"?api_key=\u003capi key\u003e\".",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ws"
+                ],
+                "summary": "WebSocket endpoint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bot API key",
+                        "name": "api_key",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols"
+                    },
+                    "401": {
+                        "description": "invalid bot API key",
+                        "schema": {
+                            "$ref": "#/definitions/model.PublicError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "health.Report": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.AnnouncementReq": {
+            "type": "object",
+            "required": [
+                "text"
+            ],
+            "properties": {
+                "text": {
+                    "type": "string",
+                    "maxLength": 2000,
+                    "minLength": 1
+                }
+            }
+        },
+        "http.AnnouncementResp": {
+            "type": "object",
+            "properties": {
+                "recipients": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.AttachmentReq": {
+            "type": "object",
+            "required": [
+                "type",
+                "url"
+            ],
+            "properties": {
+                "title": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "image",
+                        "file",
+                        "link"
+                    ]
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
+        },
+        "http.BotCommandReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 200
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 1
+                }
+            }
+        },
+        "http.CreateBotReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "callback_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 30,
+                    "minLength": 3
+                }
+            }
+        },
+        "http.CreateIncomingWebhookReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 30,
+                    "minLength": 1
+                }
+            }
+        },
+        "http.CreateRoomReq": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "avatar_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 30,
+                    "minLength": 3
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "retention_days": {
+                    "description": "RetentionDays and RetentionMaxMessages limit the kept history; omitted keeps messages forever.",
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 1
+                },
+                "retention_max_messages": {
+                    "type": "integer",
+                    "maximum": 1000000,
+                    "minimum": 1
+                },
+                "topic": {
+                    "type": "string",
+                    "maxLength": 250
+                }
+            }
+        },
+        "http.CreateWebhookReq": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "url": {
+                    "type": "string",
+                    "maxLength": 2048
+                }
+            }
+        },
+        "http.IncomingWebhookPageResp": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.IncomingWebhook"
+                    }
+                }
+            }
+        },
+        "http.MessagePageResp": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Message"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "http.PostIncomingWebhookReq": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "maxItems": 10,
+                    "items": {
+                        "$ref": "#/definitions/http.AttachmentReq"
+                    }
+                },
+                "nick": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "text": {
+                    "type": "string",
+                    "maxLength": 4000
+                }
+            }
+        },
+        "http.RoomPageResp": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Room"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "prev_cursor": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "http.SetBotCommandsReq": {
+            "type": "object",
+            "properties": {
+                "commands": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "items": {
+                        "$ref": "#/definitions/http.BotCommandReq"
+                    }
+                }
+            }
+        },
+        "http.UpdateRoomReq": {
+            "type": "object",
+            "properties": {
+                "avatar_url": {
+                    "type": "string",
+                    "maxLength": 2048
+                },
+                "description": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 30,
+                    "minLength": 3
+                },
+                "password": {
+                    "type": "string",
+                    "maxLength": 30
+                },
+                "retention_days": {
+                    "type": "integer",
+                    "maximum": 3650,
+                    "minimum": 0
+                },
+                "retention_max_messages": {
+                    "type": "integer",
+                    "maximum": 1000000,
+                    "minimum": 0
+                },
+                "topic": {
+                    "type": "string",
+                    "maxLength": 250
+                }
+            }
+        },
+        "http.WebhookPageResp": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Webhook"
+                    }
+                }
+            }
+        },
+        "model.Attachment": {
+            "type": "object",
+            "properties": {
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Bot": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "type": "string"
+                },
+                "callback_secret": {
+                    "type": "string"
+                },
+                "callback_url": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.BotCommand": {
+            "type": "object",
+            "properties": {
+                "bot_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.IncomingWebhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "room_id": {
+                    "type": "integer"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.JobRun": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "instance": {
+                    "description": "Instance is the host that ran the job.",
+                    "type": "string"
+                },
+                "job": {
+                    "type": "string"
+                },
+                "scheduled_at": {
+                    "description": "ScheduledAt is the slot the run belongs to; replicas use it to skip slots another replica already ran.",
+                    "type": "string"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Message": {
+            "type": "object",
+            "properties": {
+                "attachments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Attachment"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "nick": {
+                    "type": "string"
+                },
+                "room_id": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.PublicError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "details": {},
+                "message": {
                     "type": "string"
                 }
             }
@@ -226,12 +2139,18 @@ const docTemplate = `{
         "model.Room": {
             "type": "object",
             "properties": {
+                "avatar_url": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
                 "deleted_at": {
                     "type": "string"
                 },
+                "description": {
+                    "type": "string"
+                },
                 "has_password": {
                     "type": "boolean"
                 },
@@ -241,14 +2160,122 @@ const docTemplate = `{
                 "last_active_at": {
                     "type": "string"
                 },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
                 "name": {
                     "type": "string"
                 },
+                "retention_days": {
+                    "description": "RetentionDays and RetentionMaxMessages limit how long and how many messages are kept; zero keeps them forever.",
+                    "type": "integer"
+                },
+                "retention_max_messages": {
+                    "type": "integer"
+                },
+                "topic": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Webhook": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "room_id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "scheduler.JobStatus": {
+            "type": "object",
+            "properties": {
+                "last_run": {
+                    "$ref": "#/definitions/model.JobRun"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_run_at": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "type": "string"
+                }
+            }
+        },
+        "ws.ClientInfo": {
+            "type": "object",
+            "properties": {
+                "bot_id": {
+                    "type": "integer"
+                },
+                "connected_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "nick": {
+                    "type": "string"
+                },
+                "op": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "ws.RoomSnapshot": {
+            "type": "object",
+            "properties": {
+                "clients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/ws.ClientInfo"
+                    }
+                },
+                "id": {
+                    "type": "integer"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "AdminToken": {
+            "description": "Admin token (ADMIN_TOKEN) as \"Bearer \u003ctoken\u003e\".",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "BotAPIKey": {
+            "description": "Bot API key as \"Bearer \u003ckey\u003e\".",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
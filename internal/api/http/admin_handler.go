@@ -0,0 +1,227 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/scheduler"
+	"github.com/Rasulikus/chat/internal/service"
+	wsruntime "github.com/Rasulikus/chat/internal/ws"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	reasonAdminDisconnect = "disconnected by an administrator"
+	reasonRoomDeleted     = "room deleted"
+)
+
+type AdminHandler struct {
+	jobs        *scheduler.Scheduler
+	hub         *wsruntime.Hub
+	publisher   *wsruntime.Publisher
+	roomService service.RoomService
+	token       string
+}
+
+// NewAdminHandler constructs the admin API; requests must carry token as a bearer token, and an empty token disables the API.
+func NewAdminHandler(jobs *scheduler.Scheduler, hub *wsruntime.Hub, publisher *wsruntime.Publisher, roomService service.RoomService, token string) *AdminHandler {
+	return &AdminHandler{
+		jobs:        jobs,
+		hub:         hub,
+		publisher:   publisher,
+		roomService: roomService,
+		token:       token,
+	}
+}
+
+// AnnouncementReq represents a server-wide announcement.
+type AnnouncementReq struct {
+	Text string `json:"text" binding:"required,min=1,max=2000"`
+}
+
+// AnnouncementResp reports how many clients received an announcement.
+type AnnouncementResp struct {
+	Recipients int `json:"recipients"`
+}
+
+// Authenticate is a middleware that checks the admin bearer token.
+func (h *AdminHandler) Authenticate(c *gin.Context) {
+	if h.token == "" {
+		status, pub := model.ToHTTP(model.ErrForbidden)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(h.token)) != 1 {
+		status, pub := model.ToHTTP(model.ErrUnauthorized)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Next()
+}
+
+// Jobs lists the background jobs and the status of their last run.
+//
+// @Summary List background jobs
+// @Description Returns every scheduled job with its schedule, the next run on this instance and the last run on any instance.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} scheduler.JobStatus
+// @Failure 401 {object} model.PublicError "missing or wrong admin token"
+// @Failure 403 {object} model.PublicError "admin API is disabled"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /admin/jobs [get]
+func (h *AdminHandler) Jobs(c *gin.Context) {
+	ctx := c.Request.Context()
+	jobs, err := h.jobs.Status(ctx)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, jobs)
+}
+
+// Rooms lists the rooms with live connections on this instance.
+//
+// @Summary List live rooms
+// @Description Returns the rooms with connected clients on this instance, with every client's connection ID, nick and connection time.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} ws.RoomSnapshot
+// @Failure 401 {object} model.PublicError "missing or wrong admin token"
+// @Failure 403 {object} model.PublicError "admin API is disabled"
+// @Router /admin/rooms [get]
+func (h *AdminHandler) Rooms(c *gin.Context) {
+	c.JSON(http.StatusOK, h.hub.Rooms())
+}
+
+// DisconnectClient force-disconnects a client.
+//
+// @Summary Disconnect a client
+// @Description Closes the WebSocket connection with the given ID, as listed by GET /admin/rooms.
+// @Tags admin
+// @Security AdminToken
+// @Param conn_id path string true "Connection ID"
+// @Success 204 "No Content"
+// @Failure 401 {object} model.PublicError "missing or wrong admin token"
+// @Failure 403 {object} model.PublicError "admin API is disabled"
+// @Failure 404 {object} model.PublicError "no such connection on this instance"
+// @Router /admin/clients/{conn_id} [delete]
+func (h *AdminHandler) DisconnectClient(c *gin.Context) {
+	id := c.Param("conn_id")
+	if !h.hub.Disconnect(id, reasonAdminDisconnect) {
+		status, pub := model.ToHTTP(model.ErrNotFound)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	logging.FromContext(c.Request.Context()).Info("admin: client disconnected", "conn_id", id)
+	c.Status(http.StatusNoContent)
+}
+
+// Announce sends a notice to every connected client.
+//
+// @Summary Server-wide announcement
+// @Description Sends a "system" event to every client joined to a room on this instance. Announcements are not stored.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param input body AnnouncementReq true "Announcement"
+// @Success 200 {object} AnnouncementResp
+// @Failure 401 {object} model.PublicError "missing or wrong admin token"
+// @Failure 403 {object} model.PublicError "admin API is disabled"
+// @Failure 422 {object} model.PublicError "validation error"
+// @Router /admin/announcements [post]
+func (h *AdminHandler) Announce(c *gin.Context) {
+	var req AnnouncementReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		} else {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
+
+	n := h.hub.Announce(req.Text)
+	logging.FromContext(c.Request.Context()).Info("admin: announcement sent", "recipients", n)
+	c.JSON(http.StatusOK, AnnouncementResp{Recipients: n})
+}
+
+// DeleteRoom soft deletes a room and disconnects its clients.
+//
+// @Summary Delete a room
+// @Description Soft deletes a room; it can be restored until it is purged. Clients connected to it on this instance are disconnected.
+// @Tags admin
+// @Security AdminToken
+// @Param id path int true "Room ID"
+// @Success 204 "No Content"
+// @Failure 400 {object} model.PublicError "invalid room ID"
+// @Failure 401 {object} model.PublicError "missing or wrong admin token"
+// @Failure 403 {object} model.PublicError "admin API is disabled"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /admin/rooms/{id} [delete]
+func (h *AdminHandler) DeleteRoom(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err = h.roomService.SoftDelete(ctx, id); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	n := h.hub.CloseRoom(id, reasonRoomDeleted)
+	logging.FromContext(ctx).Info("admin: room deleted", "room_id", id, "disconnected", n)
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreRoom restores a deleted room without its password.
+//
+// @Summary Restore a deleted room
+// @Description Restores a soft-deleted room with its history, including password-protected rooms. Rooms can be restored until they are purged.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Room ID"
+// @Success 200 {object} model.Room
+// @Failure 400 {object} model.PublicError "invalid room ID"
+// @Failure 401 {object} model.PublicError "missing or wrong admin token"
+// @Failure 403 {object} model.PublicError "admin API is disabled"
+// @Failure 404 {object} model.PublicError "no deleted room with this ID, or it can no longer be restored"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /admin/rooms/{id}/restore [post]
+func (h *AdminHandler) RestoreRoom(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	room, err := h.roomService.ForceRestore(ctx, id)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	h.publisher.RoomRestored(ctx, room)
+	logging.FromContext(ctx).Info("admin: room restored", "room_id", id)
+	c.JSON(http.StatusOK, room)
+}
@@ -0,0 +1,249 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository/memory"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/Rasulikus/chat/internal/service/bot"
+	"github.com/Rasulikus/chat/internal/service/message"
+	"github.com/Rasulikus/chat/internal/service/room"
+	"github.com/Rasulikus/chat/internal/service/webhook"
+	wsruntime "github.com/Rasulikus/chat/internal/ws"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAdminToken = "admin-secret"
+
+// adminSuite serves the admin API next to a WebSocket server whose clients join the same hub.
+type adminSuite struct {
+	ctx    context.Context
+	hub    *wsruntime.Hub
+	rooms  *room.Service
+	router *gin.Engine
+	ws     *httptest.Server
+}
+
+func setupAdminSuite(t *testing.T, token string) *adminSuite {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cursors, err := cursor.NewCodec("secret")
+	require.NoError(t, err)
+
+	store := memory.NewStore()
+	roomRepo := memory.NewRoomRepository(store)
+	webhooks := webhook.NewService(memory.NewWebhookRepository(store), roomRepo)
+	messages := message.NewService(memory.NewMessageRepository(store), room.NewActivityRecorder(roomRepo), cursors, nil)
+
+	ts := &adminSuite{
+		ctx:   context.Background(),
+		hub:   wsruntime.NewHub(),
+		rooms: room.NewService(roomRepo, memory.NewTransactor(), webhooks, cursors, time.Hour),
+	}
+	go ts.hub.Run()
+	publisher := wsruntime.NewPublisher(ts.hub, messages, webhooks)
+	commands := wsruntime.NewCommandRouter(ts.hub, publisher, ts.rooms, bot.NewService(memory.NewBotRepository(store)))
+
+	h := NewAdminHandler(nil, ts.hub, publisher, ts.rooms, token)
+	ts.router = gin.New()
+	admin := ts.router.Group("/admin", h.Authenticate)
+	admin.GET("/rooms", h.Rooms)
+	admin.DELETE("/rooms/:id", h.DeleteRoom)
+	admin.DELETE("/clients/:conn_id", h.DisconnectClient)
+
+	upgrader := websocket.Upgrader{}
+	ts.ws = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		wsruntime.NewClient(r.Context(), ts.hub, conn, ts.rooms, messages, webhooks, commands, wsruntime.Options{
+			SendBuffer:      16,
+			HistoryPageSize: 50,
+		}).Start()
+	}))
+	t.Cleanup(ts.ws.Close)
+	return ts
+}
+
+// do sends an admin request, with authorization as the Authorization header when it is set.
+func (ts *adminSuite) do(method, path, authorization string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	ts.router.ServeHTTP(w, r)
+	return w
+}
+
+func (ts *adminSuite) room(t *testing.T, name string) *model.Room {
+	t.Helper()
+	r, err := ts.rooms.Create(ts.ctx, service.CreateRoomInput{Name: name})
+	require.NoError(t, err)
+	return r
+}
+
+// join connects a client to the room and waits for its join event, so the hub has registered it.
+func (ts *adminSuite) join(t *testing.T, roomID int64, nick string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.ws.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	require.NoError(t, conn.WriteJSON(wsruntime.IncomingEvent{Type: wsruntime.EventTypeJoin, RoomID: roomID, Nick: nick}))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	for {
+		var ev wsruntime.OutgoingEvent
+		require.NoError(t, conn.ReadJSON(&ev))
+		if ev.Type == wsruntime.EventTypeJoin && ev.Nick == nick {
+			return conn
+		}
+	}
+}
+
+// connIDs returns the connection IDs of the live clients by nick, as listed by GET /admin/rooms.
+func (ts *adminSuite) connIDs(t *testing.T) map[string]string {
+	t.Helper()
+	w := ts.do(http.MethodGet, "/admin/rooms", "Bearer "+testAdminToken)
+	require.Equal(t, http.StatusOK, w.Code)
+	var rooms []wsruntime.RoomSnapshot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rooms))
+
+	ids := make(map[string]string)
+	for _, r := range rooms {
+		for _, c := range r.Clients {
+			ids[c.Nick] = c.ID
+		}
+	}
+	return ids
+}
+
+// assertKicked reads until the server closes the connection and checks it was closed with reason.
+func assertKicked(t *testing.T, conn *websocket.Conn, reason string) {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	for {
+		var ev wsruntime.OutgoingEvent
+		if err := conn.ReadJSON(&ev); err != nil {
+			var closeErr *websocket.CloseError
+			require.ErrorAs(t, err, &closeErr)
+			assert.Equal(t, websocket.ClosePolicyViolation, closeErr.Code)
+			assert.Equal(t, reason, closeErr.Text)
+			return
+		}
+	}
+}
+
+// assertConnected checks the client still exchanges messages with its room.
+func assertConnected(t *testing.T, conn *websocket.Conn) {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(wsruntime.IncomingEvent{Type: wsruntime.EventTypeMessage, Text: "still here"}))
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	for {
+		var ev wsruntime.OutgoingEvent
+		require.NoError(t, conn.ReadJSON(&ev))
+		if ev.Type == wsruntime.EventTypeMessage && ev.Message.Text == "still here" {
+			return
+		}
+	}
+}
+
+func Test_AdminHandler_Authenticate(t *testing.T) {
+	testCases := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "valid token", token: testAdminToken, authorization: "Bearer " + testAdminToken, wantStatus: http.StatusOK},
+		{name: "missing token", token: testAdminToken, wantStatus: http.StatusUnauthorized},
+		{name: "wrong token", token: testAdminToken, authorization: "Bearer guess", wantStatus: http.StatusUnauthorized},
+		{name: "not a bearer token", token: testAdminToken, authorization: testAdminToken, wantStatus: http.StatusUnauthorized},
+		{name: "empty bearer token", token: testAdminToken, authorization: "Bearer ", wantStatus: http.StatusUnauthorized},
+		{name: "API disabled", authorization: "Bearer " + testAdminToken, wantStatus: http.StatusForbidden},
+		{name: "API disabled, empty bearer token", authorization: "Bearer ", wantStatus: http.StatusForbidden},
+		{name: "API disabled, no token", wantStatus: http.StatusForbidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := setupAdminSuite(t, tc.token)
+
+			for _, route := range []struct{ method, path string }{
+				{http.MethodGet, "/admin/rooms"},
+				{http.MethodDelete, "/admin/clients/unknown"},
+				{http.MethodDelete, "/admin/rooms/1"},
+			} {
+				w := ts.do(route.method, route.path, tc.authorization)
+				if tc.wantStatus == http.StatusOK {
+					assert.NotContains(t, []int{http.StatusUnauthorized, http.StatusForbidden}, w.Code, route.path)
+					continue
+				}
+				assert.Equal(t, tc.wantStatus, w.Code, route.path)
+			}
+		})
+	}
+}
+
+func Test_AdminHandler_DisconnectClient(t *testing.T) {
+	ts := setupAdminSuite(t, testAdminToken)
+	r := ts.room(t, "general")
+	alice := ts.join(t, r.ID, "alice")
+	bob := ts.join(t, r.ID, "bob")
+
+	ids := ts.connIDs(t)
+	w := ts.do(http.MethodDelete, "/admin/clients/"+ids["bob"], "Bearer "+testAdminToken)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assertKicked(t, bob, reasonAdminDisconnect)
+
+	assert.Eventually(t, func() bool {
+		_, ok := ts.connIDs(t)["bob"]
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, ids["alice"], ts.connIDs(t)["alice"])
+	assertConnected(t, alice)
+
+	t.Run("unknown connection", func(t *testing.T) {
+		w := ts.do(http.MethodDelete, "/admin/clients/"+ids["bob"], "Bearer "+testAdminToken)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func Test_AdminHandler_DeleteRoom(t *testing.T) {
+	ts := setupAdminSuite(t, testAdminToken)
+	general := ts.room(t, "general")
+	random := ts.room(t, "random")
+	alice := ts.join(t, general.ID, "alice")
+	bob := ts.join(t, general.ID, "bob")
+	carol := ts.join(t, random.ID, "carol")
+
+	w := ts.do(http.MethodDelete, "/admin/rooms/"+strconv.FormatInt(general.ID, 10), "Bearer "+testAdminToken)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assertKicked(t, alice, reasonRoomDeleted)
+	assertKicked(t, bob, reasonRoomDeleted)
+
+	_, err := ts.rooms.GetByID(ts.ctx, general.ID)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+	assert.Eventually(t, func() bool {
+		ids := ts.connIDs(t)
+		return len(ids) == 1 && ids["carol"] != ""
+	}, time.Second, 10*time.Millisecond)
+	assertConnected(t, carol)
+
+	t.Run("unknown room", func(t *testing.T) {
+		w := ts.do(http.MethodDelete, "/admin/rooms/"+strconv.FormatInt(general.ID, 10), "Bearer "+testAdminToken)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
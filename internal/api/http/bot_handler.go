@@ -0,0 +1,179 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ctxKeyBot is the Gin context key of the bot authenticated by BotHandler.Authenticate.
+const ctxKeyBot = "bot"
+
+type BotHandler struct {
+	s service.BotService
+}
+
+func NewBotHandler(s service.BotService) *BotHandler {
+	return &BotHandler{
+		s: s,
+	}
+}
+
+// APIKey extracts a bot API key from the "Authorization: Bearer <key>" header or,
+// for WebSocket clients that cannot set headers, from the api_key query parameter.
+func APIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if key, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(key)
+		}
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// Authenticate is a middleware that resolves the bot API key and stores the bot in the context.
+func (h *BotHandler) Authenticate(c *gin.Context) {
+	bot, err := h.s.Authenticate(c.Request.Context(), APIKey(c.Request))
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Set(ctxKeyBot, bot)
+	c.Next()
+}
+
+// CreateBotReq represents a request payload for registering a bot.
+type CreateBotReq struct {
+	Name        string `json:"name" binding:"required,min=3,max=30"`
+	CallbackURL string `json:"callback_url" binding:"omitempty,url,max=2048"`
+}
+
+// BotCommandReq describes a slash command handled by a bot.
+type BotCommandReq struct {
+	Name        string `json:"name" binding:"required,min=1,max=32,alphanum,lowercase"`
+	Description string `json:"description" binding:"omitempty,max=200"`
+}
+
+// SetBotCommandsReq represents the full set of commands a bot handles.
+type SetBotCommandsReq struct {
+	Commands []BotCommandReq `json:"commands" binding:"omitempty,max=50,dive"`
+}
+
+// Create handles bot registration.
+//
+// @Summary Register a bot
+// @Description Creates a bot account. The API key authenticates the bot on /bot/* endpoints and on /ws
+// @Description (Authorization: Bearer <key> or ?api_key=<key>). Commands are sent to a connected bot as
+// @Description "command" WebSocket events, otherwise POSTed to callback_url signed with callback_secret
+// @Description like outgoing webhooks. The API key and callback secret are only returned once.
+// @Tags bots
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param request body CreateBotReq true "Bot payload"
+// @Success 201 {object} model.Bot
+// @Failure 400 {object} model.PublicError "invalid request"
+// @Failure 401 {object} model.PublicError "missing or wrong admin token"
+// @Failure 403 {object} model.PublicError "admin API is disabled"
+// @Failure 409 {object} model.PublicError "bot name already taken"
+// @Failure 422 {object} model.PublicError "validation error"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /admin/bots [post]
+func (h *BotHandler) Create(c *gin.Context) {
+	var req CreateBotReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		} else {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
+
+	bot, err := h.s.Create(c.Request.Context(), service.CreateBotInput{
+		Name:        req.Name,
+		CallbackURL: req.CallbackURL,
+	})
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, bot)
+}
+
+// ListCommands returns the commands registered by the authenticated bot.
+//
+// @Summary List bot commands
+// @Description Returns the slash commands registered by the authenticated bot.
+// @Tags bots
+// @Produce json
+// @Security BotAPIKey
+// @Success 200 {array} model.BotCommand
+// @Failure 401 {object} model.PublicError "invalid API key"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /bot/commands [get]
+func (h *BotHandler) ListCommands(c *gin.Context) {
+	bot := c.MustGet(ctxKeyBot).(*model.Bot)
+
+	commands, err := h.s.ListCommands(c.Request.Context(), &bot.ID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, commands)
+}
+
+// SetCommands replaces the commands registered by the authenticated bot.
+//
+// @Summary Set bot commands
+// @Description Replaces the slash commands handled by the authenticated bot. Built-in commands
+// @Description (/help, /me, /nick, /topic, /kick) always take precedence.
+// @Tags bots
+// @Accept json
+// @Produce json
+// @Security BotAPIKey
+// @Param request body SetBotCommandsReq true "Commands payload"
+// @Success 200 {array} model.BotCommand
+// @Failure 400 {object} model.PublicError "invalid request"
+// @Failure 401 {object} model.PublicError "invalid API key"
+// @Failure 409 {object} model.PublicError "command registered by another bot"
+// @Failure 422 {object} model.PublicError "validation error"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /bot/commands [put]
+func (h *BotHandler) SetCommands(c *gin.Context) {
+	bot := c.MustGet(ctxKeyBot).(*model.Bot)
+
+	var req SetBotCommandsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		} else {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
+
+	in := make([]service.BotCommandInput, 0, len(req.Commands))
+	for _, cmd := range req.Commands {
+		in = append(in, service.BotCommandInput{Name: cmd.Name, Description: cmd.Description})
+	}
+
+	commands, err := h.s.SetCommands(c.Request.Context(), bot.ID, in)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, commands)
+}
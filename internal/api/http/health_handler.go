@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Rasulikus/chat/internal/health"
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Live reports that the process is up and serving HTTP.
+//
+// @Summary Liveness probe
+// @Description Always succeeds while the server can answer requests; dependencies are not checked.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Router /healthz [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+// Ready reports whether the server can take traffic.
+//
+// @Summary Readiness probe
+// @Description Checks the database connection, the schema migration version and the WebSocket hub.
+// @Description Reports "draining" while the server shuts down.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report "not ready"
+// @Router /readyz [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report, ok := h.checker.Ready(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/service"
+	wsruntime "github.com/Rasulikus/chat/internal/ws"
+	"github.com/gin-gonic/gin"
+)
+
+type IncomingWebhookHandler struct {
+	s         service.IncomingWebhookService
+	rooms     service.RoomService
+	publisher *wsruntime.Publisher
+}
+
+func NewIncomingWebhookHandler(s service.IncomingWebhookService, rooms service.RoomService, publisher *wsruntime.Publisher) *IncomingWebhookHandler {
+	return &IncomingWebhookHandler{
+		s:         s,
+		rooms:     rooms,
+		publisher: publisher,
+	}
+}
+
+// CreateIncomingWebhookReq represents a request payload for creating an incoming webhook.
+type CreateIncomingWebhookReq struct {
+	Name string `json:"name" binding:"required,min=1,max=30"`
+}
+
+// AttachmentReq represents a single message attachment. Clients render the URL as a link, so only http(s) URLs are accepted.
+type AttachmentReq struct {
+	Type  string `json:"type" binding:"required,oneof=image file link"`
+	URL   string `json:"url" binding:"required,http_url,max=2048"`
+	Title string `json:"title" binding:"omitempty,max=200"`
+}
+
+// PostIncomingWebhookReq represents a message posted by a bot through an incoming webhook.
+type PostIncomingWebhookReq struct {
+	Nick        string          `json:"nick" binding:"omitempty,max=30"`
+	Text        string          `json:"text" binding:"required_without=Attachments,max=4000"`
+	Attachments []AttachmentReq `json:"attachments" binding:"omitempty,max=10,dive"`
+}
+
+// Create handles incoming webhook creation for a room.
+//
+// @Summary Create an incoming webhook
+// @Description Creates a token that lets bots post messages to the room over HTTP via POST /hooks/{token}.
+// @Description The token is only returned once.
+// @Tags incoming-webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Param request body CreateIncomingWebhookReq true "Incoming webhook payload; name is the default display nick"
+// @Success 201 {object} model.IncomingWebhook
+// @Failure 400 {object} model.PublicError "invalid request"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 422 {object} model.PublicError "validation error"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /rooms/{id}/incoming-webhooks [post]
+func (h *IncomingWebhookHandler) Create(c *gin.Context) {
+	roomID, ok := authorizeRoom(c, h.rooms)
+	if !ok {
+		return
+	}
+
+	var req CreateIncomingWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		} else {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
+
+	hook, err := h.s.Create(c.Request.Context(), service.CreateIncomingWebhookInput{
+		RoomID: roomID,
+		Name:   req.Name,
+	})
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, hook)
+}
+
+// List returns the incoming webhooks of a room.
+//
+// @Summary List incoming webhooks
+// @Description Returns the incoming webhooks of a room. Tokens are never included.
+// @Tags incoming-webhooks
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Success 200 {array} model.IncomingWebhook
+// @Failure 400 {object} model.PublicError "invalid room ID"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /rooms/{id}/incoming-webhooks [get]
+func (h *IncomingWebhookHandler) List(c *gin.Context) {
+	hooks, ok := h.list(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// IncomingWebhookPageResp lists the incoming webhooks of a room; all of them fit on one page, so it carries no cursors.
+type IncomingWebhookPageResp struct {
+	Items []model.IncomingWebhook `json:"items"`
+}
+
+// ListPage returns the incoming webhooks of a room wrapped in an envelope.
+//
+// @Summary List incoming webhooks
+// @Description Returns the incoming webhooks of a room. Tokens are never included.
+// @Tags incoming-webhooks
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Success 200 {object} IncomingWebhookPageResp
+// @Failure 400 {object} model.PublicError "invalid room ID"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /api/v1/rooms/{id}/incoming-webhooks [get]
+func (h *IncomingWebhookHandler) ListPage(c *gin.Context) {
+	hooks, ok := h.list(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, IncomingWebhookPageResp{Items: hooks})
+}
+
+// list authorizes the room and fetches its incoming webhooks; it writes the error response itself.
+func (h *IncomingWebhookHandler) list(c *gin.Context) ([]model.IncomingWebhook, bool) {
+	roomID, ok := authorizeRoom(c, h.rooms)
+	if !ok {
+		return nil, false
+	}
+
+	hooks, err := h.s.ListByRoom(c.Request.Context(), roomID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return nil, false
+	}
+	return hooks, true
+}
+
+// Delete revokes an incoming webhook.
+//
+// @Summary Delete an incoming webhook
+// @Description Revokes the token of an incoming webhook.
+// @Tags incoming-webhooks
+// @Param id path int true "Room ID"
+// @Param hook_id path int true "Incoming webhook ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Success 204 "No Content"
+// @Failure 400 {object} model.PublicError "invalid ID"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room or incoming webhook not found"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /rooms/{id}/incoming-webhooks/{hook_id} [delete]
+func (h *IncomingWebhookHandler) Delete(c *gin.Context) {
+	roomID, ok := authorizeRoom(c, h.rooms)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("hook_id"), 10, 64)
+	if err != nil || id <= 0 {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	if err = h.s.Delete(c.Request.Context(), roomID, id); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Post creates a message in the room of the incoming webhook and broadcasts it to live clients.
+//
+// @Summary Post a message through an incoming webhook
+// @Description Creates a message in the webhook's room as if it was sent over the WebSocket:
+// @Description it is persisted, broadcast to connected clients and forwarded to outgoing webhooks.
+// @Tags incoming-webhooks
+// @Accept json
+// @Produce json
+// @Param token path string true "Incoming webhook token"
+// @Param request body PostIncomingWebhookReq true "Message payload; nick defaults to the webhook name"
+// @Success 201 {object} model.Message
+// @Failure 400 {object} model.PublicError "invalid request"
+// @Failure 404 {object} model.PublicError "unknown token or room not found"
+// @Failure 422 {object} model.PublicError "validation error"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /hooks/{token} [post]
+func (h *IncomingWebhookHandler) Post(c *gin.Context) {
+	var req PostIncomingWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		} else {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
+
+	var attachments []model.Attachment
+	for _, a := range req.Attachments {
+		attachments = append(attachments, model.Attachment{Type: a.Type, URL: a.URL, Title: a.Title})
+	}
+
+	ctx := c.Request.Context()
+	msg, err := h.s.Post(ctx, service.PostIncomingWebhookInput{
+		Token:       c.Param("token"),
+		Nick:        req.Nick,
+		Text:        req.Text,
+		Attachments: attachments,
+	})
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	h.publisher.Announce(ctx, msg)
+
+	c.JSON(http.StatusCreated, msg)
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository/memory"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/Rasulikus/chat/internal/service/incominghook"
+	"github.com/Rasulikus/chat/internal/service/message"
+	"github.com/Rasulikus/chat/internal/service/room"
+	"github.com/Rasulikus/chat/internal/service/webhook"
+	wsruntime "github.com/Rasulikus/chat/internal/ws"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IncomingWebhookHandler_PostAttachmentURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	cursors, err := cursor.NewCodec("secret")
+	require.NoError(t, err)
+
+	store := memory.NewStore()
+	roomRepo := memory.NewRoomRepository(store)
+	webhooks := webhook.NewService(memory.NewWebhookRepository(store), roomRepo)
+	messages := message.NewService(memory.NewMessageRepository(store), room.NewActivityRecorder(roomRepo), cursors, nil)
+	hooks := incominghook.NewService(memory.NewIncomingWebhookRepository(store), roomRepo, messages)
+	hub := wsruntime.NewHub()
+	go hub.Run()
+
+	h := NewIncomingWebhookHandler(hooks, nil, wsruntime.NewPublisher(hub, messages, webhooks))
+	router := gin.New()
+	router.POST("/hooks/:token", h.Post)
+
+	r, err := room.NewService(roomRepo, memory.NewTransactor(), webhooks, cursors, 0).Create(ctx, service.CreateRoomInput{Name: "general"})
+	require.NoError(t, err)
+	hook, err := hooks.Create(ctx, service.CreateIncomingWebhookInput{RoomID: r.ID, Name: "ci"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		url        string
+		wantStatus int
+	}{
+		{name: "https", url: "https://example.com/build/42", wantStatus: http.StatusCreated},
+		{name: "http", url: "http://example.com/logo.png", wantStatus: http.StatusCreated},
+		{name: "javascript", url: "javascript:alert(1)", wantStatus: http.StatusUnprocessableEntity},
+		{name: "data", url: "data:text/html,<script>alert(1)</script>", wantStatus: http.StatusUnprocessableEntity},
+		{name: "ftp", url: "ftp://example.com/file", wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, err := json.Marshal(PostIncomingWebhookReq{
+				Text:        "build finished",
+				Attachments: []AttachmentReq{{Type: "link", URL: tc.url}},
+			})
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodPost, "/hooks/"+hook.Token, strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tc.wantStatus, w.Code, w.Body.String())
+			if tc.wantStatus != http.StatusCreated {
+				var pub model.PublicError
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &pub))
+				assert.Equal(t, "validation_failed", pub.Code)
+			}
+		})
+	}
+
+	page, err := messages.ListByRoom(ctx, service.ListMessagesInput{RoomID: r.ID, Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Messages, 2)
+}
@@ -0,0 +1,89 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+type MessageHandler struct {
+	s     service.MessageService
+	rooms service.RoomService
+}
+
+func NewMessageHandler(s service.MessageService, rooms service.RoomService) *MessageHandler {
+	return &MessageHandler{
+		s:     s,
+		rooms: rooms,
+	}
+}
+
+// MessagePageResp is a page of room history in chronological order.
+// next_cursor leads to older messages and prev_cursor to newer ones.
+type MessagePageResp struct {
+	Items      []model.Message `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+	PrevCursor string          `json:"prev_cursor,omitempty"`
+}
+
+type MessageListQuery struct {
+	Limit  int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
+	Cursor string `form:"cursor" binding:"omitempty,max=512"`
+}
+
+// List returns a page of room history.
+//
+// @Summary List room messages
+// @Description Returns the newest messages of a room, or the page a cursor points to.
+// @Description Messages are in chronological order; next_cursor leads to older messages and prev_cursor to newer ones.
+// @Tags messages
+// @Accept json
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Param limit query int false "Maximum number of messages to return (1-100)"
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page"
+// @Success 200 {object} MessagePageResp
+// @Failure 400 {object} model.PublicError "invalid query parameters or cursor"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /api/v1/rooms/{id}/messages [get]
+func (h *MessageHandler) List(c *gin.Context) {
+	roomID, ok := authorizeRoom(c, h.rooms)
+	if !ok {
+		return
+	}
+
+	var q MessageListQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		if vErr, as := model.AsValidationError(q, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		} else {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	page, err := h.s.ListByRoom(ctx, service.ListMessagesInput{
+		RoomID: roomID,
+		Limit:  q.Limit,
+		Cursor: q.Cursor,
+	})
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusOK, MessagePageResp{
+		Items:      page.Messages,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	})
+}
@@ -3,32 +3,71 @@ package http
 import (
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Rasulikus/chat/internal/model"
 	"github.com/Rasulikus/chat/internal/service"
+	wsruntime "github.com/Rasulikus/chat/internal/ws"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// HeaderNextCursor carries the cursor of the next page of a listing; it is absent on the last page.
+	HeaderNextCursor = "X-Next-Cursor"
+	// HeaderPrevCursor carries the cursor of the previous page of a listing; it is absent on the first page.
+	HeaderPrevCursor = "X-Prev-Cursor"
+	// HeaderTotalCount carries the total number of matching items when it was requested.
+	HeaderTotalCount = "X-Total-Count"
+)
+
 type RoomHandler struct {
-	s service.RoomService
+	s         service.RoomService
+	publisher *wsruntime.Publisher
+	hub       *wsruntime.Hub
 }
 
-func NewRoomHandler(s service.RoomService) *RoomHandler {
+func NewRoomHandler(s service.RoomService, publisher *wsruntime.Publisher, hub *wsruntime.Hub) *RoomHandler {
 	return &RoomHandler{
-		s: s,
+		s:         s,
+		publisher: publisher,
+		hub:       hub,
 	}
 }
 
-// CreateRoomReq represents a request payload for creating a new room.
+// CreateRoomReq represents a request payload for creating a new room. The avatar is shown to every member,
+// so only http(s) URLs are accepted.
 type CreateRoomReq struct {
-	Name     string `json:"name" binding:"required,min=3,max=30"`
-	Password string `json:"password" binding:"omitempty,max=30"`
+	Name        string         `json:"name" binding:"required,min=3,max=30"`
+	Password    string         `json:"password" binding:"omitempty,max=30"`
+	Topic       string         `json:"topic" binding:"omitempty,max=250"`
+	Description string         `json:"description" binding:"omitempty,max=1000"`
+	AvatarURL   string         `json:"avatar_url" binding:"omitempty,http_url,max=2048"`
+	Metadata    map[string]any `json:"metadata" binding:"omitempty,max=50"`
+	// RetentionDays and RetentionMaxMessages limit the kept history; omitted keeps messages forever.
+	RetentionDays        int `json:"retention_days" binding:"omitempty,gte=1,lte=3650"`
+	RetentionMaxMessages int `json:"retention_max_messages" binding:"omitempty,gte=1,lte=1000000"`
+}
+
+// UpdateRoomReq represents a partial room update; omitted fields are left unchanged,
+// an empty avatar_url removes the avatar and a zero retention setting removes that limit.
+type UpdateRoomReq struct {
+	Name        *string        `json:"name" binding:"omitempty,min=3,max=30"`
+	Password    *string        `json:"password" binding:"omitempty,max=30"`
+	Topic       *string        `json:"topic" binding:"omitempty,max=250"`
+	Description *string        `json:"description" binding:"omitempty,max=1000"`
+	AvatarURL   *string        `json:"avatar_url" binding:"omitempty,max=2048,http_url|eq="`
+	Metadata    map[string]any `json:"metadata" binding:"omitempty,max=50"`
+
+	RetentionDays        *int `json:"retention_days" binding:"omitempty,gte=0,lte=3650"`
+	RetentionMaxMessages *int `json:"retention_max_messages" binding:"omitempty,gte=0,lte=1000000"`
 }
 
 // Create handles room creation.
 //
 // @Summary Create a new room
-// @Description Creates a new chat room with an optional password.
+// @Description Creates a new chat room with an optional password, topic, description, avatar, custom metadata
+// @Description and message retention limits.
 // @Tags rooms
 // @Accept json
 // @Produce json
@@ -54,58 +93,167 @@ func (h *RoomHandler) Create(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	room, err := h.s.Create(ctx, service.CreateRoomInput{
-		Name:     req.Name,
-		Password: req.Password,
+		Name:        req.Name,
+		Password:    req.Password,
+		Topic:       req.Topic,
+		Description: req.Description,
+		AvatarURL:   req.AvatarURL,
+		Metadata:    req.Metadata,
+
+		RetentionDays:        req.RetentionDays,
+		RetentionMaxMessages: req.RetentionMaxMessages,
 	})
 	if err != nil {
 		status, pub := model.ToHTTP(err)
 		c.AbortWithStatusJSON(status, pub)
+		return
 	}
 	c.JSON(http.StatusCreated, room)
 }
 
+// RoomPageResp is a page of rooms; the cursors are passed back as the cursor query parameter.
+type RoomPageResp struct {
+	Items      []model.Room `json:"items"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	PrevCursor string       `json:"prev_cursor,omitempty"`
+	Total      *int         `json:"total,omitempty"`
+}
+
 type RoomListQuery struct {
-	Limit    int    `form:"limit" binding:"omitempty,gte=1,lte=100"`
-	BeforeID *int64 `form:"before_id"`
-	Order    string `form:"order,default=created_at desc" binding:"omitempty,oneof='created_at desc' 'created_at asc' 'last_active_at desc' 'last_active_at asc' 'id desc' 'id asc''"`
+	Limit        int        `form:"limit" binding:"omitempty,gte=1,lte=100"`
+	Cursor       string     `form:"cursor" binding:"omitempty,max=512"`
+	BeforeID     *int64     `form:"before_id" binding:"omitempty,gte=1"`
+	Order        string     `form:"order,default=created_at desc" binding:"omitempty,oneof='created_at desc' 'created_at asc' 'last_active_at desc' 'last_active_at asc' 'id desc' 'id asc'"`
+	Q            string     `form:"q" binding:"omitempty,max=30"`
+	HasPassword  *bool      `form:"has_password"`
+	ActiveSince  *time.Time `form:"active_since" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinOnline    int        `form:"min_online" binding:"omitempty,gte=1"`
+	IncludeTotal bool       `form:"include_total"`
 }
 
 // List returns a paginated list of rooms.
 //
 // @Summary List rooms
-// @Description Returns a paginated list of rooms with optional ordering and cursor-based pagination.
+// @Description Returns a page of rooms, optionally searched by name and filtered.
+// @Description q matches names by prefix or by similarity. Pages are chained with the opaque cursors
+// @Description from the X-Next-Cursor and X-Prev-Cursor headers, which are only valid with the same order.
+// @Description Deprecated: use /api/v1/rooms, which returns the page in an envelope.
 // @Tags rooms
 // @Accept json
 // @Produce json
 // @Param limit query int false "Maximum number of rooms to return (1-100)"
-// @Param before_id query int false "Return rooms with IDs less than this value (cursor pagination)"
-// @Param order query string false "Ordering key" Enums(created_at desc,created_at asc,last_activity_at desc,last_activity_at asc,id desc,id asc)
+// @Param cursor query string false "Cursor from the X-Next-Cursor or X-Prev-Cursor header"
+// @Param before_id query int false "Deprecated: continue after the room with this ID, use cursor instead"
+// @Param order query string false "Ordering key" Enums(created_at desc,created_at asc,last_active_at desc,last_active_at asc,id desc,id asc)
+// @Param q query string false "Search rooms by name"
+// @Param has_password query bool false "Only rooms with (true) or without (false) a password"
+// @Param active_since query string false "Only rooms active since this RFC 3339 time"
+// @Param min_online query int false "Only rooms with at least this many connected clients"
+// @Param include_total query bool false "Return the number of matching rooms in X-Total-Count"
 // @Success 200 {array} model.Room
-// @Failure 400 {object} model.PublicError "invalid query parameters"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page, absent on the last page"
+// @Header 200 {string} X-Prev-Cursor "Cursor of the previous page, absent on the first page"
+// @Header 200 {int} X-Total-Count "Number of matching rooms, if include_total is set"
+// @Failure 400 {object} model.PublicError "invalid query parameters or cursor"
 // @Failure 500 {object} model.PublicError "internal server error"
 // @Router /rooms [get]
 func (h *RoomHandler) List(c *gin.Context) {
+	page, ok := h.list(c)
+	if !ok {
+		return
+	}
+
+	if page.NextCursor != "" {
+		c.Header(HeaderNextCursor, page.NextCursor)
+	}
+	if page.PrevCursor != "" {
+		c.Header(HeaderPrevCursor, page.PrevCursor)
+	}
+	if page.Total != nil {
+		c.Header(HeaderTotalCount, strconv.Itoa(*page.Total))
+	}
+	c.JSON(http.StatusOK, page.Rooms)
+}
+
+// ListPage returns a page of rooms wrapped in an envelope with its cursors.
+//
+// @Summary List rooms
+// @Description Returns a page of rooms, optionally searched by name and filtered.
+// @Description q matches names by prefix or by similarity. Pages are chained by passing next_cursor
+// @Description or prev_cursor as cursor; a cursor is only valid with the order it was issued for.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param limit query int false "Maximum number of rooms to return (1-100)"
+// @Param cursor query string false "next_cursor or prev_cursor of a previous page"
+// @Param order query string false "Ordering key" Enums(created_at desc,created_at asc,last_active_at desc,last_active_at asc,id desc,id asc)
+// @Param q query string false "Search rooms by name"
+// @Param has_password query bool false "Only rooms with (true) or without (false) a password"
+// @Param active_since query string false "Only rooms active since this RFC 3339 time"
+// @Param min_online query int false "Only rooms with at least this many connected clients"
+// @Param include_total query bool false "Return the number of matching rooms in total"
+// @Success 200 {object} RoomPageResp
+// @Failure 400 {object} model.PublicError "invalid query parameters or cursor"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /api/v1/rooms [get]
+func (h *RoomHandler) ListPage(c *gin.Context) {
+	page, ok := h.list(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, RoomPageResp{
+		Items:      page.Rooms,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+		Total:      page.Total,
+	})
+}
+
+// list binds the listing query and fetches the page; it writes the error response itself.
+func (h *RoomHandler) list(c *gin.Context) (*service.RoomPage, bool) {
 	var q RoomListQuery
 	if err := c.ShouldBindQuery(&q); err != nil {
 		if vErr, as := model.AsValidationError(q, err); as {
 			status, pub := model.ToHTTP(vErr)
 			c.AbortWithStatusJSON(status, pub)
-			return
+			return nil, false
 		} else {
 			status, pub := model.ToHTTP(model.ErrBadRequest)
 			c.AbortWithStatusJSON(status, pub)
-			return
+			return nil, false
+		}
+	}
+
+	filter := model.RoomFilter{
+		Query:       strings.TrimSpace(q.Q),
+		HasPassword: q.HasPassword,
+		ActiveSince: q.ActiveSince,
+	}
+	if q.MinOnline > 0 {
+		filter.IDs = []int64{}
+		for id, online := range h.hub.OnlineCounts() {
+			if online >= q.MinOnline {
+				filter.IDs = append(filter.IDs, id)
+			}
 		}
 	}
 
 	ctx := c.Request.Context()
-	rooms, err := h.s.List(ctx, q.Limit, q.Order, q.BeforeID)
+	page, err := h.s.List(ctx, service.ListRoomsInput{
+		Limit:     q.Limit,
+		Order:     q.Order,
+		Cursor:    q.Cursor,
+		BeforeID:  q.BeforeID,
+		Filter:    filter,
+		WithTotal: q.IncludeTotal,
+	})
 	if err != nil {
 		status, pub := model.ToHTTP(err)
 		c.AbortWithStatusJSON(status, pub)
-		return
+		return nil, false
 	}
-	c.JSON(http.StatusOK, rooms)
+	return page, true
 }
 
 // GetByID returns a room by its ID.
@@ -138,3 +286,102 @@ func (h *RoomHandler) GetByID(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, room)
 }
+
+// Update applies a partial update to a room.
+//
+// @Summary Update a room
+// @Description Updates the name, password, topic, description, avatar, metadata or message retention of a room.
+// @Description Clients in the room receive a "room_updated" event; renames and topic changes are also
+// @Description recorded in the history as system messages. An empty password removes the password.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Current room password, required for protected rooms"
+// @Param request body UpdateRoomReq true "Fields to change"
+// @Success 200 {object} model.Room
+// @Failure 400 {object} model.PublicError "invalid request"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 422 {object} model.PublicError "validation error"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /rooms/{id} [patch]
+func (h *RoomHandler) Update(c *gin.Context) {
+	id, ok := authorizeRoom(c, h.s)
+	if !ok {
+		return
+	}
+
+	var req UpdateRoomReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		} else {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	in := service.UpdateRoomInput{
+		ID:          id,
+		Name:        req.Name,
+		Password:    req.Password,
+		Topic:       req.Topic,
+		Description: req.Description,
+		AvatarURL:   req.AvatarURL,
+		Metadata:    req.Metadata,
+
+		RetentionDays:        req.RetentionDays,
+		RetentionMaxMessages: req.RetentionMaxMessages,
+	}
+	room, err := h.s.Update(ctx, in)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	h.publisher.RoomUpdated(ctx, room, in, "")
+	c.JSON(http.StatusOK, room)
+}
+
+// Restore undoes the soft delete of a room.
+//
+// @Summary Restore a deleted room
+// @Description Restores a soft-deleted room with its history. Rooms can be restored until they are purged for good.
+// @Description Only password-protected rooms can be restored here, with their password; rooms without one are restored
+// @Description by an administrator through POST /admin/rooms/{id}/restore. A system message records the restore.
+// @Tags rooms
+// @Accept json
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Success 200 {object} model.Room
+// @Failure 400 {object} model.PublicError "invalid room ID"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 403 {object} model.PublicError "room has no password"
+// @Failure 404 {object} model.PublicError "no deleted room with this ID, or it can no longer be restored"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /rooms/{id}/restore [post]
+func (h *RoomHandler) Restore(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	ctx := c.Request.Context()
+	room, err := h.s.Restore(ctx, id, c.GetHeader(HeaderRoomPassword))
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	h.publisher.RoomRestored(ctx, room)
+	c.JSON(http.StatusOK, room)
+}
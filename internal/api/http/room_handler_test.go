@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/repository/memory"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/Rasulikus/chat/internal/service/message"
+	"github.com/Rasulikus/chat/internal/service/room"
+	"github.com/Rasulikus/chat/internal/service/webhook"
+	wsruntime "github.com/Rasulikus/chat/internal/ws"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RoomHandler_AvatarURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	cursors, err := cursor.NewCodec("secret")
+	require.NoError(t, err)
+
+	store := memory.NewStore()
+	roomRepo := memory.NewRoomRepository(store)
+	webhooks := webhook.NewService(memory.NewWebhookRepository(store), roomRepo)
+	messages := message.NewService(memory.NewMessageRepository(store), room.NewActivityRecorder(roomRepo), cursors, nil)
+	rooms := room.NewService(roomRepo, memory.NewTransactor(), webhooks, cursors, 0)
+	hub := wsruntime.NewHub()
+	go hub.Run()
+
+	h := NewRoomHandler(rooms, wsruntime.NewPublisher(hub, messages, webhooks), hub)
+	router := gin.New()
+	router.POST("/rooms", h.Create)
+	router.PATCH("/rooms/:id", h.Update)
+
+	r, err := rooms.Create(ctx, service.CreateRoomInput{Name: "general", AvatarURL: "https://example.com/a.png"})
+	require.NoError(t, err)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	testCases := []struct {
+		name       string
+		url        string
+		wantStatus int
+	}{
+		{name: "https", url: "https://example.com/b.png", wantStatus: http.StatusOK},
+		{name: "javascript", url: "javascript:alert(1)", wantStatus: http.StatusUnprocessableEntity},
+		{name: "data", url: "data:image/svg+xml,<svg onload=alert(1)>", wantStatus: http.StatusUnprocessableEntity},
+		{name: "not a URL", url: "avatar.png", wantStatus: http.StatusUnprocessableEntity},
+	}
+	for _, tc := range testCases {
+		t.Run("create "+tc.name, func(t *testing.T) {
+			want := tc.wantStatus
+			if want == http.StatusOK {
+				want = http.StatusCreated
+			}
+			w := do(http.MethodPost, "/rooms", `{"name":"room `+tc.name+`","avatar_url":"`+tc.url+`"}`)
+			assert.Equal(t, want, w.Code, w.Body.String())
+		})
+		t.Run("update "+tc.name, func(t *testing.T) {
+			w := do(http.MethodPatch, "/rooms/"+strconv.FormatInt(r.ID, 10), `{"avatar_url":"`+tc.url+`"}`)
+			assert.Equal(t, tc.wantStatus, w.Code, w.Body.String())
+		})
+	}
+
+	t.Run("update removes avatar", func(t *testing.T) {
+		w := do(http.MethodPatch, "/rooms/"+strconv.FormatInt(r.ID, 10), `{"avatar_url":""}`)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		got, err := rooms.GetByID(ctx, r.ID)
+		require.NoError(t, err)
+		assert.Empty(t, got.AvatarURL)
+	})
+}
@@ -0,0 +1,207 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderRoomPassword carries the room password for endpoints that manage a protected room.
+const HeaderRoomPassword = "X-Room-Password"
+
+type WebhookHandler struct {
+	s     service.WebhookService
+	rooms service.RoomService
+}
+
+func NewWebhookHandler(s service.WebhookService, rooms service.RoomService) *WebhookHandler {
+	return &WebhookHandler{
+		s:     s,
+		rooms: rooms,
+	}
+}
+
+// CreateWebhookReq represents a request payload for subscribing a URL to room events.
+type CreateWebhookReq struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Events []string `json:"events" binding:"omitempty,dive,oneof=message join room_updated room_deleted room_restored"`
+}
+
+// Create handles webhook creation for a room.
+//
+// @Summary Create a room webhook
+// @Description Subscribes a URL to room events. Every event is POSTed as JSON and signed with
+// @Description HMAC-SHA256 over "<X-Chat-Timestamp>.<body>" using the returned secret; the signature
+// @Description is sent in the X-Chat-Signature header as "sha256=<hex>". The secret is only returned once.
+// @Description Failed deliveries are retried with exponential backoff and eventually dead-lettered.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Param request body CreateWebhookReq true "Webhook payload; events defaults to all event types"
+// @Success 201 {object} model.Webhook
+// @Failure 400 {object} model.PublicError "invalid request"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 422 {object} model.PublicError "validation error"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /rooms/{id}/webhooks [post]
+func (h *WebhookHandler) Create(c *gin.Context) {
+	roomID, ok := authorizeRoom(c, h.rooms)
+	if !ok {
+		return
+	}
+
+	var req CreateWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		if vErr, as := model.AsValidationError(req, err); as {
+			status, pub := model.ToHTTP(vErr)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		} else {
+			status, pub := model.ToHTTP(model.ErrBadRequest)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
+
+	hook, err := h.s.Create(c.Request.Context(), service.CreateWebhookInput{
+		RoomID: roomID,
+		URL:    req.URL,
+		Events: req.Events,
+	})
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.JSON(http.StatusCreated, hook)
+}
+
+// List returns the webhooks of a room.
+//
+// @Summary List room webhooks
+// @Description Returns the webhooks subscribed to a room. Secrets are never included.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Success 200 {array} model.Webhook
+// @Failure 400 {object} model.PublicError "invalid room ID"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /rooms/{id}/webhooks [get]
+func (h *WebhookHandler) List(c *gin.Context) {
+	hooks, ok := h.list(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, hooks)
+}
+
+// WebhookPageResp lists the webhooks of a room; all of them fit on one page, so it carries no cursors.
+type WebhookPageResp struct {
+	Items []model.Webhook `json:"items"`
+}
+
+// ListPage returns the webhooks of a room wrapped in an envelope.
+//
+// @Summary List room webhooks
+// @Description Returns the webhooks subscribed to a room. Secrets are never included.
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Room ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Success 200 {object} WebhookPageResp
+// @Failure 400 {object} model.PublicError "invalid room ID"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room not found"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /api/v1/rooms/{id}/webhooks [get]
+func (h *WebhookHandler) ListPage(c *gin.Context) {
+	hooks, ok := h.list(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, WebhookPageResp{Items: hooks})
+}
+
+// list authorizes the room and fetches its webhooks; it writes the error response itself.
+func (h *WebhookHandler) list(c *gin.Context) ([]model.Webhook, bool) {
+	roomID, ok := authorizeRoom(c, h.rooms)
+	if !ok {
+		return nil, false
+	}
+
+	hooks, err := h.s.ListByRoom(c.Request.Context(), roomID)
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return nil, false
+	}
+	return hooks, true
+}
+
+// Delete removes a webhook from a room.
+//
+// @Summary Delete a room webhook
+// @Description Unsubscribes a webhook; pending deliveries are discarded.
+// @Tags webhooks
+// @Param id path int true "Room ID"
+// @Param webhook_id path int true "Webhook ID"
+// @Param X-Room-Password header string false "Room password, required for protected rooms"
+// @Success 204 "No Content"
+// @Failure 400 {object} model.PublicError "invalid ID"
+// @Failure 401 {object} model.PublicError "wrong room password"
+// @Failure 404 {object} model.PublicError "room or webhook not found"
+// @Failure 500 {object} model.PublicError "internal server error"
+// @Router /rooms/{id}/webhooks/{webhook_id} [delete]
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	roomID, ok := authorizeRoom(c, h.rooms)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(c.Param("webhook_id"), 10, 64)
+	if err != nil || id <= 0 {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+
+	if err = h.s.Delete(c.Request.Context(), roomID, id); err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// authorizeRoom parses the room ID from the path and checks the room password header.
+// It writes the error response itself and reports whether the request may proceed.
+func authorizeRoom(c *gin.Context, rooms service.RoomService) (int64, bool) {
+	roomID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || roomID <= 0 {
+		status, pub := model.ToHTTP(model.ErrBadRequest)
+		c.AbortWithStatusJSON(status, pub)
+		return 0, false
+	}
+
+	ok, err := rooms.CheckPassword(c.Request.Context(), roomID, c.GetHeader(HeaderRoomPassword))
+	if err != nil {
+		status, pub := model.ToHTTP(err)
+		c.AbortWithStatusJSON(status, pub)
+		return 0, false
+	}
+	if !ok {
+		status, pub := model.ToHTTP(model.ErrWrongPassword)
+		c.AbortWithStatusJSON(status, pub)
+		return 0, false
+	}
+	return roomID, true
+}
@@ -1,37 +1,53 @@
 package ws
 
 import (
-	"log"
-	"net/http"
-
+	httpapi "github.com/Rasulikus/chat/internal/api/http"
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/origin"
 	"github.com/Rasulikus/chat/internal/service"
 	wsruntime "github.com/Rasulikus/chat/internal/ws"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 )
 
+// Options configures the WebSocket endpoint.
+type Options struct {
+	ReadBufferSize  int
+	WriteBufferSize int
+	// Origins are the cross-site origins browsers may connect from, besides the server's own.
+	Origins *origin.Matcher
+	Client  wsruntime.Options
+}
+
 type WSHandler struct {
 	hub            *wsruntime.Hub
 	roomService    service.RoomService
 	messageService service.MessageService
+	webhookService service.WebhookService
+	botService     service.BotService
+	commands       *wsruntime.CommandRouter
+	upgrader       websocket.Upgrader
+	clientOptions  wsruntime.Options
 }
 
-func NewWSHandler(hub *wsruntime.Hub, roomService service.RoomService, messageService service.MessageService) *WSHandler {
+func NewWSHandler(hub *wsruntime.Hub, roomService service.RoomService, messageService service.MessageService, webhookService service.WebhookService, botService service.BotService, commands *wsruntime.CommandRouter, opts Options) *WSHandler {
 	return &WSHandler{
 		hub:            hub,
 		roomService:    roomService,
 		messageService: messageService,
+		webhookService: webhookService,
+		botService:     botService,
+		commands:       commands,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  opts.ReadBufferSize,
+			WriteBufferSize: opts.WriteBufferSize,
+			CheckOrigin:     opts.Origins.CheckOrigin,
+		},
+		clientOptions: opts.Client,
 	}
 }
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
-}
-
 // HandleWS upgrades the HTTP connection to a WebSocket and attaches the client to the hub.
 //
 // @Summary WebSocket endpoint
@@ -40,32 +56,55 @@ var upgrader = websocket.Upgrader{
 // @Description
 // @Description WebSocket message protocol (JSON):
 // @Description   Incoming events:
-// @Description     - type: "join" | "message" | "load_history"
+// @Description     - type: "join" | "message" | "load_history" | "command_reply"
 // @Description     - room_id: number (for "join")
 // @Description     - nick: string (for "join")
 // @Description     - password: string (for "join")
-// @Description     - text: string (for "message")
-// @Description     - before_id: number (for "load_history")
+// @Description     - text: string (for "message", "command_reply"); "/name args" runs a slash command, "//" escapes the slash
+// @Description     - cursor: string (for "load_history"; next_cursor or prev_cursor of an earlier page, omit for the newest messages)
+// @Description     - before_id: number (for "load_history"; deprecated, use cursor; returns the messages right before it, before cursors it returned the oldest ones)
+// @Description     - invocation_id: string (for "command_reply", bots only)
+// @Description     - ephemeral: bool (for "command_reply"; true shows the reply to the invoker only)
 // @Description
 // @Description   Outgoing events:
-// @Description     - type: "message" | "history" | "join" | "error"
+// @Description     - type: "message" | "history" | "join" | "error" | "system" | "command" | "nick" | "room_updated" | "kick"
 // @Description     - room_id: number
 // @Description     - nick: string
-// @Description     - message: Message (for "message")
-// @Description     - messages: Message[] (for "load_history")
-// @Description     - text: string (for "error")
+// @Description     - old_nick: string (for "nick")
+// @Description     - message: Message (for "message"); message.kind is "user", "bot" or "system", system messages have no nick
+// @Description     - messages: Message[] (for "load_history"), oldest first
+// @Description     - next_cursor, prev_cursor: string (for "load_history"; lead to older and newer messages)
+// @Description     - command: CommandInvocation (for "command", bots only)
+// @Description     - room: Room (for "room_updated")
+// @Description     - text: string (for "error", "system", "kick"); "system" notices target one user and are never stored
+// @Description
+// @Description Servers running with TLS accept the WebSocket at wss:// only.
+// @Description Bots authenticate with "Authorization: Bearer <api key>" or "?api_key=<api key>".
 // @Tags ws
 // @Produce json
+// @Param api_key query string false "Bot API key"
 // @Success 101 "Switching Protocols"
+// @Failure 401 {object} model.PublicError "invalid bot API key"
 // @Router /ws [get]
 func (h *WSHandler) HandleWS(c *gin.Context) {
+	var bot *model.Bot
+	if apiKey := httpapi.APIKey(c.Request); apiKey != "" {
+		var err error
+		bot, err = h.botService.Authenticate(c.Request.Context(), apiKey)
+		if err != nil {
+			status, pub := model.ToHTTP(err)
+			c.AbortWithStatusJSON(status, pub)
+			return
+		}
+	}
 
-	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Println("HandleWS: upgrader.Upgrade error:", err)
+		logging.FromContext(c.Request.Context()).Warn("HandleWS: upgrader.Upgrade", "err", err)
 		return
 	}
 
-	client := wsruntime.NewClient(h.hub, conn, h.roomService, h.messageService)
+	client := wsruntime.NewClient(c.Request.Context(), h.hub, conn, h.roomService, h.messageService, h.webhookService, h.commands, h.clientOptions)
+	client.Bot = bot
 	client.Start()
 }
@@ -2,85 +2,193 @@ package app
 
 import (
 	"context"
-	"log"
-	"os"
-	"os/signal"
-	"syscall"
+	"errors"
+	"io"
+	"log/slog"
 	"time"
 
 	"github.com/Rasulikus/chat/internal/api/http"
 	"github.com/Rasulikus/chat/internal/api/ws"
 	"github.com/Rasulikus/chat/internal/config"
-	"github.com/Rasulikus/chat/internal/repository"
-	messageRepo "github.com/Rasulikus/chat/internal/repository/message"
-	roomRepo "github.com/Rasulikus/chat/internal/repository/room"
-	"github.com/Rasulikus/chat/internal/service"
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/metrics"
+	"github.com/Rasulikus/chat/internal/origin"
+	"github.com/Rasulikus/chat/internal/scheduler"
+	"github.com/Rasulikus/chat/internal/service/bot"
+	"github.com/Rasulikus/chat/internal/service/incominghook"
 	"github.com/Rasulikus/chat/internal/service/message"
 	"github.com/Rasulikus/chat/internal/service/room"
+	"github.com/Rasulikus/chat/internal/service/webhook"
+	"github.com/Rasulikus/chat/internal/tracing"
 	wsruntime "github.com/Rasulikus/chat/internal/ws"
 	"github.com/gin-gonic/gin"
+	"github.com/uptrace/bun"
 )
 
-// App initializes the application dependencies, configures routes, and returns the Gin engine.
-func App(cfg *config.Config) *gin.Engine {
+// App initializes the application dependencies, configures routes, and returns the Gin engine
+// together with a closer for the storage, which the caller closes once the server has shut down.
+// Background jobs run until ctx is cancelled; from then on readiness reports the server as draining. Requests, connections and jobs log through logger.
+func App(ctx context.Context, cfg *config.Config, logger *slog.Logger) (_ *gin.Engine, _ io.Closer, err error) {
+	ctx = logging.WithContext(ctx, logger)
 
-	db, err := repository.NewClient(cfg)
+	store, err := newStorage(ctx, cfg)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = store.Close()
+		}
+	}()
+	if store.db == nil {
+		logger.Warn("storage is in memory, data is lost on restart and not shared between replicas")
 	}
 
-	roomRepository := roomRepo.NewRepository(db.DB)
-	roomService := room.NewService(roomRepository)
-	roomHandler := http.NewRoomHandler(roomService)
+	cursors, err := cursor.NewCodec(cfg.API.CursorSecret)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.API.CursorSecret == "" {
+		logger.Warn("cursor secret is not set, pagination cursors will expire on restart")
+	}
+
+	webhookService := webhook.NewService(store.webhooks, store.rooms)
 
-	msgRepository := messageRepo.NewRepository(db.DB)
-	msgService := message.NewService(msgRepository)
+	roomService := room.NewService(store.rooms, store.tx, webhookService, cursors, cfg.Cleanup.PurgeAfter)
+	webhookHandler := http.NewWebhookHandler(webhookService, roomService)
+
+	activity := room.NewActivityRecorder(store.rooms)
+	go activity.Run(ctx, cfg.Cleanup.ActivityFlushInterval)
+	history := message.NewHistoryCache(cfg.HistoryCache.Rooms, cfg.HistoryCache.Messages, cfg.HistoryCache.TTL)
+	msgService := message.NewService(store.messages, activity, cursors, history)
+	msgHandler := http.NewMessageHandler(msgService, roomService)
 
 	hub := wsruntime.NewHub()
 	go hub.Run()
+	if err = metrics.RegisterHub(hub.Stats); err != nil {
+		return nil, nil, err
+	}
+	healthHandler := http.NewHealthHandler(newHealthChecker(ctx, cfg, store.db, hub))
+	publisher := wsruntime.NewPublisher(hub, msgService, webhookService)
 
-	wsHandler := ws.NewWSHandler(hub, roomService, msgService)
+	roomHandler := http.NewRoomHandler(roomService, publisher, hub)
+
+	incomingHookService := incominghook.NewService(store.incomingHooks, store.rooms, msgService)
+	incomingHookHandler := http.NewIncomingWebhookHandler(incomingHookService, roomService, publisher)
+
+	botService := bot.NewService(store.bots)
+	botHandler := http.NewBotHandler(botService)
+
+	commands := wsruntime.NewCommandRouter(hub, publisher, roomService, botService)
+	origins, err := origin.NewMatcher(cfg.HTTP.AllowedOrigins)
+	if err != nil {
+		return nil, nil, err
+	}
+	wsHandler := ws.NewWSHandler(hub, roomService, msgService, webhookService, botService, commands, ws.Options{
+		ReadBufferSize:  cfg.WS.ReadBufferSize,
+		WriteBufferSize: cfg.WS.WriteBufferSize,
+		Origins:         origins,
+		Client: wsruntime.Options{
+			SendBuffer:      cfg.WS.SendBuffer,
+			HistoryPageSize: cfg.WS.HistoryPageSize,
+		},
+	})
+
+	// Advisory locks are a Postgres feature; SQLite and in-memory storage serve a single replica, so jobs run without a lock.
+	var lockDB *bun.DB
+	if cfg.DB.Storage == config.StoragePostgres {
+		lockDB = store.db.DB
+	}
+	jobs := scheduler.New(lockDB, store.jobRuns)
+	if err = registerJobs(jobs, cfg.Cleanup, roomService, msgService, store.jobRuns); err != nil {
+		return nil, nil, err
+	}
+	jobs.Start(ctx)
+	adminHandler := http.NewAdminHandler(jobs, hub, publisher, roomService, cfg.Admin.Token)
+	if cfg.Admin.Token == "" {
+		logger.Warn("admin token is not set, the admin API is disabled")
+	}
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
-	defer stop()
-	startRoomCleanup(ctx, roomService)
+	deliverer := webhook.NewDeliverer(store.webhooks, webhook.DefaultDelivererOptions())
+	go deliverer.Run(ctx)
 
-	router := gin.Default()
+	router := gin.New()
+	router.Use(tracing.Gin(), logging.Gin(logger), logging.Recovery(), metrics.Gin())
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	router.GET("/healthz", healthHandler.Live)
+	router.GET("/readyz", healthHandler.Ready)
 
-	roomApi := router.Group("/rooms")
+	// Unversioned routes are kept for existing clients; /api/v1 returns paginated listings in envelopes.
+	cors := origin.CORS(origins)
+	roomApi := router.Group("/rooms", cors)
 	{
-		roomApi.POST("", roomHandler.Create)
+		origin.Preflight(roomApi)
 		roomApi.GET("", roomHandler.List)
-		roomApi.GET("/:id", roomHandler.GetByID)
+		roomApi.GET("/:id/webhooks", webhookHandler.List)
+		roomApi.GET("/:id/incoming-webhooks", incomingHookHandler.List)
+		roomRoutes(roomApi, roomHandler, webhookHandler, incomingHookHandler)
+	}
+	v1 := router.Group("/api/v1")
+	{
+		v1Rooms := v1.Group("/rooms", cors)
+		origin.Preflight(v1Rooms)
+		v1Rooms.GET("", roomHandler.ListPage)
+		v1Rooms.GET("/:id/messages", msgHandler.List)
+		v1Rooms.GET("/:id/webhooks", webhookHandler.ListPage)
+		v1Rooms.GET("/:id/incoming-webhooks", incomingHookHandler.ListPage)
+		roomRoutes(v1Rooms, roomHandler, webhookHandler, incomingHookHandler)
+	}
+	hookApi := router.Group("/hooks")
+	{
+		hookApi.POST("/:token", incomingHookHandler.Post)
+	}
+	botApi := router.Group("/bot", botHandler.Authenticate)
+	{
+		botApi.GET("/commands", botHandler.ListCommands)
+		botApi.PUT("/commands", botHandler.SetCommands)
+	}
+	adminApi := router.Group("/admin", adminHandler.Authenticate)
+	{
+		adminApi.GET("/jobs", adminHandler.Jobs)
+		adminApi.GET("/rooms", adminHandler.Rooms)
+		adminApi.DELETE("/rooms/:id", adminHandler.DeleteRoom)
+		adminApi.POST("/rooms/:id/restore", adminHandler.RestoreRoom)
+		adminApi.DELETE("/clients/:conn_id", adminHandler.DisconnectClient)
+		adminApi.POST("/announcements", adminHandler.Announce)
+		adminApi.POST("/bots", botHandler.Create)
 	}
 	wsApi := router.Group("/ws")
 	{
 		wsApi.GET("", wsHandler.HandleWS)
 	}
 
-	return router
+	return router, &closer{activity: activity, store: store}, nil
 }
 
-// startRoomCleanup launches a background job that periodically soft deletes inactive rooms.
-func startRoomCleanup(ctx context.Context, roomService service.RoomService) {
-	ticker := time.NewTicker(time.Hour)
-	go func() {
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				affected, err := roomService.SoftDeleteInactiveOlderThan(context.Background(), 7*24*time.Hour)
-				if err != nil {
-					log.Println("room cleanup error:", err)
-					continue
-				}
-				if affected > 0 {
-					log.Printf("room cleanup: soft-deleted %d inactive rooms\n", affected)
-				}
-			}
-		}
-	}()
+// closer writes the room activity recorded while the server drained and then closes the storage.
+type closer struct {
+	activity *room.ActivityRecorder
+	store    *storage
+}
+
+func (c *closer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := c.activity.Flush(ctx)
+	return errors.Join(err, c.store.Close())
+}
+
+// roomRoutes registers the room routes shared by every API version.
+func roomRoutes(g *gin.RouterGroup, roomHandler *http.RoomHandler, webhookHandler *http.WebhookHandler, incomingHookHandler *http.IncomingWebhookHandler) {
+	g.POST("", roomHandler.Create)
+	g.GET("/:id", roomHandler.GetByID)
+	g.PATCH("/:id", roomHandler.Update)
+	g.POST("/:id/restore", roomHandler.Restore)
+
+	g.POST("/:id/webhooks", webhookHandler.Create)
+	g.DELETE("/:id/webhooks/:webhook_id", webhookHandler.Delete)
+
+	g.POST("/:id/incoming-webhooks", incomingHookHandler.Create)
+	g.DELETE("/:id/incoming-webhooks/:hook_id", incomingHookHandler.Delete)
 }
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/config"
+	"github.com/Rasulikus/chat/internal/health"
+	"github.com/Rasulikus/chat/internal/migrator"
+	"github.com/Rasulikus/chat/internal/repository"
+	wsruntime "github.com/Rasulikus/chat/internal/ws"
+)
+
+const (
+	readyTimeout = 2 * time.Second
+	// migrationsCheckTTL spares the database a migrate connection on every probe; the schema rarely changes under a running server.
+	migrationsCheckTTL = time.Minute
+)
+
+// newHealthChecker registers the readiness checks of the server; it reports draining once ctx is cancelled.
+// The database checks are left out when db is nil, as with in-memory storage.
+func newHealthChecker(ctx context.Context, cfg *config.Config, db *repository.DB, hub *wsruntime.Hub) *health.Checker {
+	checker := health.New(readyTimeout)
+	if db != nil {
+		checker.Add("db", db.DB.PingContext)
+		checker.Add("migrations", health.CacheSuccess(migrationsCheckTTL, migrationsCheck(cfg.DB.MigrateURL())))
+	}
+	checker.Add("hub", hub.Ping)
+
+	go func() {
+		<-ctx.Done()
+		checker.Drain()
+	}()
+	return checker
+}
+
+// migrationsCheck fails unless the database schema is at the newest migration and not dirty.
+func migrationsCheck(dsn string) health.Check {
+	return func(context.Context) error {
+		opts := migrator.Options{DSN: dsn}
+		want, err := migrator.LatestVersion(opts)
+		if err != nil {
+			return err
+		}
+		got, dirty, err := migrator.Version(opts)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("schema version %d is dirty", got)
+		}
+		if got != want {
+			return fmt.Errorf("schema version %d, want %d", got, want)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/config"
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/Rasulikus/chat/internal/scheduler"
+	"github.com/Rasulikus/chat/internal/service"
+)
+
+// registerJobs adds the background jobs of the server to the scheduler.
+func registerJobs(s *scheduler.Scheduler, cfg config.CleanupConfig, roomService service.RoomService, messageService service.MessageService, jobRuns repository.JobRunRepository) error {
+	cleanupSchedule := scheduler.Every(cfg.Interval)
+	if cfg.Schedule != "" {
+		var err error
+		cleanupSchedule, err = scheduler.Cron(cfg.Schedule)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.Add(scheduler.Job{
+		Name:     "room_cleanup",
+		Schedule: cleanupSchedule,
+		Run:      roomCleanup(cfg, roomService),
+	})
+	s.Add(scheduler.Job{
+		Name:     "message_retention",
+		Schedule: scheduler.Every(cfg.RetentionInterval),
+		Run:      messageRetention(messageService, cfg.RetentionBatchSize),
+	})
+	s.Add(scheduler.Job{
+		Name:     "job_runs_cleanup",
+		Schedule: scheduler.Every(24 * time.Hour),
+		Run:      jobRunsCleanup(jobRuns, cfg.JobRunsKeep),
+	})
+	return nil
+}
+
+// roomCleanup soft deletes inactive rooms, then purges rooms that were soft deleted longer than the restore window.
+func roomCleanup(cfg config.CleanupConfig, roomService service.RoomService) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		affected, err := roomService.SoftDeleteInactiveOlderThan(ctx, cfg.InactiveAfter)
+		if err != nil {
+			return err
+		}
+		if affected > 0 {
+			logging.FromContext(ctx).Info("room cleanup: soft-deleted inactive rooms", "count", affected)
+		}
+
+		purged, err := roomService.PurgeDeleted(ctx, cfg.PurgeAfter)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			logging.FromContext(ctx).Info("room cleanup: purged deleted rooms", "count", purged)
+		}
+		return nil
+	}
+}
+
+// messageRetention purges messages outside their room's retention policy.
+func messageRetention(messageService service.MessageService, batchSize int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		purged, err := messageService.PurgeRetention(ctx, batchSize)
+		if err != nil {
+			return err
+		}
+		if purged > 0 {
+			logging.FromContext(ctx).Info("message retention: purged messages", "count", purged)
+		}
+		return nil
+	}
+}
+
+// jobRunsCleanup keeps the job run history bounded by deleting runs older than keep.
+func jobRunsCleanup(jobRuns repository.JobRunRepository, keep time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := jobRuns.DeleteOlderThan(ctx, keep)
+		return err
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Rasulikus/chat/internal/config"
+	"github.com/Rasulikus/chat/internal/migrator"
+	"github.com/Rasulikus/chat/internal/repository"
+	botRepo "github.com/Rasulikus/chat/internal/repository/bot"
+	incomingHookRepo "github.com/Rasulikus/chat/internal/repository/incominghook"
+	jobRunRepo "github.com/Rasulikus/chat/internal/repository/jobrun"
+	"github.com/Rasulikus/chat/internal/repository/memory"
+	messageRepo "github.com/Rasulikus/chat/internal/repository/message"
+	roomRepo "github.com/Rasulikus/chat/internal/repository/room"
+	"github.com/Rasulikus/chat/internal/repository/sqlite"
+	webhookRepo "github.com/Rasulikus/chat/internal/repository/webhook"
+)
+
+// storage holds the repositories of the configured backend.
+type storage struct {
+	rooms         repository.RoomRepository
+	messages      repository.MessageRepository
+	webhooks      repository.WebhookRepository
+	incomingHooks repository.IncomingWebhookRepository
+	bots          repository.BotRepository
+	jobRuns       repository.JobRunRepository
+	tx            repository.Transactor
+
+	// db is the Postgres or SQLite database; it is nil with in-memory storage.
+	db *repository.DB
+}
+
+// newStorage opens the backend selected by cfg.DB.Storage, migrating the database first when auto-migration is on.
+func newStorage(ctx context.Context, cfg *config.Config) (*storage, error) {
+	if cfg.DB.Storage == config.StorageMemory {
+		s := memory.NewStore()
+		return &storage{
+			rooms:         memory.NewRoomRepository(s),
+			messages:      memory.NewMessageRepository(s),
+			webhooks:      memory.NewWebhookRepository(s),
+			incomingHooks: memory.NewIncomingWebhookRepository(s),
+			bots:          memory.NewBotRepository(s),
+			jobRuns:       memory.NewJobRunRepository(s),
+			tx:            memory.NewTransactor(),
+		}, nil
+	}
+
+	db, err := repository.NewClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.DB.AutoMigrate {
+		if err = migrator.Up(migrator.Options{DSN: cfg.DB.MigrateURL()}); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("migrate: %w", err)
+		}
+	}
+	if cfg.DB.Storage == config.StorageSQLite {
+		// Bots and incoming webhooks run the queries of the Postgres repositories, which SQLite understands.
+		return &storage{
+			rooms:         sqlite.NewRoomRepository(db.DB),
+			messages:      sqlite.NewMessageRepository(db.DB),
+			webhooks:      sqlite.NewWebhookRepository(db.DB),
+			incomingHooks: incomingHookRepo.NewRepository(db.DB),
+			bots:          botRepo.NewRepository(db.DB),
+			jobRuns:       sqlite.NewJobRunRepository(db.DB),
+			tx:            repository.NewTransactor(db.DB),
+			db:            db,
+		}, nil
+	}
+	return &storage{
+		rooms:         roomRepo.NewRepository(db.DB),
+		messages:      messageRepo.NewRepository(db.DB),
+		webhooks:      webhookRepo.NewRepository(db.DB),
+		incomingHooks: incomingHookRepo.NewRepository(db.DB),
+		bots:          botRepo.NewRepository(db.DB),
+		jobRuns:       jobRunRepo.NewRepository(db.DB),
+		tx:            repository.NewTransactor(db.DB),
+		db:            db,
+	}, nil
+}
+
+// Close closes the database, if there is one.
+func (s *storage) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
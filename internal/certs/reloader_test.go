@@ -0,0 +1,137 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/certs/certstest"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve starts an HTTPS server presenting the reloader's certificate, with a WebSocket echo handler at /ws.
+func serve(t *testing.T, r *Reloader) string {
+	t.Helper()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", r.TLSConfig())
+	require.NoError(t, err)
+
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		typ, msg, err := conn.ReadMessage()
+		if err == nil {
+			_ = conn.WriteMessage(typ, msg)
+		}
+	})
+	server := &http.Server{Handler: mux}
+	go func() { _ = server.Serve(ln) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	return ln.Addr().String()
+}
+
+// trust returns a client configuration trusting the given self-signed certificates.
+func trust(t *testing.T, certFiles ...string) *tls.Config {
+	t.Helper()
+
+	pool := x509.NewCertPool()
+	for _, file := range certFiles {
+		pem, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.True(t, pool.AppendCertsFromPEM(pem))
+	}
+	return &tls.Config{RootCAs: pool}
+}
+
+// servedName returns the common name of the certificate the server presents to a new connection.
+func servedName(t *testing.T, addr string, cfg *tls.Config) string {
+	t.Helper()
+
+	conn, err := tls.Dial("tcp", addr, cfg)
+	require.NoError(t, err)
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+}
+
+// touch moves the modification time forward so that a rewrite within the same clock tick is noticed.
+func touch(t *testing.T, files ...string) {
+	t.Helper()
+	later := time.Now().Add(time.Minute)
+	for _, file := range files {
+		require.NoError(t, os.Chtimes(file, later, later))
+	}
+}
+
+func Test_Reloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := certstest.Write(t, dir, "server", "first")
+	firstPEM, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+
+	r, err := NewReloader(certFile, keyFile)
+	require.NoError(t, err)
+	addr := serve(t, r)
+
+	first := filepath.Join(dir, "first.crt")
+	require.NoError(t, os.WriteFile(first, firstPEM, 0o600))
+
+	t.Run("serves the loaded certificate", func(t *testing.T) {
+		assert.Equal(t, "first", servedName(t, addr, trust(t, first)))
+
+		reloaded, err := r.Reload()
+		require.NoError(t, err)
+		assert.False(t, reloaded)
+	})
+
+	t.Run("picks up renewed files", func(t *testing.T) {
+		certstest.Write(t, dir, "server", "second")
+		touch(t, certFile, keyFile)
+
+		reloaded, err := r.Reload()
+		require.NoError(t, err)
+		assert.True(t, reloaded)
+		assert.Equal(t, "second", servedName(t, addr, trust(t, certFile)))
+	})
+
+	t.Run("keeps the certificate when the new files are broken", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+		touch(t, keyFile)
+
+		reloaded, err := r.Reload()
+		assert.Error(t, err)
+		assert.False(t, reloaded)
+		assert.Equal(t, "second", servedName(t, addr, trust(t, certFile)))
+	})
+
+	t.Run("wss", func(t *testing.T) {
+		dialer := websocket.Dialer{TLSClientConfig: trust(t, certFile), HandshakeTimeout: 5 * time.Second}
+		conn, _, err := dialer.Dial("wss://"+addr+"/ws", nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+		_, msg, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(msg))
+	})
+
+	t.Run("old certificate is no longer served", func(t *testing.T) {
+		_, err := tls.Dial("tcp", addr, trust(t, first))
+		assert.Error(t, err)
+	})
+
+	_, err = NewReloader(filepath.Join(dir, "missing.crt"), keyFile)
+	assert.Error(t, err)
+}
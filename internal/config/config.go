@@ -1,72 +1,319 @@
 package config
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
-	"log"
+	"net"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/Rasulikus/chat/internal/origin"
 )
 
-const (
-	keyHTTPHost, defaultHTTPHost = "HTTP_HOST", "localhost"
-	keyHTTPPort, defaultHTTPPort = "HTTP_PORT", "8081"
+// Config is the server configuration. Every field has a key in the config file (sections by struct, like "http.port"),
+// an environment variable and a command-line flag named after the key; see Load for their precedence.
+type Config struct {
+	HTTP         HTTPConfig         `key:"http"`
+	DB           DBConfig           `key:"db"`
+	WS           WSConfig           `key:"ws"`
+	HistoryCache HistoryCacheConfig `key:"history_cache"`
+	API          APIConfig          `key:"api"`
+	Cleanup      CleanupConfig      `key:"cleanup"`
+	Log          LogConfig          `key:"log"`
+	Tracing      TracingConfig      `key:"tracing"`
+	Admin        AdminConfig        `key:"admin"`
+}
 
-	keyDBHost, defaultDBHost = "DB_HOST", "localhost"
-	keyDBPort, defaultDBPort = "DB_PORT", "5432"
-	keyDBUser, defaultDBUser = "DB_USER", "admin"
-	keyDBPass, defaultDBPass = "DB_PASS", "mypassword"
-	keyDBName, defaultDBName = "DB_NAME", "chat"
+type HTTPConfig struct {
+	Host string `key:"host" env:"HTTP_HOST" help:"HTTP listen host"`
+	Port string `key:"port" env:"HTTP_PORT" help:"HTTP listen port"`
+	// DrainDelay is how long the server keeps serving after a stop signal while readiness reports draining.
+	DrainDelay time.Duration `key:"drain_delay" env:"HTTP_DRAIN_DELAY" help:"time to keep serving after a stop signal while /readyz reports draining"`
+	TLS        TLSConfig     `key:"tls"`
+	// AllowedOrigins lists the cross-site origins browsers may use the /rooms API and WebSockets from;
+	// "*" allows any and "https://*.example.com" allows subdomains. Pages on the server's own origin are always allowed.
+	AllowedOrigins []string `key:"allowed_origins" env:"HTTP_ALLOWED_ORIGINS" help:"comma-separated cross-site origins allowed to call /rooms and open WebSockets, * for any, https://*.example.com for subdomains"`
+}
 
-	LogDefaultValue = "%s is missing, using default value"
-)
+// TLSConfig enables HTTPS when both files are set.
+type TLSConfig struct {
+	CertFile string `key:"cert_file" env:"TLS_CERT_FILE" help:"PEM certificate chain for HTTPS"`
+	KeyFile  string `key:"key_file" env:"TLS_KEY_FILE" help:"PEM private key for HTTPS"`
+	// ReloadInterval is how often the files are checked for a renewed certificate.
+	ReloadInterval time.Duration `key:"reload_interval" env:"TLS_RELOAD_INTERVAL" help:"how often the certificate files are checked for changes"`
+}
 
-type Config struct {
-	HTTP HTTPConfig
-	DB   DBConfig
+// Enabled reports whether the server should serve HTTPS.
+func (cfg TLSConfig) Enabled() bool {
+	return cfg.CertFile != "" && cfg.KeyFile != ""
 }
 
+// Storage backends selectable with DBConfig.Storage.
+const (
+	StoragePostgres = "postgres"
+	// StorageSQLite keeps data in a single SQLite file; it suits one replica and needs no database server.
+	StorageSQLite = "sqlite"
+	// StorageMemory keeps all data in process memory; it needs no database and loses everything on restart.
+	StorageMemory = "memory"
+)
+
 type DBConfig struct {
-	Host string
-	Port string
-	User string
-	Pass string
-	Name string
+	// Storage selects the backend; the Postgres settings below are ignored with SQLite and in-memory storage.
+	Storage string `key:"storage" env:"STORAGE" help:"storage backend: postgres, sqlite, or memory for tests and demos"`
+	// SQLitePath is the database file of SQLite storage; it is created on first start.
+	SQLitePath string `key:"sqlite_path" env:"DB_SQLITE_PATH" help:"SQLite database file"`
+
+	Host string `key:"host" env:"DB_HOST" help:"Postgres host"`
+	Port string `key:"port" env:"DB_PORT" help:"Postgres port"`
+	User string `key:"user" env:"DB_USER" help:"Postgres user"`
+	Pass string `key:"password" env:"DB_PASS" help:"Postgres password"`
+	Name string `key:"name" env:"DB_NAME" help:"Postgres database"`
+	// AutoMigrate applies pending migrations before the server starts; replicas starting together wait on a migration lock.
+	AutoMigrate bool `key:"auto_migrate" env:"DB_AUTO_MIGRATE" help:"apply pending migrations on start"`
+
+	MaxOpenConns    int           `key:"max_open_conns" env:"DB_MAX_OPEN_CONNS" help:"maximum open connections, 0 for no limit"`
+	MaxIdleConns    int           `key:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" help:"maximum idle connections kept in the pool"`
+	ConnMaxLifetime time.Duration `key:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" help:"maximum age of a connection, 0 for no limit"`
+	ConnMaxIdleTime time.Duration `key:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" help:"maximum idle time of a connection, 0 for no limit"`
+	// StatementTimeout cancels server queries running longer; migrations are not limited by it.
+	StatementTimeout time.Duration `key:"statement_timeout" env:"DB_STATEMENT_TIMEOUT" help:"cancel queries running longer than this, 0 for no limit"`
+	// ConnectTimeout is how long startup waits for the database to become reachable.
+	ConnectTimeout time.Duration `key:"connect_timeout" env:"DB_CONNECT_TIMEOUT" help:"how long startup retries connecting to the database"`
+
+	// SSLMode follows libpq: disable, allow, prefer, require, verify-ca or verify-full.
+	SSLMode     string `key:"sslmode" env:"DB_SSLMODE" help:"Postgres sslmode: disable, allow, prefer, require, verify-ca or verify-full"`
+	SSLRootCert string `key:"sslrootcert" env:"DB_SSLROOTCERT" help:"PEM CA certificates verifying the Postgres server"`
+	SSLCert     string `key:"sslcert" env:"DB_SSLCERT" help:"PEM client certificate for Postgres"`
+	SSLKey      string `key:"sslkey" env:"DB_SSLKEY" help:"PEM client key for Postgres"`
+}
+
+// MigrateURL returns the URL migrations connect with: the SQLite file with SQLite storage, PostgresURL otherwise.
+func (cfg *DBConfig) MigrateURL() string {
+	if cfg.Storage == StorageSQLite {
+		return "sqlite://" + cfg.SQLitePath
+	}
+	return cfg.PostgresURL()
 }
 
+// PostgresURL returns a libpq-style connection URL; an empty SSLMode means "disable".
 func (cfg *DBConfig) PostgresURL() string {
-	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", cfg.User, cfg.Pass, cfg.Host, cfg.Port, cfg.Name)
+	q := url.Values{}
+	q.Set("sslmode", cmp.Or(cfg.SSLMode, "disable"))
+	for key, value := range map[string]string{"sslrootcert": cfg.SSLRootCert, "sslcert": cfg.SSLCert, "sslkey": cfg.SSLKey} {
+		if value != "" {
+			q.Set(key, value)
+		}
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Pass),
+		Host:     net.JoinHostPort(cfg.Host, cfg.Port),
+		Path:     "/" + cfg.Name,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
 }
 
-type HTTPConfig struct {
-	Host string
-	Port string
+// WSConfig tunes WebSocket connections.
+type WSConfig struct {
+	ReadBufferSize  int `key:"read_buffer_size" env:"WS_READ_BUFFER_SIZE" help:"WebSocket read buffer in bytes"`
+	WriteBufferSize int `key:"write_buffer_size" env:"WS_WRITE_BUFFER_SIZE" help:"WebSocket write buffer in bytes"`
+	// SendBuffer is the number of outgoing events queued per client before it is disconnected as too slow.
+	SendBuffer      int `key:"send_buffer" env:"WS_SEND_BUFFER" help:"outgoing events queued per client before it is disconnected"`
+	HistoryPageSize int `key:"history_page_size" env:"WS_HISTORY_PAGE_SIZE" help:"messages per load_history page"`
+}
+
+// HistoryCacheConfig sizes the in-memory cache of the latest messages of recently read rooms, which serves the first page of history
+// without a database query. Entries are reloaded after TTL, which bounds how long messages written by other replicas can be missing.
+type HistoryCacheConfig struct {
+	Rooms    int           `key:"rooms" env:"HISTORY_CACHE_ROOMS" help:"rooms whose latest messages are cached in memory, 0 disables the cache"`
+	Messages int           `key:"messages" env:"HISTORY_CACHE_MESSAGES" help:"latest messages cached per room"`
+	TTL      time.Duration `key:"ttl" env:"HISTORY_CACHE_TTL" help:"how long cached history is served before it is reloaded, 0 for no limit"`
 }
 
-func getEnv(key, defaultValue string) string {
-	value, ok := os.LookupEnv(key)
-	if !ok || value == "" {
-		log.Printf(LogDefaultValue, key)
-		return defaultValue
+type APIConfig struct {
+	// CursorSecret signs pagination cursors; when empty a random key is used and cursors expire on restart.
+	// Replicas behind one load balancer must share it.
+	CursorSecret string `key:"cursor_secret" env:"CURSOR_SECRET" help:"key signing pagination cursors, shared by all replicas"`
+}
+
+type AdminConfig struct {
+	// Token is the bearer token of the /admin API; when empty the API rejects every request.
+	Token string `key:"token" env:"ADMIN_TOKEN" help:"bearer token of the /admin API, empty disables it"`
+}
+
+// CleanupConfig controls the background cleanup jobs. Rooms inactive for InactiveAfter are soft deleted,
+// can be restored for PurgeAfter, and are then deleted for good along with their messages.
+// The room job runs every Interval, or on the cron Schedule if one is set.
+type CleanupConfig struct {
+	Interval      time.Duration `key:"interval" env:"ROOM_CLEANUP_INTERVAL" help:"room cleanup period"`
+	Schedule      string        `key:"schedule" env:"ROOM_CLEANUP_SCHEDULE" help:"room cleanup cron schedule, overrides the interval"`
+	InactiveAfter time.Duration `key:"inactive_after" env:"ROOM_INACTIVE_AFTER" help:"inactivity after which a room is soft deleted"`
+	PurgeAfter    time.Duration `key:"purge_after" env:"ROOM_PURGE_AFTER" help:"time a deleted room can be restored before it is purged"`
+	// ActivityFlushInterval is how often room activity from messages, coalesced in memory, is written to the database.
+	ActivityFlushInterval time.Duration `key:"activity_flush_interval" env:"ROOM_ACTIVITY_FLUSH_INTERVAL" help:"how often room activity from messages is written"`
+
+	RetentionInterval  time.Duration `key:"retention_interval" env:"MESSAGE_RETENTION_INTERVAL" help:"message retention purge period"`
+	RetentionBatchSize int           `key:"retention_batch_size" env:"MESSAGE_RETENTION_BATCH_SIZE" help:"messages deleted per retention batch"`
+
+	// JobRunsKeep is how long the history of background job runs is kept.
+	JobRunsKeep time.Duration `key:"job_runs_keep" env:"JOB_RUNS_KEEP" help:"how long background job runs are kept"`
+}
+
+// LogConfig controls the structured logger; SQL enables logging of every database query at debug level.
+type LogConfig struct {
+	Level  string `key:"level" env:"LOG_LEVEL" help:"log level: debug, info, warn or error"`
+	Format string `key:"format" env:"LOG_FORMAT" help:"log format: json or text"`
+	SQL    bool   `key:"sql" env:"LOG_SQL" help:"log SQL queries at debug level"`
+}
+
+// TracingConfig selects where spans are exported: "none", "stdout" or "otlp" (OTLP over HTTP to OTLPEndpoint).
+// SampleRatio is the share of new traces that are recorded; traces started by callers follow their sampling decision.
+type TracingConfig struct {
+	Exporter     string  `key:"exporter" env:"TRACING_EXPORTER" help:"span exporter: none, stdout or otlp"`
+	OTLPEndpoint string  `key:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" help:"OTLP/HTTP collector URL"`
+	SampleRatio  float64 `key:"sample_ratio" env:"TRACING_SAMPLE_RATIO" help:"share of new traces recorded, 0 to 1"`
+}
+
+// Default returns the configuration used for everything the file, environment and flags leave unset.
+func Default() *Config {
+	return &Config{
+		HTTP: HTTPConfig{
+			Host:       "localhost",
+			Port:       "8081",
+			DrainDelay: 5 * time.Second,
+			TLS: TLSConfig{
+				ReloadInterval: time.Minute,
+			},
+		},
+		DB: DBConfig{
+			Storage:          StoragePostgres,
+			SQLitePath:       "chat.db",
+			Host:             "localhost",
+			Port:             "5432",
+			User:             "admin",
+			Pass:             "mypassword",
+			Name:             "chat",
+			MaxOpenConns:     25,
+			MaxIdleConns:     25,
+			StatementTimeout: 30 * time.Second,
+			ConnectTimeout:   time.Minute,
+			SSLMode:          "disable",
+		},
+		WS: WSConfig{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			SendBuffer:      32,
+			HistoryPageSize: 50,
+		},
+		HistoryCache: HistoryCacheConfig{
+			Rooms:    1000,
+			Messages: 200,
+			TTL:      10 * time.Second,
+		},
+		Cleanup: CleanupConfig{
+			Interval:              time.Hour,
+			InactiveAfter:         7 * 24 * time.Hour,
+			PurgeAfter:            30 * 24 * time.Hour,
+			ActivityFlushInterval: 30 * time.Second,
+			RetentionInterval:     10 * time.Minute,
+			RetentionBatchSize:    1000,
+			JobRunsKeep:           30 * 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			OTLPEndpoint: "http://localhost:4318",
+			SampleRatio:  1,
+		},
 	}
-	return value
 }
 
-func LoadConfig() *Config {
-	if err := godotenv.Load(); err != nil {
-		log.Printf("load .env: %v", err)
+// Validate checks the configuration and reports every invalid value by its key.
+func (cfg *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+		}
+	}
+
+	check(validPort(cfg.HTTP.Port), "http.port", "%q is not a port number", cfg.HTTP.Port)
+	check(cfg.HTTP.DrainDelay >= 0, "http.drain_delay", "must not be negative")
+	check((cfg.HTTP.TLS.CertFile == "") == (cfg.HTTP.TLS.KeyFile == ""), "http.tls", "cert_file and key_file must be set together")
+	check(cfg.HTTP.TLS.ReloadInterval > 0, "http.tls.reload_interval", "must be positive")
+	if _, err := origin.NewMatcher(cfg.HTTP.AllowedOrigins); err != nil {
+		check(false, "http.allowed_origins", "%v", err)
+	}
+
+	check(slices.Contains([]string{StoragePostgres, StorageSQLite, StorageMemory}, cfg.DB.Storage), "db.storage", "%q is not one of postgres, sqlite, memory", cfg.DB.Storage)
+	check(cfg.DB.Storage != StorageSQLite || cfg.DB.SQLitePath != "", "db.sqlite_path", "must be set with sqlite storage")
+	check(cfg.DB.Host != "", "db.host", "must be set")
+	check(validPort(cfg.DB.Port), "db.port", "%q is not a port number", cfg.DB.Port)
+	check(cfg.DB.User != "", "db.user", "must be set")
+	check(cfg.DB.Name != "", "db.name", "must be set")
+	check(cfg.DB.MaxOpenConns >= 0, "db.max_open_conns", "must not be negative")
+	check(cfg.DB.MaxIdleConns >= 0, "db.max_idle_conns", "must not be negative")
+	check(cfg.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime", "must not be negative")
+	check(cfg.DB.ConnMaxIdleTime >= 0, "db.conn_max_idle_time", "must not be negative")
+	check(cfg.DB.StatementTimeout >= 0, "db.statement_timeout", "must not be negative")
+	check(cfg.DB.ConnectTimeout > 0, "db.connect_timeout", "must be positive")
+	check(slices.Contains([]string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}, cfg.DB.SSLMode), "db.sslmode", "%q is not one of disable, allow, prefer, require, verify-ca, verify-full", cfg.DB.SSLMode)
+	check((cfg.DB.SSLCert == "") == (cfg.DB.SSLKey == ""), "db.sslcert", "sslcert and sslkey must be set together")
+	check(cfg.DB.SSLMode != "disable" || cfg.DB.SSLRootCert == "" && cfg.DB.SSLCert == "", "db.sslmode", "certificates are set but sslmode is disable")
+
+	for _, file := range []struct{ key, path string }{
+		{"http.tls.cert_file", cfg.HTTP.TLS.CertFile},
+		{"http.tls.key_file", cfg.HTTP.TLS.KeyFile},
+		{"db.sslrootcert", cfg.DB.SSLRootCert},
+		{"db.sslcert", cfg.DB.SSLCert},
+		{"db.sslkey", cfg.DB.SSLKey},
+	} {
+		if file.path != "" {
+			_, err := os.Stat(file.path)
+			check(err == nil, file.key, "%v", err)
+		}
 	}
 
-	cfg := new(Config)
+	check(cfg.WS.ReadBufferSize > 0, "ws.read_buffer_size", "must be positive")
+	check(cfg.WS.WriteBufferSize > 0, "ws.write_buffer_size", "must be positive")
+	check(cfg.WS.SendBuffer > 0, "ws.send_buffer", "must be positive")
+	check(cfg.WS.HistoryPageSize > 0 && cfg.WS.HistoryPageSize <= 100, "ws.history_page_size", "must be between 1 and 100")
 
-	cfg.HTTP.Host = getEnv(keyHTTPHost, defaultHTTPHost)
-	cfg.HTTP.Port = getEnv(keyHTTPPort, defaultHTTPPort)
+	check(cfg.HistoryCache.Rooms >= 0, "history_cache.rooms", "must not be negative")
+	check(cfg.HistoryCache.Messages > 0, "history_cache.messages", "must be positive")
+	check(cfg.HistoryCache.TTL >= 0, "history_cache.ttl", "must not be negative")
 
-	cfg.DB.Host = getEnv(keyDBHost, defaultDBHost)
-	cfg.DB.Port = getEnv(keyDBPort, defaultDBPort)
-	cfg.DB.User = getEnv(keyDBUser, defaultDBUser)
-	cfg.DB.Pass = getEnv(keyDBPass, defaultDBPass)
-	cfg.DB.Name = getEnv(keyDBName, defaultDBName)
+	check(cfg.Cleanup.Interval > 0, "cleanup.interval", "must be positive")
+	check(cfg.Cleanup.InactiveAfter > 0, "cleanup.inactive_after", "must be positive")
+	check(cfg.Cleanup.PurgeAfter > 0, "cleanup.purge_after", "must be positive")
+	check(cfg.Cleanup.ActivityFlushInterval > 0, "cleanup.activity_flush_interval", "must be positive")
+	check(cfg.Cleanup.ActivityFlushInterval < cfg.Cleanup.InactiveAfter, "cleanup.activity_flush_interval", "must be shorter than inactive_after")
+	check(cfg.Cleanup.RetentionInterval > 0, "cleanup.retention_interval", "must be positive")
+	check(cfg.Cleanup.RetentionBatchSize > 0, "cleanup.retention_batch_size", "must be positive")
+	check(cfg.Cleanup.JobRunsKeep > 0, "cleanup.job_runs_keep", "must be positive")
+
+	check(slices.Contains([]string{"debug", "info", "warn", "error"}, cfg.Log.Level), "log.level", "%q is not one of debug, info, warn, error", cfg.Log.Level)
+	check(slices.Contains([]string{"json", "text"}, cfg.Log.Format), "log.format", "%q is not one of json, text", cfg.Log.Format)
+
+	check(slices.Contains([]string{"none", "stdout", "otlp"}, cfg.Tracing.Exporter), "tracing.exporter", "%q is not one of none, stdout, otlp", cfg.Tracing.Exporter)
+	check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+	if cfg.Tracing.Exporter == "otlp" {
+		u, err := url.Parse(cfg.Tracing.OTLPEndpoint)
+		check(err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https"), "tracing.otlp_endpoint", "%q is not an http(s) URL", cfg.Tracing.OTLPEndpoint)
+	}
+
+	return errors.Join(errs...)
+}
 
-	return cfg
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
 }
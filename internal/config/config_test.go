@@ -0,0 +1,156 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func Test_Load(t *testing.T) {
+	t.Setenv(EnvConfigFile, "")
+
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := load(nil, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, Default(), cfg)
+	})
+
+	t.Run("yaml file", func(t *testing.T) {
+		path := writeFile(t, "chat.yaml", `
+http:
+  port: 9000
+  allowed_origins: [https://chat.example.com]
+ws:
+  send_buffer: 64
+cleanup:
+  interval: 30m
+tracing:
+  sample_ratio: 0.5
+`)
+		cfg, err := load([]string{"-config", path}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, "9000", cfg.HTTP.Port)
+		assert.Equal(t, []string{"https://chat.example.com"}, cfg.HTTP.AllowedOrigins)
+		assert.Equal(t, 64, cfg.WS.SendBuffer)
+		assert.Equal(t, 30*time.Minute, cfg.Cleanup.Interval)
+		assert.Equal(t, 0.5, cfg.Tracing.SampleRatio)
+		assert.Equal(t, 50, cfg.WS.HistoryPageSize)
+	})
+
+	t.Run("example file", func(t *testing.T) {
+		cfg, err := load([]string{"-config", "../../config.example.yaml"}, io.Discard)
+		require.NoError(t, err)
+		want := Default()
+		want.HTTP.Host = "0.0.0.0"
+		want.HTTP.AllowedOrigins = []string{}
+		assert.Equal(t, want, cfg)
+	})
+
+	t.Run("toml file from env", func(t *testing.T) {
+		path := writeFile(t, "chat.toml", `
+[db]
+host = "db.internal"
+max_open_conns = 10
+auto_migrate = true
+`)
+		t.Setenv(EnvConfigFile, path)
+		cfg, err := load(nil, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, "db.internal", cfg.DB.Host)
+		assert.Equal(t, 10, cfg.DB.MaxOpenConns)
+		assert.True(t, cfg.DB.AutoMigrate)
+	})
+
+	t.Run("env overrides file and flags override env", func(t *testing.T) {
+		path := writeFile(t, "chat.yaml", "http:\n  port: 9000\nws:\n  history_page_size: 20\n")
+		t.Setenv("HTTP_PORT", "9001")
+		t.Setenv("WS_HISTORY_PAGE_SIZE", "30")
+		cfg, err := load([]string{"-config", path, "-http.port", "9002", "-log.sql"}, io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, "9002", cfg.HTTP.Port)
+		assert.Equal(t, 30, cfg.WS.HistoryPageSize)
+		assert.True(t, cfg.Log.SQL)
+	})
+
+	t.Run("unknown and malformed keys", func(t *testing.T) {
+		path := writeFile(t, "chat.yaml", "http:\n  prot: 9000\ncleanup:\n  interval: 30\n")
+		_, err := load([]string{"-config", path}, io.Discard)
+		require.Error(t, err)
+		assert.ErrorContains(t, err, `unknown key "http.prot"`)
+		assert.ErrorContains(t, err, "cleanup.interval: 30 has no unit")
+	})
+
+	t.Run("malformed env", func(t *testing.T) {
+		t.Setenv("WS_SEND_BUFFER", "lots")
+		_, err := load(nil, io.Discard)
+		assert.ErrorContains(t, err, `WS_SEND_BUFFER: "lots" is not an integer`)
+	})
+
+	t.Run("malformed flag", func(t *testing.T) {
+		_, err := load([]string{"-http.drain_delay", "soon"}, io.Discard)
+		assert.ErrorContains(t, err, `"soon" is not a duration`)
+	})
+}
+
+func Test_Config_Validate(t *testing.T) {
+	cfg := Default()
+	cfg.HTTP.Port = "80800"
+	cfg.HTTP.TLS.CertFile = "cert.pem"
+	cfg.HTTP.AllowedOrigins = []string{"https://ok.example.com", "example.com"}
+	cfg.WS.SendBuffer = 0
+	cfg.WS.HistoryPageSize = 1000
+	cfg.HistoryCache.Messages = 0
+	cfg.Cleanup.JobRunsKeep = 0
+	cfg.DB.Storage = "mongo"
+	cfg.DB.SSLMode = "always"
+	cfg.DB.SSLCert = "client.crt"
+	cfg.Log.Level = "loud"
+	cfg.Tracing.SampleRatio = 2
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	for _, want := range []string{
+		`http.port: "80800" is not a port number`,
+		"http.tls: cert_file and key_file must be set together",
+		"http.tls.cert_file:",
+		`http.allowed_origins: "example.com" is not an origin`,
+		`db.storage: "mongo" is not one of postgres, sqlite, memory`,
+		`db.sslmode: "always" is not one of`,
+		"db.sslcert: sslcert and sslkey must be set together",
+		"ws.send_buffer: must be positive",
+		"ws.history_page_size: must be between 1 and 100",
+		"history_cache.messages: must be positive",
+		"cleanup.job_runs_keep: must be positive",
+		`log.level: "loud" is not one of`,
+		"tracing.sample_ratio: must be between 0 and 1",
+	} {
+		assert.ErrorContains(t, err, want)
+	}
+	assert.NotContains(t, err.Error(), "ok.example.com")
+
+	assert.NoError(t, Default().Validate())
+
+	cfg = Default()
+	cfg.DB.Storage, cfg.DB.SQLitePath = StorageSQLite, ""
+	assert.ErrorContains(t, cfg.Validate(), "db.sqlite_path: must be set with sqlite storage")
+}
+
+func Test_DBConfig_PostgresURL(t *testing.T) {
+	cfg := DBConfig{Host: "db", Port: "5432", User: "chat", Pass: "p@ss/word", Name: "chat"}
+	assert.Equal(t, "postgres://chat:p%40ss%2Fword@db:5432/chat?sslmode=disable", cfg.PostgresURL())
+
+	cfg.SSLMode, cfg.SSLRootCert, cfg.SSLCert, cfg.SSLKey = "verify-full", "/certs/ca.crt", "/certs/client.crt", "/certs/client.key"
+	assert.Equal(t, "postgres://chat:p%40ss%2Fword@db:5432/chat?sslcert=%2Fcerts%2Fclient.crt&sslkey=%2Fcerts%2Fclient.key&sslmode=verify-full&sslrootcert=%2Fcerts%2Fca.crt", cfg.PostgresURL())
+}
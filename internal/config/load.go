@@ -0,0 +1,279 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile names the config file when the -config flag is not given.
+const EnvConfigFile = "CONFIG_FILE"
+
+// Load builds the configuration from, in increasing precedence: the defaults, the YAML or TOML file named by
+// -config or CONFIG_FILE, the environment (including a .env file) and the command-line flags in args.
+// It returns flag.ErrHelp when args ask for usage, which has then been printed to stderr.
+func Load(args []string) (*Config, error) {
+	return load(args, os.Stderr)
+}
+
+func load(args []string, usage io.Writer) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	cfg := Default()
+	fields := cfg.fields()
+
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	fs.SetOutput(usage)
+	file := fs.String("config", os.Getenv(EnvConfigFile), "YAML or TOML config file (env "+EnvConfigFile+")")
+	var errs []error
+	flagValues := make(map[string]string)
+	for _, f := range fields {
+		help := fmt.Sprintf("%s (env %s)", f.help, f.env)
+		if !f.value.IsZero() {
+			help = fmt.Sprintf("%s (env %s, default %v)", f.help, f.env, f.value.Interface())
+		}
+		// Flags are applied after the file and the environment, so here they are only checked and recorded.
+		record := func(s string) error {
+			flagValues[f.key] = s
+			return f.check(s)
+		}
+		if f.value.Kind() == reflect.Bool {
+			fs.BoolFunc(f.key, help, record)
+		} else {
+			fs.Func(f.key, help, record)
+		}
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: chat [flags]\n       chat migrate [-migrations dir] <command>\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	if *file != "" {
+		values, err := readFile(*file)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			if v, ok := values[f.key]; ok {
+				if err := f.setAny(v); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %s: %w", *file, f.key, err))
+				}
+				delete(values, f.key)
+			}
+		}
+		for _, key := range slices.Sorted(maps.Keys(values)) {
+			errs = append(errs, fmt.Errorf("%s: unknown key %q", *file, key))
+		}
+	}
+
+	for _, f := range fields {
+		if v := os.Getenv(f.env); v != "" {
+			if err := f.set(v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if v, ok := flagValues[f.key]; ok {
+			if err := f.set(v); err != nil {
+				errs = append(errs, fmt.Errorf("-%s: %w", f.key, err))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+	return cfg, nil
+}
+
+// field is one setting of Config together with its names in every source.
+type field struct {
+	key   string
+	env   string
+	help  string
+	value reflect.Value
+}
+
+// fields lists the settings of cfg in declaration order, with keys joined across sections.
+func (cfg *Config) fields() []field {
+	var out []field
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			key := prefix + sf.Tag.Get("key")
+			if sf.Type.Kind() == reflect.Struct {
+				walk(key+".", v.Field(i))
+				continue
+			}
+			out = append(out, field{key: key, env: sf.Tag.Get("env"), help: sf.Tag.Get("help"), value: v.Field(i)})
+		}
+	}
+	walk("", reflect.ValueOf(cfg).Elem())
+	return out
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// set parses s into the field; lists are comma-separated.
+func (f field) set(s string) error {
+	v, err := f.parse(s)
+	if err != nil {
+		return err
+	}
+	f.value.Set(v)
+	return nil
+}
+
+// check reports whether s would be accepted by set.
+func (f field) check(s string) error {
+	_, err := f.parse(s)
+	return err
+}
+
+func (f field) parse(s string) (reflect.Value, error) {
+	v := reflect.New(f.value.Type()).Elem()
+	switch {
+	case f.value.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return v, fmt.Errorf("%q is not a duration like 90s or 1h30m", s)
+		}
+		v.SetInt(int64(d))
+	case f.value.Kind() == reflect.String:
+		v.SetString(s)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return v, fmt.Errorf("%q is not an integer", s)
+		}
+		v.SetInt(int64(n))
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return v, fmt.Errorf("%q is not a boolean", s)
+		}
+		v.SetBool(b)
+	case f.value.Kind() == reflect.Float64:
+		x, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return v, fmt.Errorf("%q is not a number", s)
+		}
+		v.SetFloat(x)
+	case f.value.Kind() == reflect.Slice:
+		var items []string
+		for item := range strings.SplitSeq(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		panic("config: unsupported field type " + f.value.Type().String())
+	}
+	return v, nil
+}
+
+// setAny sets the field from a decoded file value: a string, number, boolean or list of strings.
+func (f field) setAny(x any) error {
+	switch x := x.(type) {
+	case string:
+		return f.set(x)
+	case bool:
+		if f.value.Kind() != reflect.Bool {
+			return fmt.Errorf("%v is not a %s", x, f.value.Type())
+		}
+		f.value.SetBool(x)
+		return nil
+	case int, int64, uint64, float64:
+		if f.value.Type() == durationType {
+			return fmt.Errorf("%v has no unit, write a duration like \"90s\"", x)
+		}
+		if f.value.Kind() == reflect.String {
+			// Ports are naturally written as numbers.
+			return f.set(fmt.Sprint(x))
+		}
+		if f.value.Kind() != reflect.Int && f.value.Kind() != reflect.Float64 {
+			return fmt.Errorf("%v is not a %s", x, f.value.Type())
+		}
+		return f.set(fmt.Sprint(x))
+	case []any:
+		if f.value.Kind() != reflect.Slice {
+			return fmt.Errorf("a list is not a %s", f.value.Type())
+		}
+		items := make([]string, 0, len(x))
+		for _, item := range x {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("list item %v is not a string", item)
+			}
+			items = append(items, s)
+		}
+		f.value.Set(reflect.ValueOf(items))
+		return nil
+	default:
+		return fmt.Errorf("unsupported value %v", x)
+	}
+}
+
+// readFile decodes a YAML or TOML file, chosen by extension, into values keyed like "http.port".
+func readFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported extension %q, want .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	values := make(map[string]any)
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]any); ok {
+				flatten(prefix+k+".", sub)
+				continue
+			}
+			values[prefix+k] = v
+		}
+	}
+	flatten("", doc)
+	return values, nil
+}
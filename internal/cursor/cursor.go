@@ -0,0 +1,126 @@
+// Package cursor implements opaque, signed keyset pagination cursors.
+//
+// A cursor records the position of the last row a page returned, as the row's value in the sort column
+// and its id, so the next page can continue with a "(key, id) < (?, ?)" comparison whatever the ordering.
+// Tokens are signed so clients cannot forge positions or reuse a cursor with a different ordering.
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+)
+
+// Cursor is a position in a keyset-paginated listing.
+type Cursor struct {
+	// Order is the ordering the cursor was issued for, such as "last_active_at desc".
+	Order string `json:"o"`
+	// Key is the value of the sort column of the row; it is zero when the listing is ordered by id.
+	Key time.Time `json:"k,omitzero"`
+	ID  int64     `json:"id"`
+	// Backward marks a cursor that walks towards the start of the listing, as issued for the previous page.
+	Backward bool `json:"b,omitempty"`
+}
+
+// Codec signs and verifies cursor tokens with a secret key.
+type Codec struct {
+	key []byte
+}
+
+// NewCodec returns a codec signing with secret. An empty secret is replaced with a random one,
+// which makes tokens valid only until the process restarts.
+func NewCodec(secret string) (*Codec, error) {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &Codec{key: key}, nil
+}
+
+// Encode returns the cursor as a URL-safe "payload.signature" token.
+func (c *Codec) Encode(cur Cursor) string {
+	payload, _ := json.Marshal(cur)
+	enc := base64.RawURLEncoding.EncodeToString(payload)
+	return enc + "." + base64.RawURLEncoding.EncodeToString(c.sign(enc))
+}
+
+// Decode verifies a token produced by Encode and returns its cursor.
+// Malformed, forged and tampered tokens are reported as model.ErrBadRequest.
+func (c *Codec) Decode(token string) (*Cursor, error) {
+	enc, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, model.ErrBadRequest
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(mac, c.sign(enc)) {
+		return nil, model.ErrBadRequest
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(enc)
+	if err != nil {
+		return nil, model.ErrBadRequest
+	}
+
+	cur := new(Cursor)
+	if err = json.Unmarshal(payload, cur); err != nil || cur.ID <= 0 {
+		return nil, model.ErrBadRequest
+	}
+	return cur, nil
+}
+
+// DecodeFor decodes a token and checks that it was issued for the given ordering.
+func (c *Codec) DecodeFor(token, order string) (*Cursor, error) {
+	cur, err := c.Decode(token)
+	if err != nil {
+		return nil, err
+	}
+	if cur.Order != order {
+		return nil, model.ErrBadRequest
+	}
+	return cur, nil
+}
+
+func (c *Codec) sign(payload string) []byte {
+	h := hmac.New(sha256.New, c.key)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// Page computes the cursors around a page fetched with one row more than limit, in walking order.
+// It trims the extra row and returns rows in listing order, so a backward page reads the same way as a forward one.
+// next is empty on the last page and prev is empty on the first one.
+func Page[T any](rows []T, limit int, after *Cursor, at func(T) Cursor, c *Codec) (page []T, next, prev string) {
+	more := len(rows) > limit
+	if more {
+		rows = rows[:limit]
+	}
+	backward := after != nil && after.Backward
+	if backward {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) == 0 {
+		return rows, "", ""
+	}
+
+	first, last := at(rows[0]), at(rows[len(rows)-1])
+	first.Backward = true
+	// Walking forwards there is a previous page whenever we started from a cursor and a next one if a row was left over;
+	// walking backwards it is the other way round.
+	if backward || more {
+		next = c.Encode(last)
+	}
+	if (backward && more) || (!backward && after != nil) {
+		prev = c.Encode(first)
+	}
+	return rows, next, prev
+}
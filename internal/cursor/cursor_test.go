@@ -0,0 +1,88 @@
+package cursor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Codec(t *testing.T) {
+	codec, err := NewCodec("secret")
+	require.NoError(t, err)
+
+	cur := Cursor{Order: "last_active_at desc", Key: time.Date(2025, 1, 2, 3, 4, 5, 6000, time.UTC), ID: 42}
+	token := codec.Encode(cur)
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := codec.DecodeFor(token, "last_active_at desc")
+		require.NoError(t, err)
+		assert.True(t, cur.Key.Equal(got.Key))
+		assert.Equal(t, cur.ID, got.ID)
+	})
+	t.Run("other order", func(t *testing.T) {
+		_, err := codec.DecodeFor(token, "id desc")
+		require.ErrorIs(t, err, model.ErrBadRequest)
+	})
+	t.Run("other secret", func(t *testing.T) {
+		other, err := NewCodec("other")
+		require.NoError(t, err)
+		_, err = other.Decode(token)
+		require.ErrorIs(t, err, model.ErrBadRequest)
+	})
+	t.Run("tampered payload", func(t *testing.T) {
+		payload, sig, _ := strings.Cut(token, ".")
+		forged := codec.Encode(Cursor{Order: cur.Order, ID: 1})
+		forgedPayload, _, _ := strings.Cut(forged, ".")
+		require.NotEqual(t, payload, forgedPayload)
+		_, err := codec.Decode(forgedPayload + "." + sig)
+		require.ErrorIs(t, err, model.ErrBadRequest)
+	})
+	t.Run("garbage", func(t *testing.T) {
+		_, err := codec.Decode("not-a-cursor")
+		require.ErrorIs(t, err, model.ErrBadRequest)
+	})
+}
+
+func Test_Page(t *testing.T) {
+	codec, err := NewCodec("secret")
+	require.NoError(t, err)
+	at := func(id int64) Cursor { return Cursor{ID: id} }
+	decode := func(token string) *Cursor {
+		if token == "" {
+			return nil
+		}
+		cur, err := codec.Decode(token)
+		require.NoError(t, err)
+		return cur
+	}
+
+	t.Run("first page", func(t *testing.T) {
+		rows, next, prev := Page([]int64{10, 9, 8}, 2, nil, at, codec)
+		assert.Equal(t, []int64{10, 9}, rows)
+		assert.Equal(t, &Cursor{ID: 9}, decode(next))
+		assert.Empty(t, prev)
+	})
+	t.Run("last page", func(t *testing.T) {
+		rows, next, prev := Page([]int64{8}, 2, &Cursor{ID: 9}, at, codec)
+		assert.Equal(t, []int64{8}, rows)
+		assert.Empty(t, next)
+		assert.Equal(t, &Cursor{ID: 8, Backward: true}, decode(prev))
+	})
+	t.Run("backward page", func(t *testing.T) {
+		// Walking back from 8, the repository returns the closest rows first.
+		rows, next, prev := Page([]int64{9, 10, 11}, 2, &Cursor{ID: 8, Backward: true}, at, codec)
+		assert.Equal(t, []int64{10, 9}, rows)
+		assert.Equal(t, &Cursor{ID: 9}, decode(next))
+		assert.Equal(t, &Cursor{ID: 10, Backward: true}, decode(prev))
+	})
+	t.Run("backward to the start", func(t *testing.T) {
+		rows, next, prev := Page([]int64{9, 10}, 2, &Cursor{ID: 8, Backward: true}, at, codec)
+		assert.Equal(t, []int64{10, 9}, rows)
+		assert.Equal(t, &Cursor{ID: 9}, decode(next))
+		assert.Empty(t, prev)
+	})
+}
@@ -0,0 +1,100 @@
+// Package egress keeps requests to user-supplied URLs, such as webhooks and bot callbacks, away from the server's own network:
+// loopback, private, link-local and cloud metadata addresses are refused.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned for hosts that resolve to an address outgoing requests must not reach.
+var ErrForbiddenAddress = errors.New("address is not publicly routable")
+
+// ErrInvalidURL is returned for URLs that are not absolute http(s) URLs.
+var ErrInvalidURL = errors.New("not an absolute http(s) URL")
+
+// blocked lists the ranges Allowed refuses besides the loopback, private, link-local, multicast and unspecified ones
+// recognised by netip: "this network", carrier-grade NAT (home of some cloud metadata services) and the reserved 240/4.
+var blocked = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+// Allowed reports whether outgoing requests may connect to addr.
+func Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return false
+	}
+	for _, p := range blocked {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL checks that raw is an absolute http(s) URL whose host resolves only to allowed addresses.
+// It catches bad URLs when they are saved; Client enforces the same rule on every connection, after DNS resolution.
+func CheckURL(ctx context.Context, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if !Allowed(addr) {
+			return fmt.Errorf("%s: %w", host, ErrForbiddenAddress)
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !Allowed(addr) {
+			return fmt.Errorf("%s resolves to %s: %w", host, addr, ErrForbiddenAddress)
+		}
+	}
+	return nil
+}
+
+// Control is a net.Dialer Control function that refuses connections to addresses that are not Allowed.
+// It runs on the resolved address, so a name re-pointed after CheckURL (DNS rebinding) is still refused.
+func Control(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !Allowed(addrPort.Addr()) {
+		return fmt.Errorf("dial %s: %w", address, ErrForbiddenAddress)
+	}
+	return nil
+}
+
+// Client returns an HTTP client for user-supplied URLs: every connection, including those of redirects, goes through Control.
+// Proxies from the environment are not used, since the proxy rather than the client would then pick the address.
+func Client(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: Control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}
+}
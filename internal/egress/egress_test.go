@@ -0,0 +1,78 @@
+package egress
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Allowed(t *testing.T) {
+	testCases := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "10.1.2.3"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "100.100.100.200"},
+		{addr: "0.0.0.0"},
+		{addr: "fd00:ec2::254"},
+		{addr: "fe80::1"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "224.0.0.1"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.addr, func(t *testing.T) {
+			assert.Equal(t, tc.want, Allowed(netip.MustParseAddr(tc.addr)))
+		})
+	}
+}
+
+func Test_CheckURL(t *testing.T) {
+	testCases := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "public address", url: "https://93.184.216.34/hook"},
+		{name: "not http", url: "ftp://93.184.216.34/hook", wantErr: ErrInvalidURL},
+		{name: "relative", url: "/hook", wantErr: ErrInvalidURL},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", wantErr: ErrForbiddenAddress},
+		{name: "metadata", url: "http://169.254.169.254/latest/meta-data/", wantErr: ErrForbiddenAddress},
+		{name: "private IPv6", url: "http://[fd00::1]/hook", wantErr: ErrForbiddenAddress},
+		{name: "name of a loopback address", url: "http://localhost/hook", wantErr: ErrForbiddenAddress},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckURL(context.Background(), tc.url)
+			if tc.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+}
+
+func Test_Client_RefusesLocalAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	_, err := Client(time.Second).Get(srv.URL)
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+	assert.False(t, called)
+}
@@ -0,0 +1,123 @@
+// Package health runs the readiness checks of the server's dependencies.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+// Check reports whether a dependency is usable; it should give up when ctx is done.
+type Check func(ctx context.Context) error
+
+// Report is the outcome of a readiness check: "ok" or the error of every named check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the registered checks; once Drain is called it reports not ready without running them.
+type Checker struct {
+	timeout  time.Duration
+	checks   []namedCheck
+	draining atomic.Bool
+}
+
+// New constructs a checker that gives each readiness probe at most timeout.
+func New(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+	}
+}
+
+// Add registers a named check; it must be called before the checker serves probes.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Drain marks the server as shutting down, so load balancers stop sending it new traffic.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Ready runs all checks concurrently and reports whether every one passed in time.
+func (c *Checker) Ready(ctx context.Context) (Report, bool) {
+	if c.draining.Load() {
+		return Report{Status: StatusDraining}, false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]error, len(c.checks))
+	var wg sync.WaitGroup
+	for i, nc := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, nc.check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]string, len(c.checks))}
+	for i, nc := range c.checks {
+		if results[i] != nil {
+			report.Status = StatusUnavailable
+			report.Checks[nc.name] = results[i].Error()
+			continue
+		}
+		report.Checks[nc.name] = StatusOK
+	}
+	return report, report.Status == StatusOK
+}
+
+// run calls check but stops waiting for it once ctx is done, so a check that ignores ctx cannot hang the probe.
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() { done <- check(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// CacheSuccess remembers a passing result of check for ttl, for checks too costly to run on every probe.
+// Failures are never cached.
+func CacheSuccess(ttl time.Duration, check Check) Check {
+	var (
+		mu     sync.Mutex
+		passed time.Time
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		fresh := !passed.IsZero() && time.Since(passed) < ttl
+		mu.Unlock()
+		if fresh {
+			return nil
+		}
+
+		if err := check(ctx); err != nil {
+			return err
+		}
+		mu.Lock()
+		passed = time.Now()
+		mu.Unlock()
+		return nil
+	}
+}
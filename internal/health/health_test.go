@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Checker_Ready(t *testing.T) {
+	ok := func(context.Context) error { return nil }
+
+	t.Run("all checks pass", func(t *testing.T) {
+		c := New(time.Second)
+		c.Add("db", ok)
+		c.Add("hub", ok)
+
+		report, ready := c.Ready(t.Context())
+		assert.True(t, ready)
+		assert.Equal(t, Report{Status: StatusOK, Checks: map[string]string{"db": StatusOK, "hub": StatusOK}}, report)
+	})
+
+	t.Run("failing and hanging checks", func(t *testing.T) {
+		c := New(50 * time.Millisecond)
+		c.Add("db", ok)
+		c.Add("migrations", func(context.Context) error { return errors.New("schema version 3, want 4") })
+		c.Add("hub", func(context.Context) error { select {} })
+
+		report, ready := c.Ready(t.Context())
+		assert.False(t, ready)
+		assert.Equal(t, StatusUnavailable, report.Status)
+		assert.Equal(t, StatusOK, report.Checks["db"])
+		assert.Equal(t, "schema version 3, want 4", report.Checks["migrations"])
+		assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["hub"])
+	})
+
+	t.Run("draining", func(t *testing.T) {
+		c := New(time.Second)
+		c.Add("db", ok)
+		c.Drain()
+
+		report, ready := c.Ready(t.Context())
+		assert.False(t, ready)
+		assert.Equal(t, Report{Status: StatusDraining}, report)
+	})
+}
+
+func Test_CacheSuccess(t *testing.T) {
+	calls := 0
+	var fail error
+	check := CacheSuccess(time.Hour, func(context.Context) error {
+		calls++
+		return fail
+	})
+
+	fail = errors.New("down")
+	assert.Error(t, check(t.Context()))
+	assert.Error(t, check(t.Context()))
+	assert.Equal(t, 2, calls)
+
+	fail = nil
+	assert.NoError(t, check(t.Context()))
+	fail = errors.New("down")
+	assert.NoError(t, check(t.Context()))
+	assert.Equal(t, 3, calls)
+}
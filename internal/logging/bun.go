@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var _ bun.QueryHook = (*QueryHook)(nil)
+
+// QueryHook logs every bun query with the logger of the query context; it is only installed when SQL logging is enabled.
+type QueryHook struct{}
+
+func (QueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (QueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	attrs := []slog.Attr{
+		slog.String("operation", event.Operation()),
+		slog.String("query", event.Query),
+		slog.Duration("duration", time.Since(event.StartTime)),
+	}
+	level := slog.LevelDebug
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("err", event.Err.Error()))
+	}
+	FromContext(ctx).LogAttrs(ctx, level, "sql query", attrs...)
+}
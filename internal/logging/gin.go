@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID carries the request id; a valid incoming value is kept so ids can span services.
+const HeaderRequestID = "X-Request-ID"
+
+// Gin gives every request an id, returns it in the X-Request-ID header, puts a logger carrying it
+// (and the trace id, when tracing runs before it) into the request context and logs the request once it is served.
+func Gin(l *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		id := c.GetHeader(HeaderRequestID)
+		if !validRequestID(id) {
+			id = NewID()
+		}
+		c.Header(HeaderRequestID, id)
+
+		reqLog := l.With("request_id", id)
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+			reqLog = reqLog.With("trace_id", sc.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(WithContext(c.Request.Context(), reqLog))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		reqLog.LogAttrs(c.Request.Context(), level, "http request",
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+		)
+	}
+}
+
+// Recovery turns a panic in a handler into a 500 response and logs it with the request id.
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, err any) {
+		FromContext(c.Request.Context()).Error("http handler panic", "err", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_New(t *testing.T) {
+	l, err := New(io.Discard, "warn", "text")
+	require.NoError(t, err)
+	assert.False(t, l.Enabled(t.Context(), slog.LevelInfo))
+	assert.True(t, l.Enabled(t.Context(), slog.LevelWarn))
+
+	_, err = New(io.Discard, "loud", "json")
+	assert.Error(t, err)
+	_, err = New(io.Discard, "info", "xml")
+	assert.Error(t, err)
+}
+
+func Test_Gin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	l, err := New(&buf, "debug", "json")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(Gin(l), Recovery())
+	router.GET("/rooms/:id", func(c *gin.Context) {
+		FromContext(c.Request.Context()).Info("handler")
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	t.Run("keeps valid incoming id", func(t *testing.T) {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/rooms/1", nil)
+		req.Header.Set(HeaderRequestID, "abc-123")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, "abc-123", rec.Header().Get(HeaderRequestID))
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "handler", lines[0]["msg"])
+		assert.Equal(t, "abc-123", lines[0]["request_id"])
+		assert.Equal(t, "/rooms/:id", lines[1]["route"])
+		assert.Equal(t, float64(http.StatusNoContent), lines[1]["status"])
+	})
+
+	t.Run("replaces invalid id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/rooms/1", nil)
+		req.Header.Set(HeaderRequestID, "bad id\n")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		id := rec.Header().Get(HeaderRequestID)
+		assert.NotEqual(t, "bad id\n", id)
+		assert.Len(t, id, 16)
+	})
+
+	t.Run("recovers panics", func(t *testing.T) {
+		buf.Reset()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		lines := decodeLines(t, &buf)
+		require.Len(t, lines, 2)
+		assert.Equal(t, "ERROR", lines[0]["level"])
+		assert.Equal(t, rec.Header().Get(HeaderRequestID), lines[0]["request_id"])
+	})
+}
+
+func decodeLines(t *testing.T, r io.Reader) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		var line map[string]any
+		require.NoError(t, dec.Decode(&line))
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+var _ bun.QueryHook = (*QueryHook)(nil)
+
+// QueryHook records the duration of every bun query.
+type QueryHook struct{}
+
+func (QueryHook) BeforeQuery(ctx context.Context, _ *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (QueryHook) AfterQuery(_ context.Context, event *bun.QueryEvent) {
+	status := "ok"
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		status = "error"
+	}
+	DBQueryDuration.
+		WithLabelValues(event.Operation(), status).
+		Observe(time.Since(event.StartTime).Seconds())
+}
@@ -0,0 +1,118 @@
+// Package metrics defines the Prometheus metrics of the chat server and the hooks that record them.
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "chat"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// MessagesCreated counts persisted messages by kind; rate() of it gives messages per second.
+	MessagesCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_created_total",
+		Help:      "Messages persisted, by kind.",
+	}, []string{"kind"})
+
+	MessagesPurged = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "messages_purged_total",
+		Help:      "Messages deleted by room retention policies, by policy.",
+	}, []string{"policy"})
+
+	// HistoryCacheRequests counts reads of the latest page of room history by whether the cache served them.
+	HistoryCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "history_cache_requests_total",
+		Help:      "Reads of the latest room history page, by cache result.",
+	}, []string{"result"})
+
+	WSSendOverflowDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ws_send_buffer_overflow_disconnects_total",
+		Help:      "WebSocket clients disconnected because their send buffer was full.",
+	})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency by operation and outcome.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "status"})
+
+	JobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "Background job runs on this instance, by job and status.",
+	}, []string{"job", "status"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Gin records the latency of every request under its route pattern, so path parameters do not multiply series.
+func Gin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterHub exposes the live WebSocket clients and rooms reported by stats as gauges.
+// Calling it again, for a new hub, replaces the gauges of the previous one.
+func RegisterHub(stats func() (clients, rooms int)) error {
+	clients := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_clients",
+		Help:      "WebSocket clients joined to a room.",
+	}, func() float64 {
+		clients, _ := stats()
+		return float64(clients)
+	})
+	rooms := prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "ws_rooms",
+		Help:      "Rooms with at least one connected WebSocket client.",
+	}, func() float64 {
+		_, rooms := stats()
+		return float64(rooms)
+	})
+	return errors.Join(replace(clients), replace(rooms))
+}
+
+// replace registers c, unregistering an equal collector registered before.
+func replace(c prometheus.Collector) error {
+	err := prometheus.Register(c)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		prometheus.Unregister(registered.ExistingCollector)
+		err = prometheus.Register(c)
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Gin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Gin())
+	router.GET("/rooms/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for _, path := range []string{"/rooms/1", "/rooms/2", "/missing"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, uint64(2), histogramCount(t, "GET", "/rooms/:id", "204"))
+	assert.Equal(t, uint64(1), histogramCount(t, "GET", "unmatched", "404"))
+}
+
+func Test_RegisterHub(t *testing.T) {
+	require.NoError(t, RegisterHub(func() (int, int) { return 5, 2 }))
+
+	body := httptest.NewRecorder()
+	Handler().ServeHTTP(body, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, body.Body.String(), "chat_ws_clients 5")
+	assert.Contains(t, body.Body.String(), "chat_ws_rooms 2")
+
+	t.Run("again for a new hub", func(t *testing.T) {
+		require.NoError(t, RegisterHub(func() (int, int) { return 1, 1 }))
+
+		body := httptest.NewRecorder()
+		Handler().ServeHTTP(body, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Contains(t, body.Body.String(), "chat_ws_clients 1")
+		assert.Contains(t, body.Body.String(), "chat_ws_rooms 1")
+	})
+}
+
+func histogramCount(t *testing.T, labels ...string) uint64 {
+	t.Helper()
+	m := &dto.Metric{}
+	if err := HTTPRequestDuration.WithLabelValues(labels...).(prometheus.Metric).Write(m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
@@ -0,0 +1,243 @@
+package migrator
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+)
+
+// CommandUsage describes the arguments of Command.
+const CommandUsage = `  up [-dry-run] [n]     apply the next n migrations, all pending ones by default
+  down [-dry-run] [n]   roll back the last n migrations, one by default; "all" rolls back everything
+  goto [-dry-run] <v>   migrate up or down to version v
+  version               print the current version
+  force <v>             set the version without running migrations, after fixing a dirty database by hand
+  status                list migrations and whether they are applied
+`
+
+// ErrUsage is returned by Command for unknown subcommands and malformed arguments.
+var ErrUsage = errors.New("migrator: invalid usage")
+
+// Command runs a migrate subcommand such as "up 2" or "status", printing its output to w.
+// With -dry-run, up, down and goto only print the migrations they would run.
+func Command(opts Options, args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return ErrUsage
+	}
+	cmd := args[0]
+
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	dryRun := fs.Bool("dry-run", false, "print the plan without migrating")
+	if err := fs.Parse(args[1:]); err != nil {
+		return ErrUsage
+	}
+	rest := fs.Args()
+
+	// Arguments are checked before connecting, so usage errors do not need a database.
+	var (
+		run  func(m *Migrator) error
+		plan func([]Migration) []Migration
+	)
+	switch cmd {
+	case "up":
+		n, err := count(rest, 0)
+		if err != nil {
+			return err
+		}
+		run = func(m *Migrator) error { return m.Up(n) }
+		plan = func(list []Migration) []Migration { return limit(pending(list), n) }
+	case "down":
+		n := 1
+		if len(rest) == 1 && rest[0] == "all" {
+			n, rest = 0, nil
+		}
+		n, err := count(rest, n)
+		if err != nil {
+			return err
+		}
+		run = func(m *Migrator) error { return m.Down(n) }
+		plan = func(list []Migration) []Migration { return limit(applied(list), n) }
+	case "goto":
+		if len(rest) != 1 {
+			return ErrUsage
+		}
+		v, err := strconv.ParseUint(rest[0], 10, 64)
+		if err != nil {
+			return ErrUsage
+		}
+		run = func(m *Migrator) error { return m.Goto(uint(v)) }
+		plan = func(list []Migration) []Migration { return towards(list, uint(v)) }
+	case "force":
+		if len(rest) != 1 || *dryRun {
+			return ErrUsage
+		}
+		v, err := strconv.Atoi(rest[0])
+		if err != nil || v < -1 {
+			return ErrUsage
+		}
+		run = func(m *Migrator) error { return m.Force(v) }
+	case "version", "status":
+		if len(rest) != 0 || *dryRun {
+			return ErrUsage
+		}
+	default:
+		return ErrUsage
+	}
+
+	m, err := New(opts)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch {
+	case cmd == "status":
+		return printStatus(m, w)
+	case *dryRun:
+		return printPlan(m, w, plan)
+	case run != nil:
+		if err = run(m); err != nil {
+			return err
+		}
+	}
+	return printVersion(m, w)
+}
+
+// count parses the optional step count of up and down.
+func count(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n <= 0 {
+		return 0, ErrUsage
+	}
+	return n, nil
+}
+
+func printVersion(m *Migrator, w io.Writer) error {
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+	switch {
+	case version == 0:
+		_, err = fmt.Fprintln(w, "version: none")
+	case dirty:
+		_, err = fmt.Fprintf(w, "version: %d (dirty)\n", version)
+	default:
+		_, err = fmt.Fprintf(w, "version: %d\n", version)
+	}
+	return err
+}
+
+func printStatus(m *Migrator, w io.Writer) error {
+	list, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATE")
+	for _, mig := range list {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", mig.Version, mig.Name, state(mig))
+	}
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+	return printVersion(m, w)
+}
+
+// printPlan prints the migrations a command would run, in the order it would run them.
+func printPlan(m *Migrator, w io.Writer, plan func([]Migration) []Migration) error {
+	list, err := m.Status()
+	if err != nil {
+		return err
+	}
+	for _, mig := range list {
+		if mig.Dirty {
+			fmt.Fprintf(w, "warning: version %d is dirty, fix it and run force before migrating\n", mig.Version)
+		}
+	}
+
+	steps := plan(list)
+	if len(steps) == 0 {
+		_, err = fmt.Fprintln(w, "nothing to do")
+		return err
+	}
+	for _, mig := range steps {
+		dir := "up"
+		if mig.Applied {
+			dir = "down"
+		}
+		if _, err = fmt.Fprintf(w, "%s %d %s\n", dir, mig.Version, mig.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func state(mig Migration) string {
+	switch {
+	case mig.Dirty:
+		return "dirty"
+	case mig.Applied:
+		return "applied"
+	default:
+		return "pending"
+	}
+}
+
+// pending returns the migrations up would apply, oldest first.
+func pending(list []Migration) []Migration {
+	var out []Migration
+	for _, mig := range list {
+		if !mig.Applied {
+			out = append(out, mig)
+		}
+	}
+	return out
+}
+
+// applied returns the migrations down would roll back, newest first.
+func applied(list []Migration) []Migration {
+	var out []Migration
+	for _, mig := range slices.Backward(list) {
+		if mig.Applied {
+			out = append(out, mig)
+		}
+	}
+	return out
+}
+
+// towards returns the migrations goto would run to reach version.
+func towards(list []Migration, version uint) []Migration {
+	var out []Migration
+	for _, mig := range pending(list) {
+		if mig.Version <= version {
+			out = append(out, mig)
+		}
+	}
+	if len(out) > 0 {
+		return out
+	}
+	for _, mig := range applied(list) {
+		if mig.Version > version {
+			out = append(out, mig)
+		}
+	}
+	return out
+}
+
+// limit keeps the first n migrations; 0 keeps all of them.
+func limit(list []Migration, n int) []Migration {
+	if n > 0 && n < len(list) {
+		return list[:n]
+	}
+	return list
+}
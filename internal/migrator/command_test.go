@@ -0,0 +1,34 @@
+package migrator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func versions(list []Migration) []uint {
+	out := make([]uint, 0, len(list))
+	for _, mig := range list {
+		out = append(out, mig.Version)
+	}
+	return out
+}
+
+func Test_Plan(t *testing.T) {
+	list := []Migration{
+		{Version: 1, Applied: true},
+		{Version: 2, Applied: true},
+		{Version: 3, Applied: true},
+		{Version: 4},
+		{Version: 5},
+	}
+
+	assert.Equal(t, []uint{4, 5}, versions(pending(list)))
+	assert.Equal(t, []uint{4}, versions(limit(pending(list), 1)))
+	assert.Equal(t, []uint{3, 2, 1}, versions(applied(list)))
+	assert.Equal(t, []uint{3, 2}, versions(limit(applied(list), 2)))
+
+	assert.Equal(t, []uint{4}, versions(towards(list, 4)))
+	assert.Equal(t, []uint{3, 2}, versions(towards(list, 1)))
+	assert.Empty(t, towards(list, 3))
+}
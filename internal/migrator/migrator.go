@@ -3,18 +3,24 @@ package migrator
 import (
 	"errors"
 	"fmt"
-	"log"
-	"path/filepath"
-	"runtime"
+	"log/slog"
+	"os"
+	"strings"
 
+	"github.com/Rasulikus/chat/migrations"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
 type Options struct {
+	// DSN is a postgres:// URL, or a sqlite:// one for SQLite storage, which has its own migrations.
 	DSN string
 
+	// MigrationsDir overrides the migrations embedded in the binary with the files of a directory.
 	MigrationsDir string
 }
 
@@ -25,58 +31,226 @@ func NewOptions(dsn string, migrationDir string) *Options {
 	}
 }
 
-func defaultMigrationsDir() string {
-	_, filename, _, _ := runtime.Caller(0)
-	root := filepath.Join(filepath.Dir(filename), "..", "..")
-	return filepath.Join(root, "migrations")
+// openSource opens the migrations directory if one is set, the embedded migrations of the DSN's database otherwise.
+func openSource(opts Options) (source.Driver, error) {
+	if opts.MigrationsDir != "" {
+		return source.Open("file://" + opts.MigrationsDir)
+	}
+	if strings.HasPrefix(opts.DSN, "sqlite://") {
+		return iofs.New(migrations.SQLiteFS, "sqlite")
+	}
+	return iofs.New(migrations.FS, ".")
 }
 
-func Up(opts Options) error {
-	if opts.MigrationsDir == "" {
-		opts.MigrationsDir = defaultMigrationsDir()
-	}
+// Migrator applies and inspects the migrations of one database.
+type Migrator struct {
+	m   *migrate.Migrate
+	src source.Driver
+}
+
+// New connects to the database of opts.DSN; the migrator must be closed after use.
+func New(opts Options) (*Migrator, error) {
 	if opts.DSN == "" {
-		return fmt.Errorf("migrator: DSN is empty")
+		return nil, fmt.Errorf("migrator: DSN is empty")
 	}
 
-	sourceURL := "file://" + opts.MigrationsDir
+	// migrate owns and closes its source, so listing migrations needs a second one.
+	src, err := openSource(opts)
+	if err != nil {
+		return nil, fmt.Errorf("migrator: open source: %w", err)
+	}
+	list, err := openSource(opts)
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("migrator: open source: %w", err)
+	}
 
-	m, err := migrate.New(sourceURL, opts.DSN)
+	m, err := migrate.NewWithSourceInstance("migrations", src, opts.DSN)
 	if err != nil {
-		return fmt.Errorf("migrator: create migrate: %w", err)
+		_ = src.Close()
+		_ = list.Close()
+		return nil, fmt.Errorf("migrator: create migrate: %w", err)
 	}
-	defer m.Close()
+	return &Migrator{m: m, src: list}, nil
+}
 
-	err = m.Up()
+// Close releases the database connection.
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr, m.src.Close())
+}
+
+// Up applies the next n migrations, or all pending ones if n is 0.
+func (m *Migrator) Up(n int) error {
+	var err error
+	if n > 0 {
+		err = m.m.Steps(n)
+	} else {
+		err = m.m.Up()
+	}
 	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return fmt.Errorf("migrator: up: %w", err)
 	}
+	return nil
+}
 
-	log.Println("migrator: up done")
+// Down rolls back the last n migrations, or all of them if n is 0.
+func (m *Migrator) Down(n int) error {
+	var err error
+	if n > 0 {
+		err = m.m.Steps(-n)
+	} else {
+		err = m.m.Down()
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator: down: %w", err)
+	}
 	return nil
 }
 
-func Down(opts Options) error {
-	if opts.MigrationsDir == "" {
-		opts.MigrationsDir = defaultMigrationsDir()
+// Goto migrates up or down to the given version.
+func (m *Migrator) Goto(version uint) error {
+	err := m.m.Migrate(version)
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migrator: goto %d: %w", version, err)
 	}
-	if opts.DSN == "" {
-		return fmt.Errorf("migrator: DSN is empty")
+	return nil
+}
+
+// Force records version as the current one and clears the dirty flag without running any migration.
+// It is the way out after a migration failed halfway and the database was repaired by hand; -1 means no version.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		return fmt.Errorf("migrator: force %d: %w", version, err)
+	}
+	return nil
+}
+
+// Version returns the schema version recorded in the database and whether the last migration stopped halfway.
+// A database that was never migrated is at version 0.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("migrator: version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// Migration is one migration and its state in the database.
+type Migration struct {
+	Version uint
+	Name    string
+	Applied bool
+	// Dirty marks the current migration when it stopped halfway.
+	Dirty bool
+}
+
+// Status lists every known migration in order without changing the database.
+func (m *Migrator) Status() ([]Migration, error) {
+	current, dirty, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Migration
+	version, err := m.src.First()
+	for err == nil {
+		name, nameErr := m.name(version)
+		if nameErr != nil {
+			return nil, nameErr
+		}
+		list = append(list, Migration{
+			Version: version,
+			Name:    name,
+			Applied: version <= current,
+			Dirty:   dirty && version == current,
+		})
+		version, err = m.src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("migrator: list migrations: %w", err)
 	}
+	return list, nil
+}
 
-	sourceURL := "file://" + opts.MigrationsDir
+func (m *Migrator) name(version uint) (string, error) {
+	r, name, err := m.src.ReadUp(version)
+	if err != nil {
+		return "", fmt.Errorf("migrator: read migration %d: %w", version, err)
+	}
+	_ = r.Close()
+	return name, nil
+}
 
-	m, err := migrate.New(sourceURL, opts.DSN)
+// Up applies all pending migrations.
+func Up(opts Options) error {
+	m, err := New(opts)
 	if err != nil {
-		return fmt.Errorf("migrator: create migrate: %w", err)
+		return err
 	}
 	defer m.Close()
 
-	err = m.Down()
-	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return fmt.Errorf("migrator: down: %w", err)
+	if err = m.Up(0); err != nil {
+		return err
 	}
 
-	log.Println("migrator: down done")
+	slog.Info("migrator: up done")
 	return nil
 }
+
+// Down rolls back all migrations.
+func Down(opts Options) error {
+	m, err := New(opts)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err = m.Down(0); err != nil {
+		return err
+	}
+
+	slog.Info("migrator: down done")
+	return nil
+}
+
+// Version returns the schema version recorded in the database and whether the last migration stopped halfway.
+func Version(opts Options) (uint, bool, error) {
+	m, err := New(opts)
+	if err != nil {
+		return 0, false, err
+	}
+	defer m.Close()
+
+	return m.Version()
+}
+
+// LatestVersion returns the version of the newest migration, the version a fully migrated database is at.
+func LatestVersion(opts Options) (uint, error) {
+	src, err := openSource(opts)
+	if err != nil {
+		return 0, fmt.Errorf("migrator: open source: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("migrator: first migration: %w", err)
+	}
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("migrator: next migration: %w", err)
+		}
+		version = next
+	}
+}
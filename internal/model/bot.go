@@ -0,0 +1,49 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type Bot struct {
+	bun.BaseModel `bun:"table:bots" swaggerignore:"true"`
+
+	ID             int64  `json:"id" bun:"id,pk,autoincrement"`
+	Name           string `json:"name" bun:"name,notnull,unique"`
+	APIKeyHash     string `json:"-" bun:"api_key_hash,notnull,unique"`
+	APIKey         string `json:"api_key,omitempty" bun:"-"`
+	CallbackURL    string `json:"callback_url,omitempty" bun:"callback_url,nullzero"`
+	CallbackSecret string `json:"callback_secret,omitempty" bun:"callback_secret,notnull"`
+
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+type BotCommand struct {
+	bun.BaseModel `bun:"table:bot_commands,alias:bc" swaggerignore:"true"`
+
+	ID          int64  `json:"id" bun:"id,pk,autoincrement"`
+	BotID       int64  `json:"bot_id" bun:"bot_id,notnull"`
+	Name        string `json:"name" bun:"name,notnull,unique"`
+	Description string `json:"description" bun:"description,notnull"`
+
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+
+	Bot *Bot `json:"-" bun:"rel:belongs-to,join:bot_id=id"`
+}
+
+// CommandInvocation is a slash command routed to the bot that registered it.
+type CommandInvocation struct {
+	ID      string `json:"id"`
+	Command string `json:"command"`
+	Args    string `json:"args"`
+	RoomID  int64  `json:"room_id"`
+	Nick    string `json:"nick"`
+}
+
+// CommandReply is a bot's answer to an invocation. Ephemeral replies are shown to the invoker only;
+// others are posted to the room as a message from the bot.
+type CommandReply struct {
+	Text      string `json:"text"`
+	Ephemeral bool   `json:"ephemeral"`
+}
@@ -24,6 +24,7 @@ var tagMsg = map[string]string{
 	"min":      "minimum %s character(s)",
 	"max":      "maximum %s character(s)",
 	"len":      "exactly %s character(s)",
+	"http_url": "must be an http or https URL",
 }
 
 type ValidationError struct {
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+type IncomingWebhook struct {
+	bun.BaseModel `bun:"table:incoming_webhooks" swaggerignore:"true"`
+
+	ID        int64  `json:"id" bun:"id,pk,autoincrement"`
+	RoomID    int64  `json:"room_id" bun:"room_id,notnull"`
+	Name      string `json:"name" bun:"name,notnull"`
+	TokenHash string `json:"-" bun:"token_hash,notnull,unique"`
+	Token     string `json:"token,omitempty" bun:"-"`
+
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
@@ -0,0 +1,30 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusFailed    = "failed"
+)
+
+// JobRun records one execution of a scheduled background job.
+type JobRun struct {
+	bun.BaseModel `bun:"table:job_runs,alias:jr" swaggerignore:"true"`
+
+	ID  int64  `json:"id" bun:"id,pk,autoincrement"`
+	Job string `json:"job" bun:"job,notnull"`
+	// Instance is the host that ran the job.
+	Instance string `json:"instance" bun:"instance,notnull"`
+	Status   string `json:"status" bun:"status,notnull,default:'running'"`
+	Error    string `json:"error,omitempty" bun:"error,nullzero"`
+
+	// ScheduledAt is the slot the run belongs to; replicas use it to skip slots another replica already ran.
+	ScheduledAt time.Time `json:"scheduled_at" bun:"scheduled_at,notnull"`
+	StartedAt   time.Time `json:"started_at" bun:"started_at,nullzero,notnull,default:current_timestamp"`
+	FinishedAt  time.Time `json:"finished_at,omitempty" bun:"finished_at,nullzero"`
+}
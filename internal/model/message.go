@@ -6,14 +6,32 @@ import (
 	"github.com/uptrace/bun"
 )
 
+const (
+	// MessageKindUser is a message typed by a human; it always has a Nick.
+	MessageKindUser = "user"
+	// MessageKindBot is a message posted by a bot or an incoming webhook.
+	MessageKindBot = "bot"
+	// MessageKindSystem is a server notice such as a topic change; it has no Nick.
+	MessageKindSystem = "system"
+)
+
 type Message struct {
 	bun.BaseModel `bun:"table:messages" swaggerignore:"true"`
 
-	ID        int64     `json:"id" bun:"id,pk,autoincrement"`
-	Nick      string    `json:"nick" bun:"nick,notnull"`
-	Text      string    `json:"text" bun:"text,notnull"`
-	RoomID    int64     `json:"room_id" bun:"room_id,notnull"`
-	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	ID          int64        `json:"id" bun:"id,pk,autoincrement"`
+	Kind        string       `json:"kind" bun:"kind,notnull,default:'user'"`
+	Nick        string       `json:"nick,omitempty" bun:"nick,nullzero"`
+	Text        string       `json:"text" bun:"text,notnull"`
+	Attachments []Attachment `json:"attachments,omitempty" bun:"attachments,type:jsonb,nullzero"`
+	RoomID      int64        `json:"room_id" bun:"room_id,notnull"`
+	CreatedAt   time.Time    `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
 
 	Room *Room `json:"-" bun:"rel:belongs-to,join:room_id=id"`
 }
+
+// Attachment is a link rendered alongside the message text, e.g. an image or a CI build page.
+type Attachment struct {
+	Type  string `json:"type"`
+	URL   string `json:"url"`
+	Title string `json:"title,omitempty"`
+}
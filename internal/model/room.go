@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/uptrace/bun"
@@ -14,9 +15,71 @@ type Room struct {
 	PasswordHash []byte `json:"-" bun:"password_hash,nullzero"`
 	HasPassword  bool   `json:"has_password" bun:"-"`
 
+	Topic       string         `json:"topic,omitempty" bun:"topic,nullzero"`
+	Description string         `json:"description,omitempty" bun:"description,nullzero"`
+	AvatarURL   string         `json:"avatar_url,omitempty" bun:"avatar_url,nullzero"`
+	Metadata    map[string]any `json:"metadata" bun:"metadata,type:jsonb,nullzero,notnull,default:'{}'"`
+
+	// RetentionDays and RetentionMaxMessages limit how long and how many messages are kept; zero keeps them forever.
+	RetentionDays        int `json:"retention_days,omitempty" bun:"retention_days,nullzero"`
+	RetentionMaxMessages int `json:"retention_max_messages,omitempty" bun:"retention_max_messages,nullzero"`
+
 	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
 	UpdatedAt time.Time `json:"updated_at" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
 	DeletedAt time.Time `json:"deleted_at" bun:"deleted_at,soft_delete,nullzero"`
 
 	LastActiveAt time.Time `json:"last_active_at" bun:"last_active_at,notnull,default:current_timestamp"`
 }
+
+// Columns rooms can be ordered by; id always breaks ties in the same direction.
+const (
+	RoomOrderCreatedAt    = "created_at"
+	RoomOrderLastActiveAt = "last_active_at"
+	RoomOrderID           = "id"
+)
+
+// RoomOrder is a keyset ordering of rooms.
+type RoomOrder struct {
+	Column string
+	Desc   bool
+}
+
+// ParseRoomOrder parses orderings such as "last_active_at desc"; an empty string means "created_at desc".
+func ParseRoomOrder(s string) (RoomOrder, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return RoomOrder{Column: RoomOrderCreatedAt, Desc: true}, nil
+	}
+
+	column, dir, _ := strings.Cut(s, " ")
+	switch column {
+	case RoomOrderCreatedAt, RoomOrderLastActiveAt, RoomOrderID:
+	default:
+		return RoomOrder{}, ErrBadRequest
+	}
+	switch strings.TrimSpace(dir) {
+	case "", "desc":
+		return RoomOrder{Column: column, Desc: true}, nil
+	case "asc":
+		return RoomOrder{Column: column}, nil
+	default:
+		return RoomOrder{}, ErrBadRequest
+	}
+}
+
+func (o RoomOrder) String() string {
+	if o.Desc {
+		return o.Column + " desc"
+	}
+	return o.Column + " asc"
+}
+
+// RoomFilter narrows a room listing; zero-valued fields are not applied.
+type RoomFilter struct {
+	// Query matches room names by prefix or by trigram similarity.
+	Query       string
+	HasPassword *bool
+	ActiveSince *time.Time
+	// IDs restricts the listing to the given rooms when non-nil; an empty slice matches nothing.
+	IDs []int64
+}
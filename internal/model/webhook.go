@@ -0,0 +1,82 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	WebhookEventMessage      = "message"
+	WebhookEventJoin         = "join"
+	WebhookEventRoomUpdated  = "room_updated"
+	WebhookEventRoomDeleted  = "room_deleted"
+	WebhookEventRoomRestored = "room_restored"
+)
+
+// WebhookEvents lists every event type a webhook can subscribe to.
+var WebhookEvents = []string{
+	WebhookEventMessage,
+	WebhookEventJoin,
+	WebhookEventRoomUpdated,
+	WebhookEventRoomDeleted,
+	WebhookEventRoomRestored,
+}
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+type Webhook struct {
+	bun.BaseModel `bun:"table:webhooks" swaggerignore:"true"`
+
+	ID     int64    `json:"id" bun:"id,pk,autoincrement"`
+	RoomID int64    `json:"room_id" bun:"room_id,notnull"`
+	URL    string   `json:"url" bun:"url,notnull"`
+	Secret string   `json:"secret,omitempty" bun:"secret,notnull"`
+	Events []string `json:"events" bun:"events,array"`
+
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updated_at" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// Subscribed reports whether the webhook wants to receive the given event type.
+func (w *Webhook) Subscribed(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookDelivery struct {
+	bun.BaseModel `bun:"table:webhook_deliveries,alias:wd" swaggerignore:"true"`
+
+	ID            int64           `json:"id" bun:"id,pk,autoincrement"`
+	WebhookID     int64           `json:"webhook_id" bun:"webhook_id,notnull"`
+	Event         string          `json:"event" bun:"event,notnull"`
+	Payload       json.RawMessage `json:"payload" bun:"payload,type:jsonb,notnull"`
+	Status        string          `json:"status" bun:"status,notnull,default:'pending'"`
+	Attempts      int             `json:"attempts" bun:"attempts,notnull,default:0"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" bun:"next_attempt_at,nullzero,notnull,default:current_timestamp"`
+	LastError     string          `json:"last_error,omitempty" bun:"last_error,nullzero"`
+
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"updated_at" bun:"updated_at,nullzero,notnull,default:current_timestamp"`
+
+	Webhook *Webhook `json:"-" bun:"rel:belongs-to,join:webhook_id=id"`
+}
+
+// WebhookEvent is the JSON body posted to webhook subscribers.
+type WebhookEvent struct {
+	Event      string    `json:"event"`
+	RoomID     int64     `json:"room_id"`
+	Nick       string    `json:"nick,omitempty"`
+	Message    *Message  `json:"message,omitempty"`
+	Room       *Room     `json:"room,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
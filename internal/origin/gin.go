@@ -0,0 +1,68 @@
+package origin
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	allowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPatch, http.MethodDelete}
+	allowedHeaders = []string{"Authorization", "Content-Type", "X-Room-Password", "X-Request-ID"}
+	exposedHeaders = []string{"X-Next-Cursor", "X-Prev-Cursor", "X-Total-Count", "X-Request-ID"}
+)
+
+const preflightMaxAge = 10 * time.Minute
+
+// CORS returns middleware that lets browsers on allowed origins call the routes it guards.
+// Preflight requests are answered directly: 204 for allowed origins, 403 otherwise. Other requests from
+// origins that are not allowed are served without CORS headers, so same-origin pages keep working and browsers block the rest.
+// The routes need OPTIONS handlers for the middleware to see preflights; see Preflight.
+func CORS(m *Matcher) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if !m.Allowed(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		if !preflight {
+			c.Header("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+		c.Header("Access-Control-Allow-Methods", strings.Join(allowedMethods, ", "))
+		c.Header("Access-Control-Allow-Headers", strings.Join(allowedHeaders, ", "))
+		c.Header("Access-Control-Max-Age", strconv.Itoa(int(preflightMaxAge.Seconds())))
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// Preflight registers OPTIONS handlers for every path of the group, so that CORS sees preflight requests.
+// OPTIONS requests that CORS does not answer get 204 with the allowed methods.
+func Preflight(g *gin.RouterGroup) {
+	h := func(c *gin.Context) {
+		c.Header("Allow", strings.Join(slices.Concat(allowedMethods, []string{http.MethodOptions}), ", "))
+		c.Status(http.StatusNoContent)
+	}
+	g.OPTIONS("", h)
+	g.OPTIONS("/*path", h)
+}
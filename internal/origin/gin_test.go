@@ -0,0 +1,71 @@
+package origin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m, err := NewMatcher([]string{"https://*.example.com"})
+	require.NoError(t, err)
+
+	router := gin.New()
+	rooms := router.Group("/rooms", CORS(m))
+	Preflight(rooms)
+	rooms.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	rooms.POST("/:id/restore", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(method, path, origin string, preflight bool) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if preflight {
+			r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("allowed request", func(t *testing.T) {
+		w := do(http.MethodGet, "/rooms", "https://app.example.com", false)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Next-Cursor")
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+
+	t.Run("allowed preflight", func(t *testing.T) {
+		w := do(http.MethodOptions, "/rooms/1/restore", "https://app.example.com", true)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), http.MethodPatch)
+		assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), "X-Room-Password")
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("rejected preflight", func(t *testing.T) {
+		w := do(http.MethodOptions, "/rooms", "https://evil.com", true)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("request from another origin is served without CORS headers", func(t *testing.T) {
+		w := do(http.MethodGet, "/rooms", "https://evil.com", false)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("plain OPTIONS", func(t *testing.T) {
+		w := do(http.MethodOptions, "/rooms", "", false)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Contains(t, w.Header().Get("Allow"), http.MethodOptions)
+	})
+}
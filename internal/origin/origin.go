@@ -0,0 +1,97 @@
+// Package origin decides which browser origins may call the API and open WebSockets.
+package origin
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Matcher matches Origin headers against a list of allowed patterns:
+// "*" allows any origin, "https://chat.example.com" allows exactly that origin,
+// and "https://*.example.com" allows every subdomain of example.com, but not example.com itself.
+// Schemes and ports must match; a missing port means the scheme's default.
+type Matcher struct {
+	any      bool
+	patterns []pattern
+}
+
+type pattern struct {
+	scheme string
+	// host is the exact host, or the parent domain with a leading dot for wildcard patterns.
+	host     string
+	port     string
+	wildcard bool
+}
+
+// NewMatcher parses the allowed origin patterns.
+func NewMatcher(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, p := range patterns {
+		if p == "*" {
+			m.any = true
+			continue
+		}
+		u, err := url.Parse(p)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("%q is not an origin like https://example.com or https://*.example.com", p)
+		}
+		pat := pattern{scheme: u.Scheme, host: strings.ToLower(u.Hostname()), port: port(u)}
+		if rest, ok := strings.CutPrefix(pat.host, "*."); ok {
+			if rest == "" || strings.Contains(rest, "*") {
+				return nil, fmt.Errorf("%q: only a leading *. wildcard is supported", p)
+			}
+			pat.host, pat.wildcard = "."+rest, true
+		} else if strings.Contains(pat.host, "*") {
+			return nil, fmt.Errorf("%q: only a leading *. wildcard is supported", p)
+		}
+		m.patterns = append(m.patterns, pat)
+	}
+	return m, nil
+}
+
+// port returns the explicit port of u or the default one of its scheme.
+func port(u *url.URL) string {
+	if p := u.Port(); p != "" {
+		return p
+	}
+	if u.Scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// Allowed reports whether the Origin header value matches an allowed pattern.
+func (m *Matcher) Allowed(origin string) bool {
+	if m.any {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, p := range m.patterns {
+		if p.scheme != u.Scheme || p.port != port(u) {
+			continue
+		}
+		if p.wildcard && strings.HasSuffix(host, p.host) || !p.wildcard && host == p.host {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin is meant for websocket.Upgrader. It accepts requests without an Origin header, which do not come
+// from browsers, same-origin requests and allowed origins, which protects against cross-site WebSocket hijacking.
+func (m *Matcher) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return m.Allowed(origin)
+}
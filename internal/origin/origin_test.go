@@ -0,0 +1,65 @@
+package origin
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Matcher_Allowed(t *testing.T) {
+	m, err := NewMatcher([]string{"https://chat.example.com", "https://*.example.org", "http://localhost:3000"})
+	require.NoError(t, err)
+
+	for origin, want := range map[string]bool{
+		"https://chat.example.com":      true,
+		"https://CHAT.example.com":      true,
+		"https://chat.example.com:443":  true,
+		"http://chat.example.com":       false,
+		"https://chat.example.com:8443": false,
+		"https://evil.com":              false,
+		"https://a.example.org":         true,
+		"https://a.b.example.org":       true,
+		"https://example.org":           false,
+		"https://evilexample.org":       false,
+		"http://localhost:3000":         true,
+		"http://localhost":              false,
+		"null":                          false,
+		"":                              false,
+	} {
+		assert.Equal(t, want, m.Allowed(origin), origin)
+	}
+
+	all, err := NewMatcher([]string{"*"})
+	require.NoError(t, err)
+	assert.True(t, all.Allowed("https://anything.test"))
+
+	none, err := NewMatcher(nil)
+	require.NoError(t, err)
+	assert.False(t, none.Allowed("https://chat.example.com"))
+}
+
+func Test_NewMatcher_Invalid(t *testing.T) {
+	for _, p := range []string{"example.com", "ftp://example.com", "https://example.com/app", "https://ex*ample.com", "https://*.", "https://*.*.example.com"} {
+		_, err := NewMatcher([]string{p})
+		assert.Error(t, err, p)
+	}
+}
+
+func Test_Matcher_CheckOrigin(t *testing.T) {
+	m, err := NewMatcher([]string{"https://*.example.com"})
+	require.NoError(t, err)
+
+	check := func(host, origin string) bool {
+		r := httptest.NewRequest("GET", "http://"+host+"/ws", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		return m.CheckOrigin(r)
+	}
+	assert.True(t, check("chat.internal:8081", ""), "no Origin header")
+	assert.True(t, check("chat.internal:8081", "http://chat.internal:8081"), "same origin")
+	assert.True(t, check("chat.internal:8081", "https://app.example.com"), "allowed origin")
+	assert.False(t, check("chat.internal:8081", "https://evil.com"), "cross-site origin")
+}
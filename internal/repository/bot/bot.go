@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"context"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+var _ repository.BotRepository = (*Repository)(nil)
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Insert(ctx context.Context, bot *model.Bot) error {
+	_, err := r.db.NewInsert().Model(bot).Exec(ctx)
+	if err != nil {
+		return repository.IsUniqueViolationError(err)
+	}
+	return nil
+}
+
+func (r *Repository) GetByAPIKeyHash(ctx context.Context, apiKeyHash string) (*model.Bot, error) {
+	bot := new(model.Bot)
+	err := r.db.NewSelect().Model(bot).Where("api_key_hash = ?", apiKeyHash).Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return bot, nil
+}
+
+// ReplaceCommands atomically swaps the set of commands registered by a bot.
+// It returns model.ErrConflict if another bot already owns one of the names.
+func (r *Repository) ReplaceCommands(ctx context.Context, botID int64, commands []model.BotCommand) error {
+	return r.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().
+			Model((*model.BotCommand)(nil)).
+			Where("bot_id = ?", botID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if len(commands) == 0 {
+			return nil
+		}
+
+		for i := range commands {
+			commands[i].BotID = botID
+		}
+		_, err = tx.NewInsert().Model(&commands).Exec(ctx)
+		if err != nil {
+			return repository.IsUniqueViolationError(err)
+		}
+		return nil
+	})
+}
+
+// ListCommands returns the commands of one bot, or of every bot when botID is nil.
+func (r *Repository) ListCommands(ctx context.Context, botID *int64) ([]model.BotCommand, error) {
+	var commands []model.BotCommand
+	q := r.db.NewSelect().
+		Model(&commands)
+
+	if botID != nil {
+		q.Where("bot_id = ?", *botID)
+	}
+
+	err := q.
+		Order("name ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return commands, nil
+}
+
+// GetCommandByName returns a registered command together with the bot that owns it.
+func (r *Repository) GetCommandByName(ctx context.Context, name string) (*model.BotCommand, error) {
+	command := new(model.BotCommand)
+	err := r.db.NewSelect().
+		Model(command).
+		Relation("Bot").
+		Where("bc.name = ?", name).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return command, nil
+}
@@ -0,0 +1,131 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	testdb "github.com/Rasulikus/chat/internal/repository/test_db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db      *bun.DB
+	botRepo *Repository
+	ctx     context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.botRepo = NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func (ts *testSuite) insertBot(t *testing.T, name string) *model.Bot {
+	t.Helper()
+	bot := &model.Bot{
+		Name:           name,
+		APIKeyHash:     name + "-hash",
+		CallbackSecret: "secret",
+	}
+	require.NoError(t, ts.botRepo.Insert(ts.ctx, bot))
+	return bot
+}
+
+func Test_Repo_Insert(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+
+	bot := ts.insertBot(t, "ci")
+	assert.NotZero(t, bot.ID)
+	assert.WithinDuration(t, time.Now(), bot.CreatedAt, time.Second)
+
+	t.Run("duplicate name", func(t *testing.T) {
+		err := ts.botRepo.Insert(ts.ctx, &model.Bot{Name: "ci", APIKeyHash: "other", CallbackSecret: "s"})
+		require.ErrorIs(t, err, model.ErrConflict)
+	})
+}
+
+func Test_Repo_GetByAPIKeyHash(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	bot := ts.insertBot(t, "ci")
+
+	testCases := []struct {
+		name    string
+		hash    string
+		wantErr bool
+	}{
+		{"success", bot.APIKeyHash, false},
+		{"not found", "unknown", true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := ts.botRepo.GetByAPIKeyHash(ts.ctx, testCase.hash)
+			if testCase.wantErr {
+				require.ErrorIs(t, err, model.ErrNotFound)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, bot.ID, got.ID)
+			}
+		})
+	}
+}
+
+func Test_Repo_Commands(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	ci := ts.insertBot(t, "ci")
+	other := ts.insertBot(t, "other")
+
+	t.Run("replace commands", func(t *testing.T) {
+		err := ts.botRepo.ReplaceCommands(ts.ctx, ci.ID, []model.BotCommand{
+			{Name: "deploy", Description: "deploy a build"},
+			{Name: "status"},
+		})
+		require.NoError(t, err)
+		err = ts.botRepo.ReplaceCommands(ts.ctx, ci.ID, []model.BotCommand{{Name: "deploy"}})
+		require.NoError(t, err)
+
+		commands, err := ts.botRepo.ListCommands(ts.ctx, &ci.ID)
+		require.NoError(t, err)
+		require.Len(t, commands, 1)
+		assert.Equal(t, "deploy", commands[0].Name)
+	})
+
+	t.Run("name owned by another bot", func(t *testing.T) {
+		err := ts.botRepo.ReplaceCommands(ts.ctx, other.ID, []model.BotCommand{{Name: "deploy"}})
+		require.ErrorIs(t, err, model.ErrConflict)
+	})
+
+	t.Run("get command with bot", func(t *testing.T) {
+		command, err := ts.botRepo.GetCommandByName(ts.ctx, "deploy")
+		require.NoError(t, err)
+		require.NotNil(t, command.Bot)
+		assert.Equal(t, ci.Name, command.Bot.Name)
+
+		_, err = ts.botRepo.GetCommandByName(ts.ctx, "status")
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("list all commands", func(t *testing.T) {
+		commands, err := ts.botRepo.ListCommands(ts.ctx, nil)
+		require.NoError(t, err)
+		assert.Len(t, commands, 1)
+	})
+}
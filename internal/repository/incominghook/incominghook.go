@@ -0,0 +1,77 @@
+package incominghook
+
+import (
+	"context"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+var _ repository.IncomingWebhookRepository = (*Repository)(nil)
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Insert(ctx context.Context, hook *model.IncomingWebhook) error {
+	_, err := r.db.NewInsert().Model(hook).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*model.IncomingWebhook, error) {
+	hook := new(model.IncomingWebhook)
+	err := r.db.NewSelect().Model(hook).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return hook, nil
+}
+
+func (r *Repository) GetByTokenHash(ctx context.Context, tokenHash string) (*model.IncomingWebhook, error) {
+	hook := new(model.IncomingWebhook)
+	err := r.db.NewSelect().Model(hook).Where("token_hash = ?", tokenHash).Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return hook, nil
+}
+
+func (r *Repository) ListByRoom(ctx context.Context, roomID int64) ([]model.IncomingWebhook, error) {
+	var hooks []model.IncomingWebhook
+	err := r.db.NewSelect().
+		Model(&hooks).
+		Where("room_id = ?", roomID).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.NewDelete().Model((*model.IncomingWebhook)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
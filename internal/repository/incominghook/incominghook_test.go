@@ -0,0 +1,122 @@
+package incominghook
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository/room"
+	testdb "github.com/Rasulikus/chat/internal/repository/test_db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db       *bun.DB
+	hookRepo *Repository
+	roomRepo *room.Repository
+	ctx      context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.hookRepo = NewRepository(suite.db)
+	suite.roomRepo = room.NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func (ts *testSuite) insertHook(t *testing.T) *model.IncomingWebhook {
+	t.Helper()
+	testRoom := &model.Room{
+		Name: "testroom",
+	}
+	require.NoError(t, ts.roomRepo.Insert(ts.ctx, testRoom))
+
+	hook := &model.IncomingWebhook{
+		RoomID:    testRoom.ID,
+		Name:      "ci-bot",
+		TokenHash: "hash",
+	}
+	require.NoError(t, ts.hookRepo.Insert(ts.ctx, hook))
+	return hook
+}
+
+func Test_Repo_Insert(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+
+	hook := ts.insertHook(t)
+	assert.NotZero(t, hook.ID)
+	assert.WithinDuration(t, time.Now(), hook.CreatedAt, time.Second)
+
+	t.Run("duplicate token hash", func(t *testing.T) {
+		err := ts.hookRepo.Insert(ts.ctx, &model.IncomingWebhook{
+			RoomID:    hook.RoomID,
+			Name:      "other",
+			TokenHash: hook.TokenHash,
+		})
+		require.Error(t, err)
+	})
+}
+
+func Test_Repo_GetByTokenHash(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	hook := ts.insertHook(t)
+
+	testCases := []struct {
+		name      string
+		tokenHash string
+		wantErr   bool
+	}{
+		{"success", hook.TokenHash, false},
+		{"not found", "unknown", true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			got, err := ts.hookRepo.GetByTokenHash(ts.ctx, testCase.tokenHash)
+			if testCase.wantErr {
+				require.ErrorIs(t, err, model.ErrNotFound)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, hook.ID, got.ID)
+				assert.Equal(t, hook.RoomID, got.RoomID)
+			}
+		})
+	}
+}
+
+func Test_Repo_ListByRoom(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	hook := ts.insertHook(t)
+
+	hooks, err := ts.hookRepo.ListByRoom(ts.ctx, hook.RoomID)
+	require.NoError(t, err)
+	assert.Len(t, hooks, 1)
+}
+
+func Test_Repo_Delete(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	hook := ts.insertHook(t)
+
+	require.NoError(t, ts.hookRepo.Delete(ts.ctx, hook.ID))
+	_, err := ts.hookRepo.GetByID(ts.ctx, hook.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	require.ErrorIs(t, ts.hookRepo.Delete(ts.ctx, hook.ID), model.ErrNotFound)
+}
@@ -0,0 +1,96 @@
+package jobrun
+
+import (
+	"context"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+var _ repository.JobRunRepository = (*Repository)(nil)
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Insert(ctx context.Context, run *model.JobRun) error {
+	_, err := r.db.NewInsert().Model(run).Returning("id, status, started_at").Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Finish records the outcome of a run and sets its finished_at timestamp.
+func (r *Repository) Finish(ctx context.Context, run *model.JobRun) error {
+	res, err := r.db.NewUpdate().
+		Model(run).
+		Column("status", "error").
+		Set("finished_at = current_timestamp").
+		WherePK().
+		Returning("finished_at").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// Last returns the most recently scheduled run of a job.
+func (r *Repository) Last(ctx context.Context, job string) (*model.JobRun, error) {
+	run := new(model.JobRun)
+	err := r.db.NewSelect().
+		Model(run).
+		Where("job = ?", job).
+		Order("scheduled_at DESC", "id DESC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return run, nil
+}
+
+// LastPerJob returns the most recently scheduled run of every job that has run at least once, as Last does for one job.
+func (r *Repository) LastPerJob(ctx context.Context) ([]model.JobRun, error) {
+	var runs []model.JobRun
+	err := r.db.NewSelect().
+		Model(&runs).
+		DistinctOn("job").
+		Order("job", "scheduled_at DESC", "id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// DeleteOlderThan deletes the history of runs started more than d ago.
+// It returns the number of deleted runs.
+func (r *Repository) DeleteOlderThan(ctx context.Context, d time.Duration) (int64, error) {
+	res, err := r.db.NewDelete().
+		Model((*model.JobRun)(nil)).
+		Where("started_at < ?", time.Now().Add(-d)).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
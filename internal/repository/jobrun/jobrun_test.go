@@ -0,0 +1,112 @@
+package jobrun
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	testdb "github.com/Rasulikus/chat/internal/repository/test_db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db      *bun.DB
+	runRepo *Repository
+	ctx     context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.runRepo = NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func Test_Repo_InsertFinish(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+
+	run := &model.JobRun{Job: "room_cleanup", Instance: "host", ScheduledAt: time.Now()}
+	require.NoError(t, ts.runRepo.Insert(ts.ctx, run))
+	assert.NotZero(t, run.ID)
+	assert.Equal(t, model.JobRunStatusRunning, run.Status)
+	assert.WithinDuration(t, time.Now(), run.StartedAt, time.Second)
+
+	run.Status = model.JobRunStatusFailed
+	run.Error = "boom"
+	require.NoError(t, ts.runRepo.Finish(ts.ctx, run))
+	assert.WithinDuration(t, time.Now(), run.FinishedAt, time.Second)
+
+	last, err := ts.runRepo.Last(ts.ctx, "room_cleanup")
+	require.NoError(t, err)
+	assert.Equal(t, run.ID, last.ID)
+	assert.Equal(t, model.JobRunStatusFailed, last.Status)
+	assert.Equal(t, "boom", last.Error)
+
+	t.Run("not found err", func(t *testing.T) {
+		_, err := ts.runRepo.Last(ts.ctx, "unknown")
+		require.ErrorIs(t, err, model.ErrNotFound)
+		err = ts.runRepo.Finish(ts.ctx, &model.JobRun{ID: 9999999, Status: model.JobRunStatusSucceeded})
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
+
+func Test_Repo_LastPerJob(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+
+	now := time.Now()
+	var latest []int64
+	for _, job := range []string{"a", "b"} {
+		for i := 0; i < 2; i++ {
+			run := &model.JobRun{Job: job, Instance: "host", ScheduledAt: now.Add(time.Duration(i) * time.Minute)}
+			require.NoError(t, ts.runRepo.Insert(ts.ctx, run))
+			if i == 1 {
+				latest = append(latest, run.ID)
+			}
+		}
+	}
+
+	// A catch-up run of an earlier slot started last is not the latest run.
+	catchUp := &model.JobRun{Job: "a", Instance: "host", ScheduledAt: now.Add(-time.Hour)}
+	require.NoError(t, ts.runRepo.Insert(ts.ctx, catchUp))
+
+	runs, err := ts.runRepo.LastPerJob(ts.ctx)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "a", runs[0].Job)
+	assert.Equal(t, latest[0], runs[0].ID)
+	assert.Equal(t, "b", runs[1].Job)
+	assert.Equal(t, latest[1], runs[1].ID)
+}
+
+func Test_Repo_DeleteOlderThan(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+
+	old := &model.JobRun{Job: "a", Instance: "host", ScheduledAt: time.Now(), StartedAt: time.Now().Add(-48 * time.Hour)}
+	recent := &model.JobRun{Job: "a", Instance: "host", ScheduledAt: time.Now()}
+	require.NoError(t, ts.runRepo.Insert(ts.ctx, old))
+	require.NoError(t, ts.runRepo.Insert(ts.ctx, recent))
+
+	affected, err := ts.runRepo.DeleteOlderThan(ts.ctx, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	last, err := ts.runRepo.Last(ts.ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, recent.ID, last.ID)
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/uptrace/bun"
+)
+
+// LockKey derives a Postgres advisory lock key from a name.
+func LockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// WithAdvisoryLock runs fn while holding the session-level advisory lock key, so only one replica runs it at a time.
+// It reports false without running fn when another session holds the lock.
+func WithAdvisoryLock(ctx context.Context, db *bun.DB, key int64, fn func(ctx context.Context) error) (bool, error) {
+	// Session locks belong to a connection, so lock and unlock must use the same one.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(?)", key).Scan(&locked); err != nil {
+		return false, err
+	}
+	if !locked {
+		return false, nil
+	}
+	defer func() {
+		// The caller's ctx may already be cancelled; the lock must still be released before the connection is reused.
+		_, _ = conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock(?)", key)
+	}()
+
+	return true, fn(ctx)
+}
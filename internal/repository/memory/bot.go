@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+var _ repository.BotRepository = (*BotRepository)(nil)
+
+type BotRepository struct {
+	s *Store
+}
+
+func NewBotRepository(s *Store) *BotRepository {
+	return &BotRepository{
+		s: s,
+	}
+}
+
+// Insert stores a new bot; it returns model.ErrConflict if the name or API key is taken.
+func (r *BotRepository) Insert(_ context.Context, bot *model.Bot) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, other := range r.s.bots {
+		if other.Name == bot.Name || other.APIKeyHash == bot.APIKeyHash {
+			return model.ErrConflict
+		}
+	}
+	current := now()
+	bot.ID = r.s.nextID("bots")
+	bot.CreatedAt = stampOr(bot.CreatedAt, current)
+	r.s.bots[bot.ID] = cloneBot(bot)
+	return nil
+}
+
+func (r *BotRepository) GetByAPIKeyHash(_ context.Context, apiKeyHash string) (*model.Bot, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, bot := range r.s.bots {
+		if bot.APIKeyHash == apiKeyHash {
+			return cloneBot(bot), nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+// ReplaceCommands atomically swaps the set of commands registered by a bot.
+// It returns model.ErrConflict if another bot already owns one of the names.
+func (r *BotRepository) ReplaceCommands(_ context.Context, botID int64, commands []model.BotCommand) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.bots[botID]; !ok && len(commands) > 0 {
+		return constraintError("commands reference missing bot %d", botID)
+	}
+	names := make(map[string]bool, len(commands))
+	for _, command := range commands {
+		if names[command.Name] {
+			return model.ErrConflict
+		}
+		names[command.Name] = true
+	}
+	for _, other := range r.s.botCommands {
+		if other.BotID != botID && names[other.Name] {
+			return model.ErrConflict
+		}
+	}
+
+	for id, command := range r.s.botCommands {
+		if command.BotID == botID {
+			delete(r.s.botCommands, id)
+		}
+	}
+	current := now()
+	for i := range commands {
+		command := &commands[i]
+		command.ID = r.s.nextID("bot_commands")
+		command.BotID = botID
+		command.CreatedAt = stampOr(command.CreatedAt, current)
+		r.s.botCommands[command.ID] = cloneBotCommand(command)
+	}
+	return nil
+}
+
+// ListCommands returns the commands of one bot, or of every bot when botID is nil.
+func (r *BotRepository) ListCommands(_ context.Context, botID *int64) ([]model.BotCommand, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var commands []model.BotCommand
+	for _, command := range r.s.botCommands {
+		if botID == nil || command.BotID == *botID {
+			commands = append(commands, *cloneBotCommand(command))
+		}
+	}
+	slices.SortFunc(commands, func(a, b model.BotCommand) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return commands, nil
+}
+
+// GetCommandByName returns a registered command together with the bot that owns it.
+func (r *BotRepository) GetCommandByName(_ context.Context, name string) (*model.BotCommand, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, command := range r.s.botCommands {
+		if command.Name == name {
+			c := cloneBotCommand(command)
+			c.Bot = cloneBot(r.s.bots[command.BotID])
+			return c, nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func cloneBot(bot *model.Bot) *model.Bot {
+	c := *bot
+	c.APIKey = ""
+	return &c
+}
+
+func cloneBotCommand(command *model.BotCommand) *model.BotCommand {
+	c := *command
+	c.Bot = nil
+	return &c
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+var _ repository.IncomingWebhookRepository = (*IncomingWebhookRepository)(nil)
+
+type IncomingWebhookRepository struct {
+	s *Store
+}
+
+func NewIncomingWebhookRepository(s *Store) *IncomingWebhookRepository {
+	return &IncomingWebhookRepository{
+		s: s,
+	}
+}
+
+func (r *IncomingWebhookRepository) Insert(_ context.Context, hook *model.IncomingWebhook) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.rooms[hook.RoomID]; !ok {
+		return constraintError("incoming webhook references missing room %d", hook.RoomID)
+	}
+	for _, other := range r.s.incomingHooks {
+		if other.TokenHash == hook.TokenHash {
+			return constraintError("duplicate incoming webhook token")
+		}
+	}
+	current := now()
+	hook.ID = r.s.nextID("incoming_webhooks")
+	hook.CreatedAt = stampOr(hook.CreatedAt, current)
+	r.s.incomingHooks[hook.ID] = cloneIncomingWebhook(hook)
+	return nil
+}
+
+func (r *IncomingWebhookRepository) GetByID(_ context.Context, id int64) (*model.IncomingWebhook, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	hook, ok := r.s.incomingHooks[id]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return cloneIncomingWebhook(hook), nil
+}
+
+func (r *IncomingWebhookRepository) GetByTokenHash(_ context.Context, tokenHash string) (*model.IncomingWebhook, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	for _, hook := range r.s.incomingHooks {
+		if hook.TokenHash == tokenHash {
+			return cloneIncomingWebhook(hook), nil
+		}
+	}
+	return nil, model.ErrNotFound
+}
+
+func (r *IncomingWebhookRepository) ListByRoom(_ context.Context, roomID int64) ([]model.IncomingWebhook, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var hooks []model.IncomingWebhook
+	for _, id := range sortedIDs(r.s.incomingHooks) {
+		if hook := r.s.incomingHooks[id]; hook.RoomID == roomID {
+			hooks = append(hooks, *cloneIncomingWebhook(hook))
+		}
+	}
+	return hooks, nil
+}
+
+func (r *IncomingWebhookRepository) Delete(_ context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.incomingHooks[id]; !ok {
+		return model.ErrNotFound
+	}
+	delete(r.s.incomingHooks, id)
+	return nil
+}
+
+func cloneIncomingWebhook(hook *model.IncomingWebhook) *model.IncomingWebhook {
+	c := *hook
+	c.Token = ""
+	return &c
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+var _ repository.JobRunRepository = (*JobRunRepository)(nil)
+
+type JobRunRepository struct {
+	s *Store
+}
+
+func NewJobRunRepository(s *Store) *JobRunRepository {
+	return &JobRunRepository{
+		s: s,
+	}
+}
+
+func (r *JobRunRepository) Insert(_ context.Context, run *model.JobRun) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	current := now()
+	run.ID = r.s.nextID("job_runs")
+	run.Status = cmp.Or(run.Status, model.JobRunStatusRunning)
+	run.ScheduledAt = stamp(run.ScheduledAt)
+	run.StartedAt = stampOr(run.StartedAt, current)
+	stored := *run
+	r.s.jobRuns[run.ID] = &stored
+	return nil
+}
+
+// Finish records the outcome of a run and sets its finished_at timestamp.
+func (r *JobRunRepository) Finish(_ context.Context, run *model.JobRun) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.s.jobRuns[run.ID]
+	if !ok {
+		return model.ErrNotFound
+	}
+	stored.Status = run.Status
+	stored.Error = run.Error
+	stored.FinishedAt = now()
+
+	run.FinishedAt = stored.FinishedAt
+	return nil
+}
+
+// Last returns the most recently scheduled run of a job.
+func (r *JobRunRepository) Last(_ context.Context, job string) (*model.JobRun, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var last *model.JobRun
+	for _, run := range r.s.jobRuns {
+		if run.Job != job {
+			continue
+		}
+		if last == nil || cmp.Or(run.ScheduledAt.Compare(last.ScheduledAt), cmp.Compare(run.ID, last.ID)) > 0 {
+			last = run
+		}
+	}
+	if last == nil {
+		return nil, model.ErrNotFound
+	}
+	found := *last
+	return &found, nil
+}
+
+// LastPerJob returns the most recently scheduled run of every job that has run at least once, as Last does for one job.
+func (r *JobRunRepository) LastPerJob(_ context.Context) ([]model.JobRun, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	last := make(map[string]*model.JobRun)
+	for _, run := range r.s.jobRuns {
+		other := last[run.Job]
+		if other == nil || cmp.Or(run.ScheduledAt.Compare(other.ScheduledAt), cmp.Compare(run.ID, other.ID)) > 0 {
+			last[run.Job] = run
+		}
+	}
+
+	var runs []model.JobRun
+	for _, run := range last {
+		runs = append(runs, *run)
+	}
+	slices.SortFunc(runs, func(a, b model.JobRun) int {
+		return cmp.Compare(a.Job, b.Job)
+	})
+	return runs, nil
+}
+
+// DeleteOlderThan deletes the history of runs started more than d ago.
+// It returns the number of deleted runs.
+func (r *JobRunRepository) DeleteOlderThan(_ context.Context, d time.Duration) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	cutoff := time.Now().Add(-d)
+	var deleted int64
+	for id, run := range r.s.jobRuns {
+		if run.StartedAt.Before(cutoff) {
+			delete(r.s.jobRuns, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,180 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRepos(*testing.T) repotest.Repositories {
+	s := NewStore()
+	return repotest.Repositories{
+		Rooms:    NewRoomRepository(s),
+		Messages: NewMessageRepository(s),
+	}
+}
+
+func Test_RoomRepository_Contract(t *testing.T) {
+	repotest.TestRoomRepository(t, newRepos)
+}
+
+func Test_MessageRepository_Contract(t *testing.T) {
+	repotest.TestMessageRepository(t, newRepos)
+}
+
+func Test_Store_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore()
+	rooms, messages := NewRoomRepository(s), NewMessageRepository(s)
+	room := &model.Room{Name: "busy"}
+	require.NoError(t, rooms.Insert(ctx, room))
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			for range 50 {
+				assert.NoError(t, messages.Insert(ctx, &model.Message{Nick: "nick", Text: "text", RoomID: room.ID}))
+				assert.NoError(t, rooms.TouchActivity(ctx, room.ID))
+				_, err := messages.ListByRoom(ctx, room.ID, nil, 10)
+				assert.NoError(t, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	page, err := messages.ListByRoom(ctx, room.ID, nil, 1)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, int64(1000), page[0].ID)
+}
+
+func Test_Store_Copies(t *testing.T) {
+	ctx := context.Background()
+	rooms := NewRoomRepository(NewStore())
+	room := &model.Room{Name: "room", Metadata: map[string]any{"team": "ops"}}
+	require.NoError(t, rooms.Insert(ctx, room))
+
+	room.Metadata["team"] = "dev"
+	got, err := rooms.GetByID(ctx, room.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "ops", got.Metadata["team"])
+
+	got.Name = "renamed"
+	again, err := rooms.GetByID(ctx, room.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "room", again.Name)
+}
+
+func Test_WebhookRepository(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore()
+	rooms, hooks := NewRoomRepository(s), NewWebhookRepository(s)
+	room := &model.Room{Name: "room"}
+	require.NoError(t, rooms.Insert(ctx, room))
+	hook := &model.Webhook{RoomID: room.ID, URL: "http://example.com", Secret: "secret", Events: []string{model.WebhookEventMessage}}
+	require.NoError(t, hooks.Insert(ctx, hook))
+	require.Error(t, hooks.Insert(ctx, &model.Webhook{RoomID: 9999999}))
+
+	deliveries := []model.WebhookDelivery{
+		{WebhookID: hook.ID, Event: model.WebhookEventMessage, Payload: []byte(`{}`)},
+		{WebhookID: hook.ID, Event: model.WebhookEventMessage, Payload: []byte(`{}`), NextAttemptAt: time.Now().Add(time.Hour)},
+	}
+	require.NoError(t, hooks.InsertDeliveries(ctx, deliveries))
+
+	claimed, err := hooks.ClaimDueDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, deliveries[0].ID, claimed[0].ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	require.NotNil(t, claimed[0].Webhook)
+	assert.Equal(t, hook.URL, claimed[0].Webhook.URL)
+
+	// A leased delivery is not claimed again until the lease runs out.
+	claimed, err = hooks.ClaimDueDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	require.NoError(t, hooks.MarkFailed(ctx, deliveries[0].ID, "boom", -time.Second, false))
+	claimed, err = hooks.ClaimDueDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, 2, claimed[0].Attempts)
+	assert.Equal(t, "boom", claimed[0].LastError)
+
+	require.NoError(t, hooks.Delete(ctx, hook.ID))
+	require.ErrorIs(t, hooks.Delete(ctx, hook.ID), model.ErrNotFound)
+	assert.Empty(t, s.deliveries)
+}
+
+func Test_BotRepository(t *testing.T) {
+	ctx := context.Background()
+	bots := NewBotRepository(NewStore())
+	deploy := &model.Bot{Name: "deploy", APIKeyHash: "a"}
+	weather := &model.Bot{Name: "weather", APIKeyHash: "b"}
+	require.NoError(t, bots.Insert(ctx, deploy))
+	require.NoError(t, bots.Insert(ctx, weather))
+	require.ErrorIs(t, bots.Insert(ctx, &model.Bot{Name: "deploy", APIKeyHash: "c"}), model.ErrConflict)
+
+	got, err := bots.GetByAPIKeyHash(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, weather.ID, got.ID)
+
+	require.NoError(t, bots.ReplaceCommands(ctx, deploy.ID, []model.BotCommand{{Name: "ship"}, {Name: "rollback"}}))
+	err = bots.ReplaceCommands(ctx, weather.ID, []model.BotCommand{{Name: "forecast"}, {Name: "ship"}})
+	require.ErrorIs(t, err, model.ErrConflict)
+	require.NoError(t, bots.ReplaceCommands(ctx, deploy.ID, []model.BotCommand{{Name: "ship"}}))
+
+	commands, err := bots.ListCommands(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, commands, 1)
+	assert.Equal(t, "ship", commands[0].Name)
+
+	command, err := bots.GetCommandByName(ctx, "ship")
+	require.NoError(t, err)
+	require.NotNil(t, command.Bot)
+	assert.Equal(t, "deploy", command.Bot.Name)
+	_, err = bots.GetCommandByName(ctx, "rollback")
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_JobRunRepository(t *testing.T) {
+	ctx := context.Background()
+	runs := NewJobRunRepository(NewStore())
+	slot := time.Now().Truncate(time.Minute)
+	first := &model.JobRun{Job: "cleanup", Instance: "a", ScheduledAt: slot.Add(-time.Minute)}
+	second := &model.JobRun{Job: "cleanup", Instance: "b", ScheduledAt: slot}
+	other := &model.JobRun{Job: "retention", Instance: "a", ScheduledAt: slot}
+	// catchUp starts last but makes up for an earlier slot, so it is not the latest run.
+	catchUp := &model.JobRun{Job: "cleanup", Instance: "c", ScheduledAt: slot.Add(-2 * time.Minute)}
+	for _, run := range []*model.JobRun{first, second, other, catchUp} {
+		require.NoError(t, runs.Insert(ctx, run))
+		assert.Equal(t, model.JobRunStatusRunning, run.Status)
+	}
+
+	second.Status = model.JobRunStatusSucceeded
+	require.NoError(t, runs.Finish(ctx, second))
+	assert.False(t, second.FinishedAt.IsZero())
+
+	last, err := runs.Last(ctx, "cleanup")
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, last.ID)
+	assert.Equal(t, model.JobRunStatusSucceeded, last.Status)
+	_, err = runs.Last(ctx, "unknown")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	perJob, err := runs.LastPerJob(ctx)
+	require.NoError(t, err)
+	require.Len(t, perJob, 2)
+	assert.Equal(t, second.ID, perJob[0].ID)
+	assert.Equal(t, other.ID, perJob[1].ID)
+
+	deleted, err := runs.DeleteOlderThan(ctx, -time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+var _ repository.MessageRepository = (*MessageRepository)(nil)
+
+type MessageRepository struct {
+	s *Store
+}
+
+func NewMessageRepository(s *Store) *MessageRepository {
+	return &MessageRepository{
+		s: s,
+	}
+}
+
+// Insert stores a new message. Like the messages table, it requires an existing room and a nick on user messages.
+func (r *MessageRepository) Insert(_ context.Context, message *model.Message) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.rooms[message.RoomID]; !ok {
+		return constraintError("message references missing room %d", message.RoomID)
+	}
+	kind := message.Kind
+	if kind == "" {
+		kind = model.MessageKindUser
+	}
+	if kind == model.MessageKindUser && message.Nick == "" {
+		return constraintError("user message without a nick")
+	}
+
+	current := now()
+	message.ID = r.s.nextID("messages")
+	message.Kind = kind
+	message.CreatedAt = stampOr(message.CreatedAt, current)
+	r.s.messages[message.ID] = cloneMessage(message)
+	return nil
+}
+
+func (r *MessageRepository) GetByID(_ context.Context, id int64) (*model.Message, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	message, ok := r.s.messages[id]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return cloneMessage(message), nil
+}
+
+// ListByRoom returns messages of a room from the newest, continuing into older history after the cursor if one is set.
+// A backward cursor walks towards newer messages, returning the closest ones first.
+func (r *MessageRepository) ListByRoom(_ context.Context, roomID int64, after *cursor.Cursor, limit int) ([]model.Message, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	backward := after != nil && after.Backward
+	var ids []int64
+	for id, message := range r.s.messages {
+		if message.RoomID != roomID {
+			continue
+		}
+		if after != nil && (backward && id <= after.ID || !backward && id >= after.ID) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	if !backward {
+		slices.Reverse(ids)
+	}
+
+	var messages []model.Message
+	for _, id := range ids[:min(limit, len(ids))] {
+		messages = append(messages, *cloneMessage(r.s.messages[id]))
+	}
+	return messages, nil
+}
+
+// DeleteExpired deletes up to limit messages older than the retention_days of their room.
+// It returns the number of deleted messages.
+func (r *MessageRepository) DeleteExpired(_ context.Context, limit int) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	current := time.Now()
+	var deleted int64
+	for _, id := range sortedIDs(r.s.messages) {
+		if deleted == int64(limit) {
+			break
+		}
+		message := r.s.messages[id]
+		room := r.s.rooms[message.RoomID]
+		if room.RetentionDays > 0 && message.CreatedAt.Before(current.AddDate(0, 0, -room.RetentionDays)) {
+			delete(r.s.messages, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteOverflow deletes up to limit of the oldest messages beyond the retention_max_messages of their room.
+// It returns the number of deleted messages.
+func (r *MessageRepository) DeleteOverflow(_ context.Context, limit int) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Walking from the newest message, every room keeps its first retention_max_messages and the rest overflows.
+	kept := make(map[int64]int)
+	var overflow []int64
+	ids := sortedIDs(r.s.messages)
+	for _, id := range slices.Backward(ids) {
+		message := r.s.messages[id]
+		keep := r.s.rooms[message.RoomID].RetentionMaxMessages
+		if keep == 0 {
+			continue
+		}
+		if kept[message.RoomID] < keep {
+			kept[message.RoomID]++
+			continue
+		}
+		overflow = append(overflow, id)
+	}
+
+	slices.Sort(overflow)
+	overflow = overflow[:min(limit, len(overflow))]
+	for _, id := range overflow {
+		delete(r.s.messages, id)
+	}
+	return int64(len(overflow)), nil
+}
+
+// cloneMessage copies a message so the caller and the store never share its attachments.
+func cloneMessage(message *model.Message) *model.Message {
+	c := *message
+	c.Attachments = slices.Clone(message.Attachments)
+	c.Room = nil
+	return &c
+}
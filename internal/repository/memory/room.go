@@ -0,0 +1,299 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+var _ repository.RoomRepository = (*RoomRepository)(nil)
+
+type RoomRepository struct {
+	s *Store
+}
+
+func NewRoomRepository(s *Store) *RoomRepository {
+	return &RoomRepository{
+		s: s,
+	}
+}
+
+func (r *RoomRepository) Insert(_ context.Context, room *model.Room) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	current := now()
+	room.ID = r.s.nextID("rooms")
+	room.CreatedAt = stampOr(room.CreatedAt, current)
+	room.UpdatedAt = stampOr(room.UpdatedAt, current)
+	room.LastActiveAt = stampOr(room.LastActiveAt, current)
+	if room.Metadata == nil {
+		room.Metadata = map[string]any{}
+	}
+	r.s.rooms[room.ID] = cloneRoom(room)
+	return nil
+}
+
+func (r *RoomRepository) GetByID(_ context.Context, id int64) (*model.Room, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	room, ok := r.live(id)
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return cloneRoom(room), nil
+}
+
+// List returns a page of rooms matching filter in the given order, starting after the cursor if one is set.
+// A backward cursor walks towards the start of the listing, returning the closest rooms first.
+func (r *RoomRepository) List(_ context.Context, filter model.RoomFilter, order model.RoomOrder, after *cursor.Cursor, limit int) ([]model.Room, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	desc := order.Desc != (after != nil && after.Backward)
+	// position compares a room with the cursor the way "(column, id) > (key, id)" does.
+	position := func(room *model.Room) int {
+		if order.Column != model.RoomOrderID {
+			if c := roomKey(room, order.Column).Compare(after.Key); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(room.ID, after.ID)
+	}
+
+	var matched []*model.Room
+	for _, room := range r.s.rooms {
+		if !matchRoom(room, filter) {
+			continue
+		}
+		if after != nil && (desc && position(room) >= 0 || !desc && position(room) <= 0) {
+			continue
+		}
+		matched = append(matched, room)
+	}
+	slices.SortFunc(matched, func(a, b *model.Room) int {
+		c := cmp.Compare(a.ID, b.ID)
+		if order.Column != model.RoomOrderID {
+			c = cmp.Or(roomKey(a, order.Column).Compare(roomKey(b, order.Column)), c)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	})
+
+	var rooms []model.Room
+	for _, room := range matched[:min(limit, len(matched))] {
+		rooms = append(rooms, *cloneRoom(room))
+	}
+	return rooms, nil
+}
+
+// Count returns the number of rooms matching filter.
+func (r *RoomRepository) Count(_ context.Context, filter model.RoomFilter) (int, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	count := 0
+	for _, room := range r.s.rooms {
+		if matchRoom(room, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Update saves the editable fields of a room and refreshes its updated_at timestamp.
+func (r *RoomRepository) Update(_ context.Context, room *model.Room) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stored, ok := r.live(room.ID)
+	if !ok {
+		return model.ErrNotFound
+	}
+	updated := cloneRoom(room)
+	stored.Name = updated.Name
+	stored.PasswordHash = updated.PasswordHash
+	stored.Topic = updated.Topic
+	stored.Description = updated.Description
+	stored.AvatarURL = updated.AvatarURL
+	stored.Metadata = updated.Metadata
+	if stored.Metadata == nil {
+		stored.Metadata = map[string]any{}
+	}
+	stored.RetentionDays = updated.RetentionDays
+	stored.RetentionMaxMessages = updated.RetentionMaxMessages
+	stored.UpdatedAt = now()
+
+	room.UpdatedAt = stored.UpdatedAt
+	return nil
+}
+
+// TouchActivity updates the activity timestamp of a room by its ID.
+func (r *RoomRepository) TouchActivity(_ context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	room, ok := r.live(id)
+	if !ok {
+		return model.ErrNotFound
+	}
+	room.UpdatedAt = now()
+	room.LastActiveAt = room.UpdatedAt
+	return nil
+}
+
+// TouchActivityBatch moves the activity timestamps of live rooms forward to the given times;
+// deleted and unknown rooms are skipped. It returns the number of rooms updated.
+func (r *RoomRepository) TouchActivityBatch(_ context.Context, activity map[int64]time.Time) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var affected int64
+	for id, at := range activity {
+		room, ok := r.live(id)
+		if !ok {
+			continue
+		}
+		if at = stamp(at); at.After(room.LastActiveAt) {
+			room.LastActiveAt = at
+		}
+		affected++
+	}
+	return affected, nil
+}
+
+// SoftDeleteInactiveOlderThan soft deletes rooms that have been inactive longer than d.
+// It returns the number of rooms that were marked as deleted.
+func (r *RoomRepository) SoftDeleteInactiveOlderThan(_ context.Context, d time.Duration) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	cutoff, deletedAt := time.Now().Add(-d), now()
+	var affected int64
+	for _, room := range r.s.rooms {
+		if room.DeletedAt.IsZero() && room.LastActiveAt.Before(cutoff) {
+			room.DeletedAt = deletedAt
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+// SoftDelete marks a room as deleted; it returns model.ErrNotFound if there is no live room with the ID.
+func (r *RoomRepository) SoftDelete(_ context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	room, ok := r.live(id)
+	if !ok {
+		return model.ErrNotFound
+	}
+	room.DeletedAt = now()
+	return nil
+}
+
+// GetDeletedByID returns a soft-deleted room by its ID.
+func (r *RoomRepository) GetDeletedByID(_ context.Context, id int64) (*model.Room, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	room, ok := r.s.rooms[id]
+	if !ok || room.DeletedAt.IsZero() {
+		return nil, model.ErrNotFound
+	}
+	return cloneRoom(room), nil
+}
+
+// Restore undoes the soft delete of a room and marks it active, so the cleanup job does not delete it again right away.
+func (r *RoomRepository) Restore(_ context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	room, ok := r.s.rooms[id]
+	if !ok || room.DeletedAt.IsZero() {
+		return model.ErrNotFound
+	}
+	room.DeletedAt = time.Time{}
+	room.UpdatedAt = now()
+	room.LastActiveAt = room.UpdatedAt
+	return nil
+}
+
+// HardDeleteDeletedOlderThan permanently deletes rooms soft deleted more than d ago; their messages,
+// webhooks and incoming webhooks go with them. It returns the number of deleted rooms.
+func (r *RoomRepository) HardDeleteDeletedOlderThan(_ context.Context, d time.Duration) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	cutoff := time.Now().Add(-d)
+	var affected int64
+	for id, room := range r.s.rooms {
+		if !room.DeletedAt.IsZero() && room.DeletedAt.Before(cutoff) {
+			r.s.deleteRoom(id)
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+// live returns the stored room with the ID unless it is missing or soft deleted.
+func (r *RoomRepository) live(id int64) (*model.Room, bool) {
+	room, ok := r.s.rooms[id]
+	if !ok || !room.DeletedAt.IsZero() {
+		return nil, false
+	}
+	return room, true
+}
+
+func roomKey(room *model.Room, column string) time.Time {
+	if column == model.RoomOrderLastActiveAt {
+		return room.LastActiveAt
+	}
+	return room.CreatedAt
+}
+
+// matchRoom reports whether a live room passes filter.
+func matchRoom(room *model.Room, filter model.RoomFilter) bool {
+	if !room.DeletedAt.IsZero() {
+		return false
+	}
+	if filter.Query != "" &&
+		!strings.HasPrefix(strings.ToLower(room.Name), strings.ToLower(filter.Query)) &&
+		repository.Similarity(room.Name, filter.Query) < repository.SimilarityThreshold {
+		return false
+	}
+	if filter.HasPassword != nil && *filter.HasPassword != (room.PasswordHash != nil) {
+		return false
+	}
+	if filter.ActiveSince != nil && room.LastActiveAt.Before(*filter.ActiveSince) {
+		return false
+	}
+	if filter.IDs != nil && !slices.Contains(filter.IDs, room.ID) {
+		return false
+	}
+	return true
+}
+
+// cloneRoom copies a room so the caller and the store never share its slices and maps.
+// The empty password hash and the flag derived from it are normalized the way a round trip through Postgres does.
+func cloneRoom(room *model.Room) *model.Room {
+	c := *room
+	c.PasswordHash = bytes.Clone(room.PasswordHash)
+	if len(c.PasswordHash) == 0 {
+		c.PasswordHash = nil
+	}
+	c.HasPassword = false
+	c.Metadata = maps.Clone(room.Metadata)
+	return &c
+}
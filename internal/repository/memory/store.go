@@ -0,0 +1,98 @@
+// Package memory implements the repositories on maps in process memory, for tests and single-process demos.
+//
+// The repositories follow the semantics of the Postgres ones: soft-deleted rooms are hidden, listings keep
+// the same keyset orderings, missing rows are model.ErrNotFound and purging a room removes everything that
+// references it. Nothing survives a restart and replicas do not share data.
+package memory
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+)
+
+// Store holds the tables shared by the repositories of one in-memory database.
+type Store struct {
+	mu sync.RWMutex
+
+	rooms         map[int64]*model.Room
+	messages      map[int64]*model.Message
+	webhooks      map[int64]*model.Webhook
+	deliveries    map[int64]*model.WebhookDelivery
+	incomingHooks map[int64]*model.IncomingWebhook
+	bots          map[int64]*model.Bot
+	botCommands   map[int64]*model.BotCommand
+	jobRuns       map[int64]*model.JobRun
+
+	// lastID is the last id handed out per table, like a BIGSERIAL sequence.
+	lastID map[string]int64
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{
+		rooms:         make(map[int64]*model.Room),
+		messages:      make(map[int64]*model.Message),
+		webhooks:      make(map[int64]*model.Webhook),
+		deliveries:    make(map[int64]*model.WebhookDelivery),
+		incomingHooks: make(map[int64]*model.IncomingWebhook),
+		bots:          make(map[int64]*model.Bot),
+		botCommands:   make(map[int64]*model.BotCommand),
+		jobRuns:       make(map[int64]*model.JobRun),
+		lastID:        make(map[string]int64),
+	}
+}
+
+func (s *Store) nextID(table string) int64 {
+	s.lastID[table]++
+	return s.lastID[table]
+}
+
+// deleteRoom removes a room with its messages, webhooks and incoming webhooks, as ON DELETE CASCADE does.
+func (s *Store) deleteRoom(id int64) {
+	delete(s.rooms, id)
+	maps.DeleteFunc(s.messages, func(_ int64, m *model.Message) bool { return m.RoomID == id })
+	maps.DeleteFunc(s.incomingHooks, func(_ int64, h *model.IncomingWebhook) bool { return h.RoomID == id })
+	for hookID, hook := range s.webhooks {
+		if hook.RoomID == id {
+			s.deleteWebhook(hookID)
+		}
+	}
+}
+
+func (s *Store) deleteWebhook(id int64) {
+	delete(s.webhooks, id)
+	maps.DeleteFunc(s.deliveries, func(_ int64, d *model.WebhookDelivery) bool { return d.WebhookID == id })
+}
+
+// now returns the current time at the microsecond precision of a timestamptz column.
+func now() time.Time {
+	return stamp(time.Now())
+}
+
+func stamp(t time.Time) time.Time {
+	return t.Round(time.Microsecond)
+}
+
+// stampOr stamps t, replacing a zero time with current like a column defaulting to current_timestamp,
+// which is the same for every column of a statement.
+func stampOr(t, current time.Time) time.Time {
+	if t.IsZero() {
+		return current
+	}
+	return stamp(t)
+}
+
+// sortedIDs returns the keys of a table in ascending order.
+func sortedIDs[T any](rows map[int64]T) []int64 {
+	return slices.Sorted(maps.Keys(rows))
+}
+
+// constraintError stands in for the error Postgres raises when a write breaks an integrity constraint.
+func constraintError(format string, args ...any) error {
+	return fmt.Errorf("memory: "+format, args...)
+}
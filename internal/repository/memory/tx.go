@@ -0,0 +1,22 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+var _ repository.Transactor = (*Transactor)(nil)
+
+// Transactor runs units of work on a Store. Every repository call is applied at once under the store lock,
+// so a unit of work is not isolated from concurrent calls and is not rolled back when fn fails.
+type Transactor struct{}
+
+func NewTransactor() *Transactor {
+	return &Transactor{}
+}
+
+// InTx runs fn.
+func (t *Transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+type WebhookRepository struct {
+	s *Store
+}
+
+func NewWebhookRepository(s *Store) *WebhookRepository {
+	return &WebhookRepository{
+		s: s,
+	}
+}
+
+func (r *WebhookRepository) Insert(_ context.Context, hook *model.Webhook) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.rooms[hook.RoomID]; !ok {
+		return constraintError("webhook references missing room %d", hook.RoomID)
+	}
+	current := now()
+	hook.ID = r.s.nextID("webhooks")
+	hook.CreatedAt = stampOr(hook.CreatedAt, current)
+	hook.UpdatedAt = stampOr(hook.UpdatedAt, current)
+	r.s.webhooks[hook.ID] = cloneWebhook(hook)
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(_ context.Context, id int64) (*model.Webhook, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	hook, ok := r.s.webhooks[id]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return cloneWebhook(hook), nil
+}
+
+func (r *WebhookRepository) ListByRoom(_ context.Context, roomID int64) ([]model.Webhook, error) {
+	r.s.mu.RLock()
+	defer r.s.mu.RUnlock()
+
+	var hooks []model.Webhook
+	for _, id := range sortedIDs(r.s.webhooks) {
+		if hook := r.s.webhooks[id]; hook.RoomID == roomID {
+			hooks = append(hooks, *cloneWebhook(hook))
+		}
+	}
+	return hooks, nil
+}
+
+func (r *WebhookRepository) Delete(_ context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if _, ok := r.s.webhooks[id]; !ok {
+		return model.ErrNotFound
+	}
+	r.s.deleteWebhook(id)
+	return nil
+}
+
+// InsertDeliveries enqueues deliveries in the retry queue.
+func (r *WebhookRepository) InsertDeliveries(_ context.Context, deliveries []model.WebhookDelivery) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	for _, d := range deliveries {
+		if _, ok := r.s.webhooks[d.WebhookID]; !ok {
+			return constraintError("delivery references missing webhook %d", d.WebhookID)
+		}
+	}
+	current := now()
+	for i := range deliveries {
+		d := &deliveries[i]
+		d.ID = r.s.nextID("webhook_deliveries")
+		d.Status = cmp.Or(d.Status, model.WebhookDeliveryPending)
+		d.NextAttemptAt = stampOr(d.NextAttemptAt, current)
+		d.CreatedAt = stampOr(d.CreatedAt, current)
+		d.UpdatedAt = stampOr(d.UpdatedAt, current)
+		r.s.deliveries[d.ID] = cloneDelivery(d)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries leases up to limit pending deliveries whose next attempt is due for the given duration,
+// so concurrent workers never pick the same one. Each claimed delivery has its attempt counter incremented and its Webhook loaded.
+func (r *WebhookRepository) ClaimDueDeliveries(_ context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	current := now()
+	var due []*model.WebhookDelivery
+	for _, d := range r.s.deliveries {
+		if d.Status == model.WebhookDeliveryPending && !d.NextAttemptAt.After(current) {
+			due = append(due, d)
+		}
+	}
+	slices.SortFunc(due, func(a, b *model.WebhookDelivery) int {
+		return cmp.Or(a.NextAttemptAt.Compare(b.NextAttemptAt), cmp.Compare(a.ID, b.ID))
+	})
+	due = due[:min(limit, len(due))]
+	slices.SortFunc(due, func(a, b *model.WebhookDelivery) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	var deliveries []model.WebhookDelivery
+	for _, d := range due {
+		d.Attempts++
+		d.NextAttemptAt = stamp(current.Add(lease))
+		d.UpdatedAt = current
+
+		claimed := cloneDelivery(d)
+		claimed.Webhook = cloneWebhook(r.s.webhooks[d.WebhookID])
+		deliveries = append(deliveries, *claimed)
+	}
+	return deliveries, nil
+}
+
+// MarkDelivered moves a delivery out of the retry queue after a successful attempt.
+func (r *WebhookRepository) MarkDelivered(_ context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if d, ok := r.s.deliveries[id]; ok {
+		d.Status = model.WebhookDeliveryDelivered
+		d.LastError = ""
+		d.UpdatedAt = now()
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt and either schedules the next one retryIn from now or moves the delivery
+// to the dead-letter state.
+func (r *WebhookRepository) MarkFailed(_ context.Context, id int64, lastErr string, retryIn time.Duration, dead bool) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	if d, ok := r.s.deliveries[id]; ok {
+		d.Status = model.WebhookDeliveryPending
+		if dead {
+			d.Status = model.WebhookDeliveryDead
+		}
+		d.LastError = lastErr
+		d.UpdatedAt = now()
+		d.NextAttemptAt = stamp(d.UpdatedAt.Add(retryIn))
+	}
+	return nil
+}
+
+func cloneWebhook(hook *model.Webhook) *model.Webhook {
+	c := *hook
+	c.Events = slices.Clone(hook.Events)
+	return &c
+}
+
+func cloneDelivery(d *model.WebhookDelivery) *model.WebhookDelivery {
+	c := *d
+	c.Payload = bytes.Clone(d.Payload)
+	c.Webhook = nil
+	return &c
+}
@@ -3,6 +3,7 @@ package message
 import (
 	"context"
 
+	"github.com/Rasulikus/chat/internal/cursor"
 	"github.com/Rasulikus/chat/internal/model"
 	"github.com/Rasulikus/chat/internal/repository"
 	"github.com/uptrace/bun"
@@ -11,44 +12,56 @@ import (
 var _ repository.MessageRepository = (*Repository)(nil)
 
 type Repository struct {
-	db *bun.DB
+	db bun.IDB
 }
 
-func NewRepository(db *bun.DB) *Repository {
+func NewRepository(db bun.IDB) *Repository {
 	return &Repository{
 		db: db,
 	}
 }
 
+func (r *Repository) conn(ctx context.Context) bun.IDB {
+	return repository.Conn(ctx, r.db)
+}
+
+// Insert stores a new message, retrying transient database errors.
 func (r *Repository) Insert(ctx context.Context, message *model.Message) error {
-	_, err := r.db.NewInsert().Model(message).Exec(ctx)
-	if err != nil {
+	return repository.Retry(ctx, repository.DefaultRetryPolicy, func(ctx context.Context) error {
+		_, err := r.conn(ctx).NewInsert().Model(message).Exec(ctx)
 		return err
-	}
-	return nil
+	})
 }
 
 func (r *Repository) GetByID(ctx context.Context, id int64) (*model.Message, error) {
 	message := new(model.Message)
-	err := r.db.NewSelect().Model(message).Where("id = ?", id).Scan(ctx)
+	err := r.conn(ctx).NewSelect().Model(message).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, repository.IsNoRowsError(err)
 	}
 	return message, nil
 }
 
-func (r *Repository) ListByRoom(ctx context.Context, roomID int64, beforeID *int64, limit int) ([]model.Message, error) {
+// ListByRoom returns messages of a room from the newest, continuing into older history after the cursor if one is set.
+// A backward cursor walks towards newer messages, returning the closest ones first.
+func (r *Repository) ListByRoom(ctx context.Context, roomID int64, after *cursor.Cursor, limit int) ([]model.Message, error) {
 	var messages []model.Message
-	q := r.db.NewSelect().
+	q := r.conn(ctx).NewSelect().
 		Model(&messages).
 		Where("room_id = ?", roomID)
 
-	if beforeID != nil {
-		q.Where("id < ?", *beforeID)
+	order := "id DESC"
+	if after != nil {
+		if after.Backward {
+			q.Where("id > ?", after.ID)
+			order = "id ASC"
+		} else {
+			q.Where("id < ?", after.ID)
+		}
 	}
 
 	err := q.
-		Order("id ASC").
+		Order(order).
 		Limit(limit).
 		Scan(ctx)
 	if err != nil {
@@ -56,3 +69,52 @@ func (r *Repository) ListByRoom(ctx context.Context, roomID int64, beforeID *int
 	}
 	return messages, nil
 }
+
+// DeleteExpired deletes up to limit messages older than the retention_days of their room.
+// It returns the number of deleted messages.
+func (r *Repository) DeleteExpired(ctx context.Context, limit int) (int64, error) {
+	expired := r.conn(ctx).NewSelect().
+		Model((*model.Message)(nil)).
+		ModelTableExpr("messages AS m").
+		Column("m.id").
+		Join("JOIN rooms AS r ON r.id = m.room_id").
+		Where("r.retention_days IS NOT NULL").
+		Where("m.created_at < current_timestamp - r.retention_days * interval '1 day'").
+		Limit(limit)
+
+	res, err := r.conn(ctx).NewDelete().
+		Model((*model.Message)(nil)).
+		Where("id IN (?)", expired).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// DeleteOverflow deletes up to limit of the oldest messages beyond the retention_max_messages of their room.
+// It returns the number of deleted messages.
+func (r *Repository) DeleteOverflow(ctx context.Context, limit int) (int64, error) {
+	ranked := r.conn(ctx).NewSelect().
+		Model((*model.Message)(nil)).
+		ModelTableExpr("messages AS m").
+		Column("m.id").
+		ColumnExpr("row_number() OVER (PARTITION BY m.room_id ORDER BY m.id DESC) AS rn").
+		ColumnExpr("r.retention_max_messages AS keep").
+		Join("JOIN rooms AS r ON r.id = m.room_id").
+		Where("r.retention_max_messages IS NOT NULL")
+	overflow := r.conn(ctx).NewSelect().
+		TableExpr("(?) AS ranked", ranked).
+		Column("id").
+		Where("rn > keep").
+		Limit(limit)
+
+	res, err := r.conn(ctx).NewDelete().
+		Model((*model.Message)(nil)).
+		Where("id IN (?)", overflow).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -1,38 +1,128 @@
 package repository
 
 import (
+	"context"
+	"crypto/tls"
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Rasulikus/chat/internal/config"
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/metrics"
 	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/tracing"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
-	"github.com/uptrace/bun/extra/bundebug"
+	"github.com/uptrace/bun/schema"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
 type DB struct {
 	DB *bun.DB
 }
 
-func NewClient(cfg *config.Config) (*DB, error) {
-	dsn := cfg.DB.PostgresURL()
+// NewClient opens the database of cfg.DB.Storage, SQLite or Postgres. It waits for Postgres to become reachable,
+// retrying with backoff for up to cfg.DB.ConnectTimeout; errors that retrying cannot fix, such as a wrong password, fail at once.
+func NewClient(ctx context.Context, cfg *config.Config) (*DB, error) {
+	if cfg.DB.Storage == config.StorageSQLite {
+		return newSQLiteClient(ctx, cfg)
+	}
+
+	connector, err := NewConnector(&cfg.DB)
+	if err != nil {
+		return nil, err
+	}
 	// Open a PostgreSQL database
-	sqlDB := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
-	err := sqlDB.Ping()
+	sqlDB := sql.OpenDB(connector)
+	sqlDB.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
+
+	pingCtx, cancel := context.WithTimeout(ctx, cfg.DB.ConnectTimeout)
+	defer cancel()
+	err = Retry(pingCtx, startupRetryPolicy, sqlDB.PingContext)
 	if err != nil {
+		_ = sqlDB.Close()
 		return nil, fmt.Errorf("cant connect to database: %w", err)
 	}
 	// Open a PostgreSQL database
-	db := bun.NewDB(sqlDB, pgdialect.New())
-	// Print all queries to stdout
-	db.AddQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
+	return newDB(sqlDB, pgdialect.New(), cfg), nil
+}
+
+func newDB(sqlDB *sql.DB, dialect schema.Dialect, cfg *config.Config) *DB {
+	db := bun.NewDB(sqlDB, dialect)
+	// Record query durations
+	db.AddQueryHook(metrics.QueryHook{})
+	// Trace queries as children of the request or event span
+	db.AddQueryHook(tracing.QueryHook{})
+	// Log queries with the request's logger when asked to
+	if cfg.Log.SQL {
+		db.AddQueryHook(logging.QueryHook{})
+	}
 
 	return &DB{
 		DB: db,
-	}, nil
+	}
+}
+
+// startupRetryPolicy keeps pinging a database that is still starting until the connect timeout.
+var startupRetryPolicy = RetryPolicy{
+	BaseBackoff: 500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+// Close closes the connection pool once the running queries finish.
+func (db *DB) Close() error {
+	return db.DB.Close()
+}
+
+// NewConnector returns a driver connector for cfg. The driver reads sslmode and sslrootcert from the URL
+// but not client certificates, so those are loaded here. The statement timeout is left out of the URL,
+// which migrations also use, and applies to the server's own connections only.
+func NewConnector(cfg *config.DBConfig) (*pgdriver.Connector, error) {
+	dsnCfg := *cfg
+	dsnCfg.SSLCert, dsnCfg.SSLKey = "", ""
+	opts := []pgdriver.Option{pgdriver.WithDSN(dsnCfg.PostgresURL())}
+
+	if cfg.SSLCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+		if err != nil {
+			return nil, fmt.Errorf("load database client certificate: %w", err)
+		}
+		opts = append(opts, func(c *pgdriver.Config) {
+			if c.TLSConfig != nil {
+				c.TLSConfig.Certificates = []tls.Certificate{cert}
+			}
+		})
+	}
+	if cfg.StatementTimeout > 0 {
+		// Postgres cancels statements running longer than this on every connection of the pool.
+		opts = append(opts, pgdriver.WithConnParams(map[string]any{
+			"statement_timeout": cfg.StatementTimeout.Milliseconds(),
+		}))
+	}
+	return pgdriver.NewConnector(opts...), nil
+}
+
+// IsUniqueViolationError maps a unique constraint violation of Postgres or SQLite to model.ErrConflict.
+func IsUniqueViolationError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) && pgErr.Field('C') == "23505" {
+		return model.ErrConflict
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return model.ErrConflict
+	}
+	return err
 }
 
 func IsNoRowsError(err error) error {
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/certs/certstest"
+	"github.com/Rasulikus/chat/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewConnector(t *testing.T) {
+	cfg := config.Default().DB
+
+	t.Run("sslmode disable", func(t *testing.T) {
+		connector, err := NewConnector(&cfg)
+		require.NoError(t, err)
+		assert.Nil(t, connector.Config().TLSConfig)
+		assert.Equal(t, map[string]any{"statement_timeout": int64(30000)}, connector.Config().ConnParams)
+	})
+
+	t.Run("verify-full with CA and client certificate", func(t *testing.T) {
+		dir := t.TempDir()
+		ca, _ := certstest.Write(t, dir, "ca", "ca")
+		cert, key := certstest.Write(t, dir, "client", "admin")
+
+		cfg := cfg
+		cfg.SSLMode, cfg.SSLRootCert, cfg.SSLCert, cfg.SSLKey = "verify-full", ca, cert, key
+		connector, err := NewConnector(&cfg)
+		require.NoError(t, err)
+
+		tlsCfg := connector.Config().TLSConfig
+		require.NotNil(t, tlsCfg)
+		assert.Equal(t, "localhost", tlsCfg.ServerName)
+		assert.False(t, tlsCfg.InsecureSkipVerify)
+		assert.NotNil(t, tlsCfg.RootCAs)
+		assert.Len(t, tlsCfg.Certificates, 1)
+		assert.NotContains(t, connector.Config().ConnParams, "sslcert")
+	})
+
+	t.Run("no statement timeout", func(t *testing.T) {
+		cfg := cfg
+		cfg.StatementTimeout = 0
+		connector, err := NewConnector(&cfg)
+		require.NoError(t, err)
+		assert.Empty(t, connector.Config().ConnParams)
+	})
+
+	t.Run("broken client certificate", func(t *testing.T) {
+		cfg := cfg
+		cfg.SSLMode, cfg.SSLCert, cfg.SSLKey = "require", "missing.crt", "missing.key"
+		_, err := NewConnector(&cfg)
+		assert.ErrorContains(t, err, "load database client certificate")
+	})
+}
+
+func Test_NewClient_Unreachable(t *testing.T) {
+	cfg := config.Default()
+	cfg.DB.Host, cfg.DB.Port = "127.0.0.1", "1"
+	cfg.DB.ConnectTimeout = 300 * time.Millisecond
+
+	start := time.Now()
+	_, err := NewClient(t.Context(), cfg)
+	assert.ErrorContains(t, err, "cant connect to database")
+	assert.GreaterOrEqual(t, time.Since(start), 250*time.Millisecond, "retried until the connect timeout")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
@@ -4,20 +4,64 @@ import (
 	"context"
 	"time"
 
+	"github.com/Rasulikus/chat/internal/cursor"
 	"github.com/Rasulikus/chat/internal/model"
 )
 
 type RoomRepository interface {
 	Insert(ctx context.Context, room *model.Room) error
 	GetByID(ctx context.Context, id int64) (*model.Room, error)
-	List(ctx context.Context, limit int, order string, beforeID *int64) ([]model.Room, error)
+	List(ctx context.Context, filter model.RoomFilter, order model.RoomOrder, after *cursor.Cursor, limit int) ([]model.Room, error)
+	Count(ctx context.Context, filter model.RoomFilter) (int, error)
+	Update(ctx context.Context, room *model.Room) error
 	TouchActivity(ctx context.Context, roomID int64) error
+	TouchActivityBatch(ctx context.Context, activity map[int64]time.Time) (int64, error)
 	SoftDeleteInactiveOlderThan(ctx context.Context, d time.Duration) (int64, error)
 	SoftDelete(ctx context.Context, id int64) error
+	GetDeletedByID(ctx context.Context, id int64) (*model.Room, error)
+	Restore(ctx context.Context, id int64) error
+	HardDeleteDeletedOlderThan(ctx context.Context, d time.Duration) (int64, error)
 }
 
 type MessageRepository interface {
 	Insert(ctx context.Context, message *model.Message) error
 	GetByID(ctx context.Context, id int64) (*model.Message, error)
-	ListByRoom(ctx context.Context, roomID int64, beforeID *int64, limit int) ([]model.Message, error)
+	ListByRoom(ctx context.Context, roomID int64, after *cursor.Cursor, limit int) ([]model.Message, error)
+	DeleteExpired(ctx context.Context, limit int) (int64, error)
+	DeleteOverflow(ctx context.Context, limit int) (int64, error)
+}
+
+type WebhookRepository interface {
+	Insert(ctx context.Context, hook *model.Webhook) error
+	GetByID(ctx context.Context, id int64) (*model.Webhook, error)
+	ListByRoom(ctx context.Context, roomID int64) ([]model.Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	InsertDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, lastErr string, retryIn time.Duration, dead bool) error
+}
+
+type IncomingWebhookRepository interface {
+	Insert(ctx context.Context, hook *model.IncomingWebhook) error
+	GetByID(ctx context.Context, id int64) (*model.IncomingWebhook, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*model.IncomingWebhook, error)
+	ListByRoom(ctx context.Context, roomID int64) ([]model.IncomingWebhook, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type BotRepository interface {
+	Insert(ctx context.Context, bot *model.Bot) error
+	GetByAPIKeyHash(ctx context.Context, apiKeyHash string) (*model.Bot, error)
+	ReplaceCommands(ctx context.Context, botID int64, commands []model.BotCommand) error
+	ListCommands(ctx context.Context, botID *int64) ([]model.BotCommand, error)
+	GetCommandByName(ctx context.Context, name string) (*model.BotCommand, error)
+}
+
+type JobRunRepository interface {
+	Insert(ctx context.Context, run *model.JobRun) error
+	Finish(ctx context.Context, run *model.JobRun) error
+	Last(ctx context.Context, job string) (*model.JobRun, error)
+	LastPerJob(ctx context.Context) ([]model.JobRun, error)
+	DeleteOlderThan(ctx context.Context, d time.Duration) (int64, error)
 }
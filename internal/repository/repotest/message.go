@@ -0,0 +1,262 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMessageRepository runs the contract of repository.MessageRepository.
+func TestMessageRepository(t *testing.T, newRepos NewFunc) {
+	run(t, newRepos, []contractTest{
+		{"Insert", messageInsert},
+		{"GetByID", messageGetByID},
+		{"ListByRoom", messageListByRoom},
+		{"DeleteExpired", messageDeleteExpired},
+		{"DeleteOverflow", messageDeleteOverflow},
+		{"HardDeletedRoom", messageHardDeletedRoom},
+	})
+}
+
+func messageInsert(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	testRoom := &model.Room{
+		Name: "testroom",
+	}
+	require.NoError(t, repos.Rooms.Insert(ctx, testRoom))
+
+	testCases := []struct {
+		name    string
+		message *model.Message
+		wantErr bool
+	}{
+		{
+			name: "success",
+			message: &model.Message{
+				Nick:   "testNick",
+				Text:   "some text",
+				RoomID: testRoom.ID,
+			},
+		},
+		{
+			name: "success with attachments",
+			message: &model.Message{
+				Nick:   "ci-bot",
+				Text:   "build failed",
+				RoomID: testRoom.ID,
+				Attachments: []model.Attachment{
+					{Type: "link", URL: "http://ci.example.com/builds/1", Title: "build #1"},
+				},
+			},
+		},
+		{
+			name: "system message without nick",
+			message: &model.Message{
+				Kind:   model.MessageKindSystem,
+				Text:   "topic changed",
+				RoomID: testRoom.ID,
+			},
+		},
+		{
+			name: "user message without nick",
+			message: &model.Message{
+				Kind:   model.MessageKindUser,
+				Text:   "some text",
+				RoomID: testRoom.ID,
+			},
+			wantErr: true,
+		},
+		{
+			name: "without room",
+			message: &model.Message{
+				Nick: "testNick",
+				Text: "some text",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := repos.Messages.Insert(ctx, testCase.message)
+			if testCase.wantErr {
+				require.Error(t, err)
+				assert.Zero(t, testCase.message.ID)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotZero(t, testCase.message.ID)
+			assert.NotZero(t, testCase.message.Kind)
+			assert.WithinDuration(t, time.Now(), testCase.message.CreatedAt, time.Second)
+
+			stored, err := repos.Messages.GetByID(ctx, testCase.message.ID)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.message.Kind, stored.Kind)
+			assert.Equal(t, testCase.message.Nick, stored.Nick)
+			assert.Equal(t, testCase.message.Attachments, stored.Attachments)
+		})
+	}
+}
+
+func messageGetByID(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	testRoom := &model.Room{
+		Name: "testroom",
+	}
+	require.NoError(t, repos.Rooms.Insert(ctx, testRoom))
+	testMessage := &model.Message{
+		Nick:   "testNick",
+		Text:   "some text",
+		RoomID: testRoom.ID,
+	}
+	require.NoError(t, repos.Messages.Insert(ctx, testMessage))
+
+	t.Run("success", func(t *testing.T) {
+		message, err := repos.Messages.GetByID(ctx, testMessage.ID)
+		require.NoError(t, err)
+		assert.Equal(t, testMessage.ID, message.ID)
+		assert.Equal(t, model.MessageKindUser, message.Kind)
+		assert.Equal(t, "testNick", message.Nick)
+		assert.Equal(t, "some text", message.Text)
+		assert.WithinDuration(t, time.Now(), message.CreatedAt, time.Second)
+	})
+	t.Run("not found", func(t *testing.T) {
+		message, err := repos.Messages.GetByID(ctx, -1)
+		require.ErrorIs(t, err, model.ErrNotFound)
+		assert.Nil(t, message)
+	})
+}
+
+func messageListByRoom(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	testRoom := &model.Room{Name: "testroom"}
+	otherRoom := &model.Room{Name: "other"}
+	require.NoError(t, repos.Rooms.Insert(ctx, testRoom))
+	require.NoError(t, repos.Rooms.Insert(ctx, otherRoom))
+
+	testMessage1 := &model.Message{Nick: "testNick", Text: "some text", RoomID: testRoom.ID}
+	other := &model.Message{Nick: "testNick", Text: "elsewhere", RoomID: otherRoom.ID}
+	testMessage2 := &model.Message{Nick: "testNick", Text: "some other text", RoomID: testRoom.ID}
+	for _, m := range []*model.Message{testMessage1, other, testMessage2} {
+		require.NoError(t, repos.Messages.Insert(ctx, m))
+	}
+
+	t.Run("list all messages in room from the newest", func(t *testing.T) {
+		messages, err := repos.Messages.ListByRoom(ctx, testRoom.ID, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, messages, 2)
+		assert.Equal(t, testMessage2.ID, messages[0].ID)
+		assert.Equal(t, testMessage1.ID, messages[1].ID)
+	})
+
+	t.Run("list before id 2 messages", func(t *testing.T) {
+		messages, err := repos.Messages.ListByRoom(ctx, testRoom.ID, &cursor.Cursor{ID: testMessage2.ID}, 10)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, testMessage1.ID, messages[0].ID)
+	})
+
+	t.Run("list after id 1 messages", func(t *testing.T) {
+		messages, err := repos.Messages.ListByRoom(ctx, testRoom.ID, &cursor.Cursor{ID: testMessage1.ID, Backward: true}, 10)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, testMessage2.ID, messages[0].ID)
+	})
+
+	t.Run("list with limit 1 returns the newest", func(t *testing.T) {
+		messages, err := repos.Messages.ListByRoom(ctx, testRoom.ID, nil, 1)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, testMessage2.ID, messages[0].ID)
+	})
+
+	t.Run("list with not valid room", func(t *testing.T) {
+		messages, err := repos.Messages.ListByRoom(ctx, -1, nil, 10)
+		require.NoError(t, err)
+		assert.Len(t, messages, 0)
+	})
+}
+
+func messageDeleteExpired(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	retained := &model.Room{Name: "retained", RetentionDays: 1}
+	forever := &model.Room{Name: "forever"}
+	require.NoError(t, repos.Rooms.Insert(ctx, retained))
+	require.NoError(t, repos.Rooms.Insert(ctx, forever))
+
+	old := time.Now().Add(-48 * time.Hour)
+	messages := []*model.Message{
+		{Nick: "nick", Text: "old", RoomID: retained.ID, CreatedAt: old},
+		{Nick: "nick", Text: "older", RoomID: retained.ID, CreatedAt: old.Add(-time.Hour)},
+		{Nick: "nick", Text: "new", RoomID: retained.ID},
+		{Nick: "nick", Text: "old", RoomID: forever.ID, CreatedAt: old},
+	}
+	for _, m := range messages {
+		require.NoError(t, repos.Messages.Insert(ctx, m))
+	}
+
+	n, err := repos.Messages.DeleteExpired(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = repos.Messages.DeleteExpired(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	for _, m := range messages[:2] {
+		_, err := repos.Messages.GetByID(ctx, m.ID)
+		require.ErrorIs(t, err, model.ErrNotFound)
+	}
+	for _, m := range messages[2:] {
+		_, err := repos.Messages.GetByID(ctx, m.ID)
+		require.NoError(t, err)
+	}
+}
+
+func messageDeleteOverflow(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	capped := &model.Room{Name: "capped", RetentionMaxMessages: 2}
+	forever := &model.Room{Name: "forever"}
+	require.NoError(t, repos.Rooms.Insert(ctx, capped))
+	require.NoError(t, repos.Rooms.Insert(ctx, forever))
+
+	var messages []*model.Message
+	for _, roomID := range []int64{capped.ID, capped.ID, capped.ID, forever.ID, forever.ID, forever.ID} {
+		m := &model.Message{Nick: "nick", Text: "text", RoomID: roomID}
+		require.NoError(t, repos.Messages.Insert(ctx, m))
+		messages = append(messages, m)
+	}
+
+	n, err := repos.Messages.DeleteOverflow(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	_, err = repos.Messages.GetByID(ctx, messages[0].ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	for _, m := range messages[1:] {
+		_, err := repos.Messages.GetByID(ctx, m.ID)
+		require.NoError(t, err)
+	}
+}
+
+// messageHardDeletedRoom checks that purging a room takes its messages with it.
+func messageHardDeletedRoom(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	room := &model.Room{Name: "purged"}
+	require.NoError(t, repos.Rooms.Insert(ctx, room))
+	message := &model.Message{Nick: "nick", Text: "text", RoomID: room.ID}
+	require.NoError(t, repos.Messages.Insert(ctx, message))
+
+	require.NoError(t, repos.Rooms.SoftDelete(ctx, room.ID))
+	_, err := repos.Messages.GetByID(ctx, message.ID)
+	require.NoError(t, err, "soft delete keeps the history for a restore")
+
+	_, err = repos.Rooms.HardDeleteDeletedOlderThan(ctx, -time.Minute)
+	require.NoError(t, err)
+	_, err = repos.Messages.GetByID(ctx, message.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
@@ -0,0 +1,32 @@
+// Package repotest is the contract test suite of the room and message repositories.
+// Every implementation runs it, so the in-memory and SQL backends are held to the same semantics.
+package repotest
+
+import (
+	"testing"
+
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+// Repositories are the implementations under test, sharing one database.
+type Repositories struct {
+	Rooms    repository.RoomRepository
+	Messages repository.MessageRepository
+}
+
+// NewFunc returns repositories over an empty database whose ids start at 1; it is called once per test.
+type NewFunc func(t *testing.T) Repositories
+
+type contractTest struct {
+	name string
+	run  func(t *testing.T, repos Repositories)
+}
+
+func run(t *testing.T, newRepos NewFunc, tests []contractTest) {
+	t.Helper()
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.run(t, newRepos(t))
+		})
+	}
+}
@@ -0,0 +1,400 @@
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRoomRepository runs the contract of repository.RoomRepository.
+func TestRoomRepository(t *testing.T, newRepos NewFunc) {
+	run(t, newRepos, []contractTest{
+		{"Insert", roomInsert},
+		{"GetByID", roomGetByID},
+		{"List", roomList},
+		{"List_Cursor", roomListCursor},
+		{"List_Filter", roomListFilter},
+		{"Update", roomUpdate},
+		{"TouchActivity", roomTouchActivity},
+		{"TouchActivityBatch", roomTouchActivityBatch},
+		{"SoftDeleteInactiveOlderThan", roomSoftDeleteInactiveOlderThan},
+		{"SoftDelete", roomSoftDelete},
+		{"Restore", roomRestore},
+		{"HardDeleteDeletedOlderThan", roomHardDeleteDeletedOlderThan},
+	})
+}
+
+func roomInsert(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	roomWithoutPassword := &model.Room{
+		Name: "test room",
+	}
+	roomWithPassword := &model.Room{
+		Name:         "testPassword room",
+		PasswordHash: []byte("password"),
+	}
+
+	for _, room := range []*model.Room{roomWithoutPassword, roomWithPassword} {
+		require.NoError(t, repos.Rooms.Insert(ctx, room))
+		assert.NotZero(t, room.ID)
+		assert.WithinDuration(t, time.Now(), room.CreatedAt, time.Second)
+		assert.WithinDuration(t, time.Now(), room.UpdatedAt, time.Second)
+		assert.WithinDuration(t, time.Now(), room.LastActiveAt, time.Second)
+		assert.Equal(t, map[string]any{}, room.Metadata)
+	}
+
+	stored, err := repos.Rooms.GetByID(ctx, roomWithPassword.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("password"), stored.PasswordHash)
+	assert.True(t, stored.DeletedAt.IsZero())
+}
+
+func roomGetByID(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	insertRoom := &model.Room{
+		Name: "test room",
+	}
+	require.NoError(t, repos.Rooms.Insert(ctx, insertRoom))
+	require.Equal(t, int64(1), insertRoom.ID)
+
+	t.Run("found", func(t *testing.T) {
+		room, err := repos.Rooms.GetByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, insertRoom.Name, room.Name)
+	})
+	t.Run("not found err", func(t *testing.T) {
+		room, err := repos.Rooms.GetByID(ctx, 2)
+		require.ErrorIs(t, err, model.ErrNotFound)
+		assert.Nil(t, room)
+	})
+}
+
+func roomList(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	insertRoom1 := &model.Room{Name: "test room"}
+	insertRoom2 := &model.Room{Name: "test room 2"}
+	require.NoError(t, repos.Rooms.Insert(ctx, insertRoom1))
+	require.NoError(t, repos.Rooms.Insert(ctx, insertRoom2))
+
+	idAsc := model.RoomOrder{Column: model.RoomOrderID}
+
+	t.Run("list all rooms", func(t *testing.T) {
+		rooms, err := repos.Rooms.List(ctx, model.RoomFilter{}, idAsc, nil, 10)
+		require.NoError(t, err)
+		assert.Len(t, rooms, 2)
+	})
+	t.Run("list after 1 id rooms", func(t *testing.T) {
+		after := &cursor.Cursor{Order: idAsc.String(), ID: insertRoom1.ID}
+		rooms, err := repos.Rooms.List(ctx, model.RoomFilter{}, idAsc, after, 10)
+		require.NoError(t, err)
+		require.Len(t, rooms, 1)
+		assert.Equal(t, insertRoom2.ID, rooms[0].ID)
+	})
+	t.Run("list with limit 1 rooms", func(t *testing.T) {
+		rooms, err := repos.Rooms.List(ctx, model.RoomFilter{}, idAsc, nil, 1)
+		require.NoError(t, err)
+		require.Len(t, rooms, 1)
+		assert.Equal(t, insertRoom1.ID, rooms[0].ID)
+	})
+	t.Run("deleted rooms are hidden", func(t *testing.T) {
+		require.NoError(t, repos.Rooms.SoftDelete(ctx, insertRoom1.ID))
+		rooms, err := repos.Rooms.List(ctx, model.RoomFilter{}, idAsc, nil, 10)
+		require.NoError(t, err)
+		require.Len(t, rooms, 1)
+		assert.Equal(t, insertRoom2.ID, rooms[0].ID)
+	})
+}
+
+func roomListCursor(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	// The oldest room is the most recently active one, so id and activity orderings disagree.
+	now := time.Now()
+	var rooms []*model.Room
+	for i, activeAgo := range []time.Duration{time.Minute, 3 * time.Hour, 2 * time.Hour, 3 * time.Hour} {
+		room := &model.Room{Name: "room " + string(rune('a'+i)), LastActiveAt: now.Add(-activeAgo)}
+		require.NoError(t, repos.Rooms.Insert(ctx, room))
+		rooms = append(rooms, room)
+	}
+
+	order := model.RoomOrder{Column: model.RoomOrderLastActiveAt, Desc: true}
+	want := []int64{rooms[0].ID, rooms[2].ID, rooms[3].ID, rooms[1].ID}
+
+	var got []int64
+	var after *cursor.Cursor
+	for {
+		page, err := repos.Rooms.List(ctx, model.RoomFilter{}, order, after, 1)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+		got = append(got, page[0].ID)
+		after = &cursor.Cursor{Order: order.String(), Key: page[0].LastActiveAt, ID: page[0].ID}
+	}
+	assert.Equal(t, want, got)
+
+	t.Run("backward", func(t *testing.T) {
+		last, err := repos.Rooms.GetByID(ctx, rooms[1].ID)
+		require.NoError(t, err)
+		before := &cursor.Cursor{Order: order.String(), Key: last.LastActiveAt, ID: last.ID, Backward: true}
+		page, err := repos.Rooms.List(ctx, model.RoomFilter{}, order, before, 2)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.Equal(t, rooms[3].ID, page[0].ID)
+		assert.Equal(t, rooms[2].ID, page[1].ID)
+	})
+}
+
+func roomListFilter(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	general := &model.Room{Name: "general"}
+	generic := &model.Room{Name: "generic_100%", PasswordHash: []byte("hash")}
+	random := &model.Room{Name: "random", LastActiveAt: time.Now().Add(-48 * time.Hour)}
+	for _, room := range []*model.Room{general, generic, random} {
+		require.NoError(t, repos.Rooms.Insert(ctx, room))
+	}
+
+	yes, dayAgo := true, time.Now().Add(-24*time.Hour)
+	testCases := []struct {
+		name   string
+		filter model.RoomFilter
+		want   []int64
+	}{
+		{name: "prefix", filter: model.RoomFilter{Query: "gen"}, want: []int64{general.ID, generic.ID}},
+		{name: "prefix ignores case", filter: model.RoomFilter{Query: "GEN"}, want: []int64{general.ID, generic.ID}},
+		{name: "wildcards are literal", filter: model.RoomFilter{Query: "%"}, want: []int64{}},
+		{name: "similar name", filter: model.RoomFilter{Query: "randon"}, want: []int64{random.ID}},
+		{name: "has password", filter: model.RoomFilter{HasPassword: &yes}, want: []int64{generic.ID}},
+		{name: "active since", filter: model.RoomFilter{ActiveSince: &dayAgo}, want: []int64{general.ID, generic.ID}},
+		{name: "ids", filter: model.RoomFilter{IDs: []int64{random.ID}}, want: []int64{random.ID}},
+		{name: "empty ids", filter: model.RoomFilter{IDs: []int64{}}, want: []int64{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rooms, err := repos.Rooms.List(ctx, tc.filter, model.RoomOrder{Column: model.RoomOrderID}, nil, 10)
+			require.NoError(t, err)
+			got := []int64{}
+			for _, room := range rooms {
+				got = append(got, room.ID)
+			}
+			assert.Equal(t, tc.want, got)
+
+			count, err := repos.Rooms.Count(ctx, tc.filter)
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.want), count)
+		})
+	}
+}
+
+func roomUpdate(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	insertRoom := &model.Room{
+		Name:      "test room",
+		Topic:     "old topic",
+		UpdatedAt: time.Now().Add(-time.Hour),
+	}
+	require.NoError(t, repos.Rooms.Insert(ctx, insertRoom))
+
+	t.Run("update profile", func(t *testing.T) {
+		insertRoom.Name = "renamed"
+		insertRoom.Topic = ""
+		insertRoom.Description = "about"
+		insertRoom.AvatarURL = "http://example.com/a.png"
+		insertRoom.Metadata = map[string]any{"team": "ops"}
+		insertRoom.RetentionDays = 7
+		require.NoError(t, repos.Rooms.Update(ctx, insertRoom))
+		assert.WithinDuration(t, time.Now(), insertRoom.UpdatedAt, time.Second)
+
+		room, err := repos.Rooms.GetByID(ctx, insertRoom.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "renamed", room.Name)
+		assert.Empty(t, room.Topic)
+		assert.Equal(t, "about", room.Description)
+		assert.Equal(t, "http://example.com/a.png", room.AvatarURL)
+		assert.Equal(t, map[string]any{"team": "ops"}, room.Metadata)
+		assert.Equal(t, 7, room.RetentionDays)
+	})
+
+	t.Run("not found err", func(t *testing.T) {
+		err := repos.Rooms.Update(ctx, &model.Room{ID: 9999999, Name: "x", Metadata: map[string]any{}})
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("deleted room", func(t *testing.T) {
+		require.NoError(t, repos.Rooms.SoftDelete(ctx, insertRoom.ID))
+		err := repos.Rooms.Update(ctx, insertRoom)
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
+
+func roomTouchActivity(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	insertRoom := &model.Room{
+		Name:         "test room",
+		CreatedAt:    time.Now().Add(-time.Hour),
+		UpdatedAt:    time.Now().Add(-time.Hour),
+		LastActiveAt: time.Now().Add(-time.Hour),
+	}
+	require.NoError(t, repos.Rooms.Insert(ctx, insertRoom))
+	require.WithinDuration(t, time.Now().Add(-time.Hour), insertRoom.CreatedAt, time.Second)
+	require.WithinDuration(t, time.Now().Add(-time.Hour), insertRoom.UpdatedAt, time.Second)
+
+	t.Run("no err", func(t *testing.T) {
+		require.NoError(t, repos.Rooms.TouchActivity(ctx, insertRoom.ID))
+		room, err := repos.Rooms.GetByID(ctx, insertRoom.ID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), room.UpdatedAt, time.Second)
+		assert.WithinDuration(t, time.Now(), room.LastActiveAt, time.Second)
+	})
+	t.Run("not found err", func(t *testing.T) {
+		err := repos.Rooms.TouchActivity(ctx, 9999999)
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
+
+func roomTouchActivityBatch(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	old := time.Now().Add(-time.Hour)
+	live := &model.Room{Name: "live", LastActiveAt: old}
+	deleted := &model.Room{Name: "deleted", LastActiveAt: old}
+	for _, room := range []*model.Room{live, deleted} {
+		require.NoError(t, repos.Rooms.Insert(ctx, room))
+	}
+	require.NoError(t, repos.Rooms.SoftDelete(ctx, deleted.ID))
+
+	now := time.Now()
+	affected, err := repos.Rooms.TouchActivityBatch(ctx, map[int64]time.Time{
+		live.ID:    now,
+		deleted.ID: now,
+		9999999:    now,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	room, err := repos.Rooms.GetByID(ctx, live.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, now, room.LastActiveAt, time.Millisecond)
+
+	// Older activity never moves the timestamp back.
+	_, err = repos.Rooms.TouchActivityBatch(ctx, map[int64]time.Time{live.ID: old})
+	require.NoError(t, err)
+	room, err = repos.Rooms.GetByID(ctx, live.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, now, room.LastActiveAt, time.Millisecond)
+
+	room, err = repos.Rooms.GetDeletedByID(ctx, deleted.ID)
+	require.NoError(t, err)
+	assert.WithinDuration(t, old, room.LastActiveAt, time.Millisecond)
+}
+
+func roomSoftDeleteInactiveOlderThan(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	insertRoom := &model.Room{
+		Name:         "test room",
+		LastActiveAt: time.Now().Add(-time.Hour),
+	}
+	require.NoError(t, repos.Rooms.Insert(ctx, insertRoom))
+	require.WithinDuration(t, time.Now().Add(-time.Hour), insertRoom.LastActiveAt, time.Second)
+
+	t.Run("without delete", func(t *testing.T) {
+		aff, err := repos.Rooms.SoftDeleteInactiveOlderThan(ctx, time.Minute*61)
+		require.NoError(t, err)
+		require.Zero(t, aff)
+	})
+
+	t.Run("soft delete inactive", func(t *testing.T) {
+		aff, err := repos.Rooms.SoftDeleteInactiveOlderThan(ctx, time.Minute*59)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), aff)
+		_, err = repos.Rooms.GetByID(ctx, insertRoom.ID)
+		require.ErrorIs(t, err, model.ErrNotFound)
+
+		aff, err = repos.Rooms.SoftDeleteInactiveOlderThan(ctx, time.Minute*59)
+		require.NoError(t, err)
+		require.Zero(t, aff)
+	})
+}
+
+func roomSoftDelete(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	insertRoom := &model.Room{
+		Name: "test room",
+	}
+	require.NoError(t, repos.Rooms.Insert(ctx, insertRoom))
+
+	t.Run("soft delete room", func(t *testing.T) {
+		require.NoError(t, repos.Rooms.SoftDelete(ctx, insertRoom.ID))
+		room, err := repos.Rooms.GetByID(ctx, insertRoom.ID)
+		require.ErrorIs(t, err, model.ErrNotFound)
+		require.Nil(t, room)
+
+		count, err := repos.Rooms.Count(ctx, model.RoomFilter{})
+		require.NoError(t, err)
+		assert.Zero(t, count)
+	})
+	t.Run("already deleted", func(t *testing.T) {
+		err := repos.Rooms.SoftDelete(ctx, insertRoom.ID)
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+	t.Run("not found err", func(t *testing.T) {
+		err := repos.Rooms.SoftDelete(ctx, 9999999)
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
+
+func roomRestore(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	room := &model.Room{Name: "test room", LastActiveAt: time.Now().Add(-30 * 24 * time.Hour)}
+	require.NoError(t, repos.Rooms.Insert(ctx, room))
+
+	t.Run("not deleted", func(t *testing.T) {
+		_, err := repos.Rooms.GetDeletedByID(ctx, room.ID)
+		require.ErrorIs(t, err, model.ErrNotFound)
+		err = repos.Rooms.Restore(ctx, room.ID)
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("restore deleted", func(t *testing.T) {
+		require.NoError(t, repos.Rooms.SoftDelete(ctx, room.ID))
+		deleted, err := repos.Rooms.GetDeletedByID(ctx, room.ID)
+		require.NoError(t, err)
+		assert.False(t, deleted.DeletedAt.IsZero())
+
+		require.NoError(t, repos.Rooms.Restore(ctx, room.ID))
+		restored, err := repos.Rooms.GetByID(ctx, room.ID)
+		require.NoError(t, err)
+		assert.True(t, restored.DeletedAt.IsZero())
+		assert.WithinDuration(t, time.Now(), restored.LastActiveAt, time.Second)
+	})
+}
+
+func roomHardDeleteDeletedOlderThan(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	deleted := &model.Room{Name: "deleted"}
+	alive := &model.Room{Name: "alive"}
+	for _, room := range []*model.Room{deleted, alive} {
+		require.NoError(t, repos.Rooms.Insert(ctx, room))
+	}
+	require.NoError(t, repos.Rooms.SoftDelete(ctx, deleted.ID))
+
+	affected, err := repos.Rooms.HardDeleteDeletedOlderThan(ctx, time.Hour)
+	require.NoError(t, err)
+	assert.Zero(t, affected)
+	_, err = repos.Rooms.GetDeletedByID(ctx, deleted.ID)
+	require.NoError(t, err)
+
+	// A negative age reaches rooms deleted a moment ago.
+	affected, err = repos.Rooms.HardDeleteDeletedOlderThan(ctx, -time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), affected)
+
+	_, err = repos.Rooms.GetDeletedByID(ctx, deleted.ID)
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, err = repos.Rooms.GetByID(ctx, alive.ID)
+	require.NoError(t, err)
+}
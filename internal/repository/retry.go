@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/uptrace/bun/driver/pgdriver"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// RetryPolicy bounds how often and how fast Retry repeats a call.
+type RetryPolicy struct {
+	// Attempts is the total number of calls; zero means retrying until the context is done.
+	Attempts int
+	// BaseBackoff is the delay before the first retry; it doubles on every further attempt.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used by repository calls; it rides out a failover or a brief connection loss.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:    3,
+	BaseBackoff: 50 * time.Millisecond,
+	MaxBackoff:  time.Second,
+}
+
+// Retry calls fn until it succeeds, fails with an error that is not transient, the attempts run out or ctx is done.
+// It returns the last error of fn. Inside a transaction fn is called once: a failed statement aborts the transaction,
+// which Transactor.InTx retries as a whole.
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return fn(ctx)
+	}
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || !IsTransient(err) || attempt == policy.Attempts {
+			return err
+		}
+
+		delay := policy.backoff(attempt)
+		logging.FromContext(ctx).Warn("db: transient error, retrying", "err", err, "attempt", attempt, "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay after the given attempt, doubling from BaseBackoff up to MaxBackoff.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// transientStates are the SQLSTATE codes after which the statement did not take effect and may succeed when run again.
+var transientStates = map[string]bool{
+	"08000": true, // connection_exception
+	"08001": true, // sqlclient_unable_to_establish_sqlconnection
+	"08004": true, // sqlserver_rejected_establishment_of_sqlconnection
+	"08006": true, // connection_failure
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"57P01": true, // admin_shutdown
+	"57P03": true, // cannot_connect_now
+}
+
+// IsTransient reports whether err is a database error after which the statement certainly did not take effect,
+// so that even an insert can be repeated safely: a failed dial, a connection the pool found broken before use,
+// a server error from transientStates, or a SQLite database that stayed locked for longer than its busy timeout.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	var pgErr pgdriver.Error
+	if errors.As(err, &pgErr) {
+		return transientStates[pgErr.Field('C')]
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		// Extended result codes keep the primary code in the low byte.
+		return sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
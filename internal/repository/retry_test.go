@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_IsTransient(t *testing.T) {
+	_, dialErr := net.DialTimeout("tcp", "127.0.0.1:1", time.Second)
+	assert.Error(t, dialErr)
+
+	assert.True(t, IsTransient(dialErr), "failed dial")
+	assert.True(t, IsTransient(fmt.Errorf("ping: %w", driver.ErrBadConn)), "bad connection")
+	assert.False(t, IsTransient(nil))
+	assert.False(t, IsTransient(model.ErrNotFound))
+	assert.False(t, IsTransient(context.DeadlineExceeded))
+	assert.False(t, IsTransient(&net.OpError{Op: "read", Err: errors.New("connection reset by peer")}), "the statement may have run")
+}
+
+func Test_Retry(t *testing.T) {
+	policy := RetryPolicy{Attempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+	t.Run("recovers from transient errors", func(t *testing.T) {
+		calls := 0
+		err := Retry(t.Context(), policy, func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return driver.ErrBadConn
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after the attempts", func(t *testing.T) {
+		calls := 0
+		err := Retry(t.Context(), policy, func(context.Context) error {
+			calls++
+			return driver.ErrBadConn
+		})
+		assert.ErrorIs(t, err, driver.ErrBadConn)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("returns other errors at once", func(t *testing.T) {
+		calls := 0
+		err := Retry(t.Context(), policy, func(context.Context) error {
+			calls++
+			return model.ErrConflict
+		})
+		assert.ErrorIs(t, err, model.ErrConflict)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+		calls := 0
+		err := Retry(ctx, RetryPolicy{BaseBackoff: 5 * time.Millisecond, MaxBackoff: 5 * time.Millisecond}, func(context.Context) error {
+			calls++
+			return driver.ErrBadConn
+		})
+		assert.ErrorIs(t, err, driver.ErrBadConn)
+		assert.Greater(t, calls, 1)
+		assert.Less(t, calls, 10)
+	})
+}
+
+func Test_RetryPolicy_backoff(t *testing.T) {
+	p := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	assert.Equal(t, 100*time.Millisecond, p.backoff(1))
+	assert.Equal(t, 400*time.Millisecond, p.backoff(3))
+	assert.Equal(t, time.Second, p.backoff(10))
+}
@@ -2,54 +2,83 @@ package room
 
 import (
 	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/Rasulikus/chat/internal/cursor"
 	"github.com/Rasulikus/chat/internal/model"
 	"github.com/Rasulikus/chat/internal/repository"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
 )
 
 var _ repository.RoomRepository = (*Repository)(nil)
 
 type Repository struct {
-	db *bun.DB
+	db bun.IDB
 }
 
-func NewRepository(db *bun.DB) *Repository {
+func NewRepository(db bun.IDB) *Repository {
 	return &Repository{
 		db: db,
 	}
 }
 
+func (r *Repository) conn(ctx context.Context) bun.IDB {
+	return repository.Conn(ctx, r.db)
+}
+
+// Insert stores a new room, retrying transient database errors.
 func (r *Repository) Insert(ctx context.Context, room *model.Room) error {
-	_, err := r.db.NewInsert().Model(room).Exec(ctx)
-	if err != nil {
+	return repository.Retry(ctx, repository.DefaultRetryPolicy, func(ctx context.Context) error {
+		_, err := r.conn(ctx).NewInsert().Model(room).Exec(ctx)
 		return err
-	}
-	return nil
+	})
 }
 
 func (r *Repository) GetByID(ctx context.Context, id int64) (*model.Room, error) {
 	room := new(model.Room)
 
-	err := r.db.NewSelect().Model(room).Where("id = ?", id).Scan(ctx)
+	err := r.conn(ctx).NewSelect().Model(room).Where("id = ?", id).Scan(ctx)
 	if err != nil {
 		return nil, repository.IsNoRowsError(err)
 	}
 	return room, nil
 }
 
-func (r *Repository) List(ctx context.Context, limit int, order string, beforeID *int64) ([]model.Room, error) {
+// List returns a page of rooms matching filter in the given order, starting after the cursor if one is set.
+// A backward cursor walks towards the start of the listing, returning the closest rooms first.
+func (r *Repository) List(ctx context.Context, filter model.RoomFilter, order model.RoomOrder, after *cursor.Cursor, limit int) ([]model.Room, error) {
 	var rooms []model.Room
-	q := r.db.NewSelect().
+	if filter.IDs != nil && len(filter.IDs) == 0 {
+		return rooms, nil
+	}
+
+	q := r.conn(ctx).NewSelect().
 		Model(&rooms)
+	applyFilter(q, filter)
 
-	if beforeID != nil {
-		q.Where("id < ?", *beforeID)
+	cmp, dir := ">", "ASC"
+	if order.Desc != (after != nil && after.Backward) {
+		cmp, dir = "<", "DESC"
+	}
+
+	if order.Column == model.RoomOrderID {
+		if after != nil {
+			q.Where("id "+cmp+" ?", after.ID)
+		}
+		q.OrderExpr("id " + dir)
+	} else {
+		if after != nil {
+			q.Where("(?, id) "+cmp+" (?, ?)", bun.Ident(order.Column), after.Key, after.ID)
+		}
+		q.OrderExpr("? "+dir+", id "+dir, bun.Ident(order.Column))
 	}
 
 	err := q.
-		Order(order).
 		Limit(limit).
 		Scan(ctx)
 	if err != nil {
@@ -58,13 +87,49 @@ func (r *Repository) List(ctx context.Context, limit int, order string, beforeID
 	return rooms, nil
 }
 
-// TouchActivity updates the activity timestamp of a room by its ID.
-func (r *Repository) TouchActivity(ctx context.Context, id int64) error {
-	res, err := r.db.NewUpdate().
-		Model((*model.Room)(nil)).
+// Count returns the number of rooms matching filter.
+func (r *Repository) Count(ctx context.Context, filter model.RoomFilter) (int, error) {
+	if filter.IDs != nil && len(filter.IDs) == 0 {
+		return 0, nil
+	}
+
+	q := r.conn(ctx).NewSelect().
+		Model((*model.Room)(nil))
+	applyFilter(q, filter)
+
+	return q.Count(ctx)
+}
+
+// likeEscaper escapes LIKE wildcards so a search query matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func applyFilter(q *bun.SelectQuery, filter model.RoomFilter) {
+	if filter.Query != "" {
+		q.Where("(name ILIKE ? OR name % ?)", likeEscaper.Replace(filter.Query)+"%", filter.Query)
+	}
+	if filter.HasPassword != nil {
+		if *filter.HasPassword {
+			q.Where("password_hash IS NOT NULL")
+		} else {
+			q.Where("password_hash IS NULL")
+		}
+	}
+	if filter.ActiveSince != nil {
+		q.Where("last_active_at >= ?", *filter.ActiveSince)
+	}
+	if filter.IDs != nil {
+		q.Where("id IN (?)", bun.In(filter.IDs))
+	}
+}
+
+// Update saves the editable fields of a room and refreshes its updated_at timestamp.
+func (r *Repository) Update(ctx context.Context, room *model.Room) error {
+	res, err := r.conn(ctx).NewUpdate().
+		Model(room).
+		Column("name", "password_hash", "topic", "description", "avatar_url", "metadata", "retention_days", "retention_max_messages").
 		Set("updated_at = current_timestamp").
-		Set("last_active_at = current_timestamp").
-		Where("id = ?", id).
+		WherePK().
+		Returning("updated_at").
 		Exec(ctx)
 	if err != nil {
 		return err
@@ -81,10 +146,68 @@ func (r *Repository) TouchActivity(ctx context.Context, id int64) error {
 	return nil
 }
 
+// TouchActivity updates the activity timestamp of a room by its ID, retrying transient database errors.
+func (r *Repository) TouchActivity(ctx context.Context, id int64) error {
+	var res sql.Result
+	err := repository.Retry(ctx, repository.DefaultRetryPolicy, func(ctx context.Context) error {
+		var err error
+		res, err = r.conn(ctx).NewUpdate().
+			Model((*model.Room)(nil)).
+			Set("updated_at = current_timestamp").
+			Set("last_active_at = current_timestamp").
+			Where("id = ?", id).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchActivityBatch moves the activity timestamps of live rooms forward to the given times in one statement;
+// deleted and unknown rooms are skipped. It returns the number of rooms updated.
+func (r *Repository) TouchActivityBatch(ctx context.Context, activity map[int64]time.Time) (int64, error) {
+	if len(activity) == 0 {
+		return 0, nil
+	}
+	// Rows are locked in id order, so concurrent batches from several replicas cannot deadlock.
+	ids := slices.Sorted(maps.Keys(activity))
+	times := make([]time.Time, len(ids))
+	for i, id := range ids {
+		times[i] = activity[id]
+	}
+
+	var res sql.Result
+	err := repository.Retry(ctx, repository.DefaultRetryPolicy, func(ctx context.Context) error {
+		var err error
+		res, err = r.conn(ctx).NewUpdate().
+			Model((*model.Room)(nil)).
+			TableExpr("unnest(?::bigint[], ?::timestamptz[]) AS activity (id, at)", pgdialect.Array(ids), pgdialect.Array(times)).
+			Set("last_active_at = GREATEST(room.last_active_at, activity.at)").
+			Where("room.id = activity.id").
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // SoftDeleteInactiveOlderThan soft deletes rooms that have been inactive longer than d.
 // It returns the number of rooms that were marked as deleted.
 func (r *Repository) SoftDeleteInactiveOlderThan(ctx context.Context, d time.Duration) (int64, error) {
-	res, err := r.db.NewDelete().
+	res, err := r.conn(ctx).NewDelete().
 		Model((*model.Room)(nil)).
 		Where("last_active_at < ?", time.Now().Add(-d)).
 		Exec(ctx)
@@ -94,10 +217,72 @@ func (r *Repository) SoftDeleteInactiveOlderThan(ctx context.Context, d time.Dur
 	return res.RowsAffected()
 }
 
+// SoftDelete marks a room as deleted; it returns model.ErrNotFound if there is no live room with the ID.
 func (r *Repository) SoftDelete(ctx context.Context, id int64) error {
-	_, err := r.db.NewDelete().Model((*model.Room)(nil)).Where("id = ?", id).Exec(ctx)
+	res, err := r.conn(ctx).NewDelete().Model((*model.Room)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetDeletedByID returns a soft-deleted room by its ID. Inside a transaction the row stays locked until
+// the transaction ends, so a restore decided on it cannot race a purge or another restore.
+func (r *Repository) GetDeletedByID(ctx context.Context, id int64) (*model.Room, error) {
+	room := new(model.Room)
+
+	err := r.conn(ctx).NewSelect().Model(room).WhereDeleted().Where("id = ?", id).For("UPDATE").Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return room, nil
+}
+
+// Restore undoes the soft delete of a room and marks it active, so the cleanup job does not delete it again right away.
+func (r *Repository) Restore(ctx context.Context, id int64) error {
+	res, err := r.conn(ctx).NewUpdate().
+		Model((*model.Room)(nil)).
+		WhereDeleted().
+		Set("deleted_at = NULL").
+		Set("updated_at = current_timestamp").
+		Set("last_active_at = current_timestamp").
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
 	return nil
 }
+
+// HardDeleteDeletedOlderThan permanently deletes rooms soft deleted more than d ago; their messages,
+// webhooks and incoming webhooks go with them. It returns the number of deleted rooms.
+func (r *Repository) HardDeleteDeletedOlderThan(ctx context.Context, d time.Duration) (int64, error) {
+	res, err := r.conn(ctx).NewDelete().
+		Model((*model.Room)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", time.Now().Add(-d)).
+		ForceDelete().
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
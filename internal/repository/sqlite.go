@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	"github.com/Rasulikus/chat/internal/config"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+// newSQLiteClient opens the SQLite database file of cfg.DB.SQLitePath, creating it if it does not exist.
+func newSQLiteClient(ctx context.Context, cfg *config.Config) (*DB, error) {
+	sqlDB, err := sql.Open("sqlite", SQLiteDSN(cfg.DB.SQLitePath))
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
+
+	if err = sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("cant open sqlite database: %w", err)
+	}
+	return newDB(sqlDB, sqlitedialect.New(), cfg), nil
+}
+
+// SQLiteDSN returns the driver DSN of the database file at path. Every connection enforces foreign keys and
+// waits for a locked database instead of failing at once. Write-ahead logging lets readers run alongside the writer,
+// and transactions take the write lock when they begin, so two of them never deadlock upgrading their read locks.
+func SQLiteDSN(path string) string {
+	q := url.Values{}
+	q.Add("_pragma", "foreign_keys(1)")
+	q.Add("_pragma", "busy_timeout(5000)")
+	q.Add("_pragma", "journal_mode(WAL)")
+	q.Set("_txlock", "immediate")
+	return path + "?" + q.Encode()
+}
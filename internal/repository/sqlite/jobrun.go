@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+var _ repository.JobRunRepository = (*JobRunRepository)(nil)
+
+type JobRunRepository struct {
+	db *bun.DB
+}
+
+func NewJobRunRepository(db *bun.DB) *JobRunRepository {
+	return &JobRunRepository{
+		db: db,
+	}
+}
+
+func (r *JobRunRepository) Insert(ctx context.Context, run *model.JobRun) error {
+	run.Status = cmp.Or(run.Status, model.JobRunStatusRunning)
+	run.StartedAt = cmp.Or(run.StartedAt, now())
+
+	_, err := r.db.NewInsert().Model(run).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// Finish records the outcome of a run and sets its finished_at timestamp.
+func (r *JobRunRepository) Finish(ctx context.Context, run *model.JobRun) error {
+	finishedAt := now()
+	res, err := r.db.NewUpdate().
+		Model(run).
+		Column("status", "error").
+		Set("finished_at = ?", finishedAt).
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	run.FinishedAt = finishedAt
+	return nil
+}
+
+// Last returns the most recently scheduled run of a job.
+func (r *JobRunRepository) Last(ctx context.Context, job string) (*model.JobRun, error) {
+	run := new(model.JobRun)
+	err := r.db.NewSelect().
+		Model(run).
+		Where("job = ?", job).
+		Order("scheduled_at DESC", "id DESC").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return run, nil
+}
+
+// LastPerJob returns the most recently scheduled run of every job that has run at least once, as Last does for one job.
+func (r *JobRunRepository) LastPerJob(ctx context.Context) ([]model.JobRun, error) {
+	// SQLite has no DISTINCT ON; the latest run of each job is found through the (job, scheduled_at) index instead.
+	latest := r.db.NewSelect().
+		Model((*model.JobRun)(nil)).
+		ModelTableExpr("job_runs AS latest").
+		Column("latest.id").
+		Where("latest.job = jr.job").
+		Order("latest.scheduled_at DESC", "latest.id DESC").
+		Limit(1)
+
+	var runs []model.JobRun
+	err := r.db.NewSelect().
+		Model(&runs).
+		Where("jr.id = (?)", latest).
+		Order("job").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// DeleteOlderThan deletes the history of runs started more than d ago.
+// It returns the number of deleted runs.
+func (r *JobRunRepository) DeleteOlderThan(ctx context.Context, d time.Duration) (int64, error) {
+	res, err := r.db.NewDelete().
+		Model((*model.JobRun)(nil)).
+		Where("started_at < ?", time.Now().Add(-d)).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,303 @@
+package sqlite
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+var _ repository.RoomRepository = (*RoomRepository)(nil)
+
+type RoomRepository struct {
+	db bun.IDB
+}
+
+func NewRoomRepository(db bun.IDB) *RoomRepository {
+	return &RoomRepository{
+		db: db,
+	}
+}
+
+func (r *RoomRepository) conn(ctx context.Context) bun.IDB {
+	return repository.Conn(ctx, r.db)
+}
+
+// Insert stores a new room, retrying transient database errors.
+func (r *RoomRepository) Insert(ctx context.Context, room *model.Room) error {
+	current := now()
+	room.CreatedAt = cmp.Or(room.CreatedAt, current)
+	room.UpdatedAt = cmp.Or(room.UpdatedAt, current)
+	room.LastActiveAt = cmp.Or(room.LastActiveAt, current)
+
+	return repository.Retry(ctx, repository.DefaultRetryPolicy, func(ctx context.Context) error {
+		_, err := r.conn(ctx).NewInsert().Model(room).Exec(ctx)
+		return err
+	})
+}
+
+func (r *RoomRepository) GetByID(ctx context.Context, id int64) (*model.Room, error) {
+	room := new(model.Room)
+
+	err := r.conn(ctx).NewSelect().Model(room).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return room, nil
+}
+
+// List returns a page of rooms matching filter in the given order, starting after the cursor if one is set.
+// A backward cursor walks towards the start of the listing, returning the closest rooms first.
+func (r *RoomRepository) List(ctx context.Context, filter model.RoomFilter, order model.RoomOrder, after *cursor.Cursor, limit int) ([]model.Room, error) {
+	var rooms []model.Room
+	if filter.IDs != nil && len(filter.IDs) == 0 {
+		return rooms, nil
+	}
+
+	q := r.conn(ctx).NewSelect().
+		Model(&rooms)
+	applyFilter(q, filter)
+
+	cmp, dir := ">", "ASC"
+	if order.Desc != (after != nil && after.Backward) {
+		cmp, dir = "<", "DESC"
+	}
+
+	if order.Column == model.RoomOrderID {
+		if after != nil {
+			q.Where("id "+cmp+" ?", after.ID)
+		}
+		q.OrderExpr("id " + dir)
+	} else {
+		if after != nil {
+			q.Where("(?, id) "+cmp+" (?, ?)", bun.Ident(order.Column), after.Key, after.ID)
+		}
+		q.OrderExpr("? "+dir+", id "+dir, bun.Ident(order.Column))
+	}
+
+	err := q.
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+// Count returns the number of rooms matching filter.
+func (r *RoomRepository) Count(ctx context.Context, filter model.RoomFilter) (int, error) {
+	if filter.IDs != nil && len(filter.IDs) == 0 {
+		return 0, nil
+	}
+
+	q := r.conn(ctx).NewSelect().
+		Model((*model.Room)(nil))
+	applyFilter(q, filter)
+
+	return q.Count(ctx)
+}
+
+// applyFilter narrows q like the Postgres repository does, with the similarity and unicode_lower functions
+// registered by this package standing in for pg_trgm and ILIKE.
+func applyFilter(q *bun.SelectQuery, filter model.RoomFilter) {
+	if filter.Query != "" {
+		q.Where(`(unicode_lower(name) LIKE ? ESCAPE '\' OR similarity(name, ?) >= ?)`,
+			likeEscaper.Replace(strings.ToLower(filter.Query))+"%", filter.Query, repository.SimilarityThreshold)
+	}
+	if filter.HasPassword != nil {
+		if *filter.HasPassword {
+			q.Where("password_hash IS NOT NULL")
+		} else {
+			q.Where("password_hash IS NULL")
+		}
+	}
+	if filter.ActiveSince != nil {
+		q.Where("last_active_at >= ?", *filter.ActiveSince)
+	}
+	if filter.IDs != nil {
+		q.Where("id IN (?)", bun.In(filter.IDs))
+	}
+}
+
+// Update saves the editable fields of a room and refreshes its updated_at timestamp.
+func (r *RoomRepository) Update(ctx context.Context, room *model.Room) error {
+	updatedAt := now()
+	res, err := r.conn(ctx).NewUpdate().
+		Model(room).
+		Column("name", "password_hash", "topic", "description", "avatar_url", "metadata", "retention_days", "retention_max_messages").
+		Set("updated_at = ?", updatedAt).
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	room.UpdatedAt = updatedAt
+	return nil
+}
+
+// TouchActivity updates the activity timestamp of a room by its ID, retrying transient database errors.
+func (r *RoomRepository) TouchActivity(ctx context.Context, id int64) error {
+	var res sql.Result
+	err := repository.Retry(ctx, repository.DefaultRetryPolicy, func(ctx context.Context) error {
+		current := now()
+		var err error
+		res, err = r.conn(ctx).NewUpdate().
+			Model((*model.Room)(nil)).
+			Set("updated_at = ?", current).
+			Set("last_active_at = ?", current).
+			Where("id = ?", id).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchActivityBatch moves the activity timestamps of live rooms forward to the given times in one transaction;
+// deleted and unknown rooms are skipped. It returns the number of rooms updated.
+func (r *RoomRepository) TouchActivityBatch(ctx context.Context, activity map[int64]time.Time) (int64, error) {
+	if len(activity) == 0 {
+		return 0, nil
+	}
+	ids := slices.Sorted(maps.Keys(activity))
+
+	var affected int64
+	err := repository.Retry(ctx, repository.DefaultRetryPolicy, func(ctx context.Context) error {
+		affected = 0
+		// SQLite has no unnest, but statements are cheap in-process; one transaction keeps them a single write.
+		return r.conn(ctx).RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			for _, id := range ids {
+				res, err := tx.NewUpdate().
+					Model((*model.Room)(nil)).
+					Set("last_active_at = max(last_active_at, ?)", activity[id]).
+					Where("id = ?", id).
+					Exec(ctx)
+				if err != nil {
+					return err
+				}
+				n, err := res.RowsAffected()
+				if err != nil {
+					return err
+				}
+				affected += n
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// SoftDeleteInactiveOlderThan soft deletes rooms that have been inactive longer than d.
+// It returns the number of rooms that were marked as deleted.
+func (r *RoomRepository) SoftDeleteInactiveOlderThan(ctx context.Context, d time.Duration) (int64, error) {
+	res, err := r.conn(ctx).NewDelete().
+		Model((*model.Room)(nil)).
+		Where("last_active_at < ?", time.Now().Add(-d)).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// SoftDelete marks a room as deleted; it returns model.ErrNotFound if there is no live room with the ID.
+func (r *RoomRepository) SoftDelete(ctx context.Context, id int64) error {
+	res, err := r.conn(ctx).NewDelete().Model((*model.Room)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetDeletedByID returns a soft-deleted room by its ID. SQLite has no row locks; transactions begin
+// immediate and hold the database write lock, which keeps the row as read until they end.
+func (r *RoomRepository) GetDeletedByID(ctx context.Context, id int64) (*model.Room, error) {
+	room := new(model.Room)
+
+	err := r.conn(ctx).NewSelect().Model(room).WhereDeleted().Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return room, nil
+}
+
+// Restore undoes the soft delete of a room and marks it active, so the cleanup job does not delete it again right away.
+func (r *RoomRepository) Restore(ctx context.Context, id int64) error {
+	current := now()
+	res, err := r.conn(ctx).NewUpdate().
+		Model((*model.Room)(nil)).
+		WhereDeleted().
+		Set("deleted_at = NULL").
+		Set("updated_at = ?", current).
+		Set("last_active_at = ?", current).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// HardDeleteDeletedOlderThan permanently deletes rooms soft deleted more than d ago; their messages,
+// webhooks and incoming webhooks go with them. It returns the number of deleted rooms.
+func (r *RoomRepository) HardDeleteDeletedOlderThan(ctx context.Context, d time.Duration) (int64, error) {
+	res, err := r.conn(ctx).NewDelete().
+		Model((*model.Room)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", time.Now().Add(-d)).
+		ForceDelete().
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,36 @@
+// Package sqlite implements the repositories on SQLite for single-binary deployments, keeping the semantics
+// of the Postgres ones. Rooms and messages have their own queries here; bots and incoming webhooks share the
+// Postgres repositories, whose queries SQLite runs as they are.
+//
+// Timestamps are written from Go in bun's UTC text format, so comparing and ordering them as text is chronological.
+package sqlite
+
+import (
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/repository"
+	"modernc.org/sqlite"
+)
+
+func init() {
+	// SQLite has neither pg_trgm nor Unicode case folding, so room search gets both from Go.
+	sqlite.MustRegisterDeterministicScalarFunction("similarity", 2, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		a, _ := args[0].(string)
+		b, _ := args[1].(string)
+		return repository.Similarity(a, b), nil
+	})
+	sqlite.MustRegisterDeterministicScalarFunction("unicode_lower", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, _ := args[0].(string)
+		return strings.ToLower(s), nil
+	})
+}
+
+// now returns the current time at the microsecond precision it is stored with.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// likeEscaper escapes LIKE wildcards so a search query matches literally; queries declare the backslash with ESCAPE.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
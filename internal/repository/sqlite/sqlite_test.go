@@ -0,0 +1,152 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/config"
+	"github.com/Rasulikus/chat/internal/migrator"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	botRepo "github.com/Rasulikus/chat/internal/repository/bot"
+	"github.com/Rasulikus/chat/internal/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+// newDB returns a migrated database in a fresh file, closed when the test ends.
+func newDB(t *testing.T) *bun.DB {
+	t.Helper()
+	cfg := config.Default()
+	cfg.DB.Storage = config.StorageSQLite
+	cfg.DB.SQLitePath = filepath.Join(t.TempDir(), "chat.db")
+	require.NoError(t, migrator.Up(migrator.Options{DSN: cfg.DB.MigrateURL()}))
+
+	db, err := repository.NewClient(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	return db.DB
+}
+
+func newRepos(t *testing.T) repotest.Repositories {
+	db := newDB(t)
+	return repotest.Repositories{
+		Rooms:    NewRoomRepository(db),
+		Messages: NewMessageRepository(db),
+	}
+}
+
+func Test_RoomRepository_Contract(t *testing.T) {
+	repotest.TestRoomRepository(t, newRepos)
+}
+
+func Test_MessageRepository_Contract(t *testing.T) {
+	repotest.TestMessageRepository(t, newRepos)
+}
+
+func Test_WebhookRepository(t *testing.T) {
+	ctx := context.Background()
+	db := newDB(t)
+	rooms, hooks := NewRoomRepository(db), NewWebhookRepository(db)
+	room := &model.Room{Name: "room"}
+	require.NoError(t, rooms.Insert(ctx, room))
+	hook := &model.Webhook{RoomID: room.ID, URL: "http://example.com", Secret: "secret", Events: []string{model.WebhookEventMessage}}
+	require.NoError(t, hooks.Insert(ctx, hook))
+	require.Error(t, hooks.Insert(ctx, &model.Webhook{RoomID: 9999999, Events: []string{}}))
+
+	got, err := hooks.GetByID(ctx, hook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{model.WebhookEventMessage}, got.Events)
+
+	deliveries := []model.WebhookDelivery{
+		{WebhookID: hook.ID, Event: model.WebhookEventMessage, Payload: []byte(`{}`)},
+		{WebhookID: hook.ID, Event: model.WebhookEventMessage, Payload: []byte(`{}`), NextAttemptAt: time.Now().Add(time.Hour)},
+	}
+	require.NoError(t, hooks.InsertDeliveries(ctx, deliveries))
+
+	claimed, err := hooks.ClaimDueDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, deliveries[0].ID, claimed[0].ID)
+	assert.Equal(t, 1, claimed[0].Attempts)
+	assert.JSONEq(t, `{}`, string(claimed[0].Payload))
+	require.NotNil(t, claimed[0].Webhook)
+	assert.Equal(t, hook.URL, claimed[0].Webhook.URL)
+
+	// A leased delivery is not claimed again until the lease runs out.
+	claimed, err = hooks.ClaimDueDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, claimed)
+
+	require.NoError(t, hooks.MarkFailed(ctx, deliveries[0].ID, "boom", -time.Second, false))
+	claimed, err = hooks.ClaimDueDeliveries(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, 2, claimed[0].Attempts)
+	assert.Equal(t, "boom", claimed[0].LastError)
+
+	// Hard deleting the room removes its webhooks and their deliveries with it.
+	require.NoError(t, rooms.SoftDelete(ctx, room.ID))
+	_, err = rooms.HardDeleteDeletedOlderThan(ctx, -time.Minute)
+	require.NoError(t, err)
+	require.ErrorIs(t, hooks.Delete(ctx, hook.ID), model.ErrNotFound)
+	count, err := db.NewSelect().Model((*model.WebhookDelivery)(nil)).Count(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func Test_JobRunRepository(t *testing.T) {
+	ctx := context.Background()
+	runs := NewJobRunRepository(newDB(t))
+	slot := time.Now().Truncate(time.Minute)
+	first := &model.JobRun{Job: "cleanup", Instance: "a", ScheduledAt: slot.Add(-time.Minute)}
+	second := &model.JobRun{Job: "cleanup", Instance: "b", ScheduledAt: slot}
+	other := &model.JobRun{Job: "retention", Instance: "a", ScheduledAt: slot}
+	// catchUp starts last but makes up for an earlier slot, so it is not the latest run.
+	catchUp := &model.JobRun{Job: "cleanup", Instance: "c", ScheduledAt: slot.Add(-2 * time.Minute)}
+	for _, run := range []*model.JobRun{first, second, other, catchUp} {
+		require.NoError(t, runs.Insert(ctx, run))
+		assert.Equal(t, model.JobRunStatusRunning, run.Status)
+	}
+
+	second.Status = model.JobRunStatusSucceeded
+	require.NoError(t, runs.Finish(ctx, second))
+	assert.False(t, second.FinishedAt.IsZero())
+	require.ErrorIs(t, runs.Finish(ctx, &model.JobRun{ID: 9999999}), model.ErrNotFound)
+
+	last, err := runs.Last(ctx, "cleanup")
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, last.ID)
+	assert.Equal(t, model.JobRunStatusSucceeded, last.Status)
+	_, err = runs.Last(ctx, "unknown")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	perJob, err := runs.LastPerJob(ctx)
+	require.NoError(t, err)
+	require.Len(t, perJob, 2)
+	assert.Equal(t, second.ID, perJob[0].ID)
+	assert.Equal(t, other.ID, perJob[1].ID)
+
+	deleted, err := runs.DeleteOlderThan(ctx, -time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), deleted)
+}
+
+// The bot repository is shared with Postgres; SQLite's constraint errors must map the same way.
+func Test_BotRepository_OnSQLite(t *testing.T) {
+	ctx := context.Background()
+	bots := botRepo.NewRepository(newDB(t))
+	deploy := &model.Bot{Name: "deploy", APIKeyHash: "a"}
+	require.NoError(t, bots.Insert(ctx, deploy))
+	require.ErrorIs(t, bots.Insert(ctx, &model.Bot{Name: "deploy", APIKeyHash: "b"}), model.ErrConflict)
+
+	require.NoError(t, bots.ReplaceCommands(ctx, deploy.ID, []model.BotCommand{{Name: "ship"}}))
+	command, err := bots.GetCommandByName(ctx, "ship")
+	require.NoError(t, err)
+	require.NotNil(t, command.Bot)
+	assert.Equal(t, "deploy", command.Bot.Name)
+	assert.WithinDuration(t, time.Now(), command.CreatedAt, time.Minute)
+}
@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"cmp"
+	"context"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+var _ repository.WebhookRepository = (*WebhookRepository)(nil)
+
+type WebhookRepository struct {
+	db *bun.DB
+}
+
+func NewWebhookRepository(db *bun.DB) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+func (r *WebhookRepository) Insert(ctx context.Context, hook *model.Webhook) error {
+	current := now()
+	hook.CreatedAt = cmp.Or(hook.CreatedAt, current)
+	hook.UpdatedAt = cmp.Or(hook.UpdatedAt, current)
+
+	_, err := r.db.NewInsert().Model(hook).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *WebhookRepository) GetByID(ctx context.Context, id int64) (*model.Webhook, error) {
+	hook := new(model.Webhook)
+	err := r.db.NewSelect().Model(hook).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return hook, nil
+}
+
+func (r *WebhookRepository) ListByRoom(ctx context.Context, roomID int64) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	err := r.db.NewSelect().
+		Model(&hooks).
+		Where("room_id = ?", roomID).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.NewDelete().Model((*model.Webhook)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// InsertDeliveries enqueues deliveries in the persistent retry queue.
+func (r *WebhookRepository) InsertDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	current := now()
+	for i := range deliveries {
+		d := &deliveries[i]
+		d.NextAttemptAt = cmp.Or(d.NextAttemptAt, current)
+		d.CreatedAt = cmp.Or(d.CreatedAt, current)
+		d.UpdatedAt = cmp.Or(d.UpdatedAt, current)
+	}
+	_, err := r.db.NewInsert().Model(&deliveries).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ClaimDueDeliveries leases up to limit pending deliveries whose next attempt is due for the given duration,
+// so concurrent workers never pick the same one. SQLite runs one write at a time, so the claiming update
+// needs no row locks. Each claimed delivery has its attempt counter incremented and its Webhook loaded.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	current := now()
+	due := r.db.NewSelect().
+		Model((*model.WebhookDelivery)(nil)).
+		Column("id").
+		Where("status = ?", model.WebhookDeliveryPending).
+		Where("next_attempt_at <= ?", current).
+		Order("next_attempt_at ASC").
+		Limit(limit)
+
+	var ids []int64
+	err := r.db.NewUpdate().
+		Model((*model.WebhookDelivery)(nil)).
+		Set("attempts = attempts + 1").
+		Set("next_attempt_at = ?", current.Add(lease)).
+		Set("updated_at = ?", current).
+		Where("id IN (?)", due).
+		Returning("id").
+		Scan(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []model.WebhookDelivery
+	err = r.db.NewSelect().
+		Model(&deliveries).
+		Relation("Webhook").
+		Where("wd.id IN (?)", bun.In(ids)).
+		Order("wd.id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// MarkDelivered moves a delivery out of the retry queue after a successful attempt.
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*model.WebhookDelivery)(nil)).
+		Set("status = ?", model.WebhookDeliveryDelivered).
+		Set("last_error = NULL").
+		Set("updated_at = ?", now()).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt and either schedules the next one retryIn from now or moves the delivery
+// to the dead-letter state.
+func (r *WebhookRepository) MarkFailed(ctx context.Context, id int64, lastErr string, retryIn time.Duration, dead bool) error {
+	status := model.WebhookDeliveryPending
+	if dead {
+		status = model.WebhookDeliveryDead
+	}
+	current := now()
+	_, err := r.db.NewUpdate().
+		Model((*model.WebhookDelivery)(nil)).
+		Set("status = ?", status).
+		Set("last_error = ?", lastErr).
+		Set("next_attempt_at = ?", current.Add(retryIn)).
+		Set("updated_at = ?", current).
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -20,7 +20,13 @@ var (
 	truncateSQL = `
 	TRUNCATE TABLE
 		rooms,
-	    messages
+	    messages,
+	    webhooks,
+	    webhook_deliveries,
+	    incoming_webhooks,
+	    bots,
+	    bot_commands,
+	    job_runs
 	RESTART IDENTITY CASCADE;
 	`
 )
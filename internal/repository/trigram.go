@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+)
+
+// SimilarityThreshold is the default pg_trgm.similarity_threshold used by the % operator.
+const SimilarityThreshold = 0.3
+
+// Similarity is pg_trgm's similarity(): the trigrams both strings share over all distinct trigrams of the two.
+// Backends without pg_trgm search room names with it.
+func Similarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if _, ok := tb[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams splits s into lower-cased words of letters and digits and returns their trigrams,
+// padding every word with two spaces in front and one behind as pg_trgm does.
+func trigrams(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			set[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+	return set
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Similarity(t *testing.T) {
+	testCases := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "random", b: "random", want: 1},
+		{a: "Random", b: "RANDOM", want: 1},
+		// Both have 7 trigrams and share "  r", " ra", "ran", "and", "ndo".
+		{a: "random", b: "randon", want: 5.0 / 9},
+		{a: "general", b: "xyz", want: 0},
+		{a: "general", b: "", want: 0},
+		{a: "!!!", b: "!!!", want: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.a+"/"+tc.b, func(t *testing.T) {
+			assert.InDelta(t, tc.want, Similarity(tc.a, tc.b), 1e-9)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+// Transactor runs a unit of work spanning several repository calls in one database transaction.
+type Transactor interface {
+	// InTx runs fn in a transaction that is committed when fn returns nil and rolled back otherwise.
+	// Repository calls made with the context passed to fn join the transaction, and so does a nested InTx.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+var _ Transactor = (*BunTransactor)(nil)
+
+// BunTransactor runs transactions on a bun database.
+type BunTransactor struct {
+	db *bun.DB
+}
+
+func NewTransactor(db *bun.DB) *BunTransactor {
+	return &BunTransactor{
+		db: db,
+	}
+}
+
+type txKey struct{}
+
+// InTx runs fn in a transaction. A transaction that fails with a transient error has been rolled back
+// and is run again as a whole, so fn must not have effects outside the database that cannot be repeated.
+func (t *BunTransactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if inTx(ctx) {
+		return fn(ctx)
+	}
+	return Retry(ctx, DefaultRetryPolicy, func(ctx context.Context) error {
+		return t.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+			return fn(context.WithValue(ctx, txKey{}, tx))
+		})
+	})
+}
+
+// Conn returns the transaction carried by ctx, or db outside of a transaction. Repositories that take part
+// in Transactor units of work run every query on Conn(ctx, db), so they need no transaction of their own.
+func Conn(ctx context.Context, db bun.IDB) bun.IDB {
+	if tx, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(bun.Tx)
+	return ok
+}
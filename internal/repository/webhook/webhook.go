@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+var _ repository.WebhookRepository = (*Repository)(nil)
+
+type Repository struct {
+	db *bun.DB
+}
+
+func NewRepository(db *bun.DB) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Insert(ctx context.Context, hook *model.Webhook) error {
+	_, err := r.db.NewInsert().Model(hook).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) GetByID(ctx context.Context, id int64) (*model.Webhook, error) {
+	hook := new(model.Webhook)
+	err := r.db.NewSelect().Model(hook).Where("id = ?", id).Scan(ctx)
+	if err != nil {
+		return nil, repository.IsNoRowsError(err)
+	}
+	return hook, nil
+}
+
+func (r *Repository) ListByRoom(ctx context.Context, roomID int64) ([]model.Webhook, error) {
+	var hooks []model.Webhook
+	err := r.db.NewSelect().
+		Model(&hooks).
+		Where("room_id = ?", roomID).
+		Order("id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+func (r *Repository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.NewDelete().Model((*model.Webhook)(nil)).Where("id = ?", id).Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return model.ErrNotFound
+	}
+
+	return nil
+}
+
+// InsertDeliveries enqueues deliveries in the persistent retry queue.
+func (r *Repository) InsertDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	_, err := r.db.NewInsert().Model(&deliveries).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ClaimDueDeliveries locks up to limit pending deliveries whose next attempt is due and
+// leases them for the given duration, so concurrent workers never pick the same row.
+// Due-ness and the lease both use the database clock, so a replica whose clock lags cannot shorten the lease.
+// Each claimed delivery has its attempt counter incremented and its Webhook loaded.
+func (r *Repository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	due := r.db.NewSelect().
+		Model((*model.WebhookDelivery)(nil)).
+		Column("id").
+		Where("status = ?", model.WebhookDeliveryPending).
+		Where("next_attempt_at <= current_timestamp").
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	var ids []int64
+	err := r.db.NewUpdate().
+		Model((*model.WebhookDelivery)(nil)).
+		Set("attempts = attempts + 1").
+		Set("next_attempt_at = current_timestamp + ? * interval '1 second'", lease.Seconds()).
+		Set("updated_at = current_timestamp").
+		Where("id IN (?)", due).
+		Returning("id").
+		Scan(ctx, &ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []model.WebhookDelivery
+	err = r.db.NewSelect().
+		Model(&deliveries).
+		Relation("Webhook").
+		Where("wd.id IN (?)", bun.In(ids)).
+		Order("wd.id ASC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// MarkDelivered moves a delivery out of the retry queue after a successful attempt.
+func (r *Repository) MarkDelivered(ctx context.Context, id int64) error {
+	_, err := r.db.NewUpdate().
+		Model((*model.WebhookDelivery)(nil)).
+		Set("status = ?", model.WebhookDeliveryDelivered).
+		Set("last_error = NULL").
+		Set("updated_at = current_timestamp").
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt and either schedules the next one retryIn from now, on the database clock,
+// or moves the delivery to the dead-letter state.
+func (r *Repository) MarkFailed(ctx context.Context, id int64, lastErr string, retryIn time.Duration, dead bool) error {
+	status := model.WebhookDeliveryPending
+	if dead {
+		status = model.WebhookDeliveryDead
+	}
+	_, err := r.db.NewUpdate().
+		Model((*model.WebhookDelivery)(nil)).
+		Set("status = ?", status).
+		Set("last_error = ?", lastErr).
+		Set("next_attempt_at = current_timestamp + ? * interval '1 second'", retryIn.Seconds()).
+		Set("updated_at = current_timestamp").
+		Where("id = ?", id).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository/room"
+	testdb "github.com/Rasulikus/chat/internal/repository/test_db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+)
+
+func TestMain(m *testing.M) {
+	testdb.RecreateTables()
+	code := m.Run()
+	testdb.CloseDB()
+	os.Exit(code)
+}
+
+type testSuite struct {
+	db          *bun.DB
+	webhookRepo *Repository
+	roomRepo    *room.Repository
+	ctx         context.Context
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	var suite testSuite
+	suite.db = testdb.DB()
+	suite.webhookRepo = NewRepository(suite.db)
+	suite.roomRepo = room.NewRepository(suite.db)
+	suite.ctx = context.Background()
+	return &suite
+}
+
+func (ts *testSuite) insertHook(t *testing.T) *model.Webhook {
+	t.Helper()
+	testRoom := &model.Room{
+		Name: "testroom",
+	}
+	require.NoError(t, ts.roomRepo.Insert(ts.ctx, testRoom))
+
+	hook := &model.Webhook{
+		RoomID: testRoom.ID,
+		URL:    "http://example.com/hook",
+		Secret: "secret",
+		Events: []string{model.WebhookEventMessage},
+	}
+	require.NoError(t, ts.webhookRepo.Insert(ts.ctx, hook))
+	return hook
+}
+
+func Test_Repo_Insert(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+
+	hook := ts.insertHook(t)
+	assert.NotZero(t, hook.ID)
+	assert.WithinDuration(t, time.Now(), hook.CreatedAt, time.Second)
+
+	got, err := ts.webhookRepo.GetByID(ts.ctx, hook.ID)
+	require.NoError(t, err)
+	assert.Equal(t, hook.URL, got.URL)
+	assert.Equal(t, []string{model.WebhookEventMessage}, got.Events)
+}
+
+func Test_Repo_GetByID(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+
+	_, err := ts.webhookRepo.GetByID(ts.ctx, 1)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_Repo_ListByRoom(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	hook := ts.insertHook(t)
+
+	t.Run("list room hooks", func(t *testing.T) {
+		hooks, err := ts.webhookRepo.ListByRoom(ts.ctx, hook.RoomID)
+		require.NoError(t, err)
+		assert.Len(t, hooks, 1)
+	})
+	t.Run("list with not valid room", func(t *testing.T) {
+		hooks, err := ts.webhookRepo.ListByRoom(ts.ctx, -1)
+		require.NoError(t, err)
+		assert.Len(t, hooks, 0)
+	})
+}
+
+func Test_Repo_Delete(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	hook := ts.insertHook(t)
+
+	require.NoError(t, ts.webhookRepo.Delete(ts.ctx, hook.ID))
+	require.ErrorIs(t, ts.webhookRepo.Delete(ts.ctx, hook.ID), model.ErrNotFound)
+}
+
+func Test_Repo_Deliveries(t *testing.T) {
+	ts := setupTestSuite(t)
+	testdb.CleanDB(ts.ctx)
+	hook := ts.insertHook(t)
+
+	payload, err := json.Marshal(model.WebhookEvent{Event: model.WebhookEventMessage, RoomID: hook.RoomID})
+	require.NoError(t, err)
+	err = ts.webhookRepo.InsertDeliveries(ts.ctx, []model.WebhookDelivery{
+		{WebhookID: hook.ID, Event: model.WebhookEventMessage, Payload: payload},
+	})
+	require.NoError(t, err)
+
+	var claimed []model.WebhookDelivery
+	t.Run("claim due delivery", func(t *testing.T) {
+		claimed, err = ts.webhookRepo.ClaimDueDeliveries(ts.ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, 1, claimed[0].Attempts)
+		require.NotNil(t, claimed[0].Webhook)
+		assert.Equal(t, hook.URL, claimed[0].Webhook.URL)
+	})
+
+	t.Run("leased delivery is not claimed again", func(t *testing.T) {
+		again, err := ts.webhookRepo.ClaimDueDeliveries(ts.ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Len(t, again, 0)
+	})
+
+	t.Run("failed delivery is retried when due", func(t *testing.T) {
+		err := ts.webhookRepo.MarkFailed(ts.ctx, claimed[0].ID, "boom", -time.Second, false)
+		require.NoError(t, err)
+		again, err := ts.webhookRepo.ClaimDueDeliveries(ts.ctx, 10, time.Minute)
+		require.NoError(t, err)
+		require.Len(t, again, 1)
+		assert.Equal(t, 2, again[0].Attempts)
+		assert.Equal(t, "boom", again[0].LastError)
+	})
+
+	t.Run("dead delivery is not claimed", func(t *testing.T) {
+		err := ts.webhookRepo.MarkFailed(ts.ctx, claimed[0].ID, "boom", -time.Second, true)
+		require.NoError(t, err)
+		again, err := ts.webhookRepo.ClaimDueDeliveries(ts.ctx, 10, time.Minute)
+		require.NoError(t, err)
+		assert.Len(t, again, 0)
+	})
+}
@@ -0,0 +1,48 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule computes when a job runs next.
+type Schedule interface {
+	// Next returns the first run time strictly after t.
+	Next(t time.Time) time.Time
+	String() string
+}
+
+type every time.Duration
+
+// Every returns a schedule that runs a job at a fixed interval.
+func Every(d time.Duration) Schedule {
+	return every(d)
+}
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func (e every) String() string {
+	return "every " + time.Duration(e).String()
+}
+
+type cronSchedule struct {
+	spec string
+	cron.Schedule
+}
+
+// Cron parses a standard five-field cron expression such as "30 3 * * *" or a descriptor such as "@hourly".
+func Cron(spec string) (Schedule, error) {
+	s, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("parse cron schedule %q: %w", spec, err)
+	}
+	return cronSchedule{spec: spec, Schedule: s}, nil
+}
+
+func (c cronSchedule) String() string {
+	return c.spec
+}
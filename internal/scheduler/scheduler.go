@@ -0,0 +1,195 @@
+// Package scheduler runs named background jobs on intervals or cron schedules.
+//
+// Every replica runs the scheduler, but each run of a job happens on one replica only: a run takes
+// a Postgres advisory lock named after the job and skips the slot if another replica already ran it.
+// Runs are recorded in the job_runs table, and a panicking job is recorded as failed instead of crashing the server.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/metrics"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/uptrace/bun"
+)
+
+// Job is a named unit of background work.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Timeout bounds a single run; zero means no limit.
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// JobStatus describes a registered job and its latest run.
+type JobStatus struct {
+	Name      string        `json:"name"`
+	Schedule  string        `json:"schedule"`
+	NextRunAt time.Time     `json:"next_run_at"`
+	LastRun   *model.JobRun `json:"last_run,omitempty"`
+}
+
+type Scheduler struct {
+	db       *bun.DB
+	runs     repository.JobRunRepository
+	instance string
+
+	mu   sync.RWMutex
+	jobs []Job
+	next map[string]time.Time
+}
+
+// New constructs a scheduler that coordinates through db and records runs in runs.
+// A nil db runs every slot without a lock, which is only correct for a single replica, as with in-memory storage.
+func New(db *bun.DB, runs repository.JobRunRepository) *Scheduler {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+	return &Scheduler{
+		db:       db,
+		runs:     runs,
+		instance: instance,
+		next:     make(map[string]time.Time),
+	}
+}
+
+// Add registers a job; it must be called before Start.
+func (s *Scheduler) Add(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.jobs = append(s.jobs, job)
+}
+
+// Start launches one goroutine per job; they stop when ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, job := range s.jobs {
+		go s.loop(ctx, job)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	ctx = logging.WithContext(ctx, logging.FromContext(ctx).With("job", job.Name))
+	for {
+		slot := job.Schedule.Next(time.Now())
+		s.setNext(job.Name, slot)
+
+		timer := time.NewTimer(time.Until(slot))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.runSlot(ctx, job, slot)
+		}
+	}
+}
+
+// runSlot runs the job for one slot under its advisory lock, unless another replica already ran that slot.
+func (s *Scheduler) runSlot(ctx context.Context, job Job, slot time.Time) {
+	run := func(ctx context.Context) error {
+		last, err := s.runs.Last(ctx, job.Name)
+		if err != nil && !errors.Is(err, model.ErrNotFound) {
+			return err
+		}
+		if last != nil && job.Schedule.Next(last.ScheduledAt).After(slot) {
+			return nil
+		}
+		return s.record(ctx, job, slot)
+	}
+
+	var err error
+	if s.db == nil {
+		err = run(ctx)
+	} else {
+		_, err = repository.WithAdvisoryLock(ctx, s.db, repository.LockKey("job:"+job.Name), run)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("scheduler: run slot", "err", err)
+	}
+}
+
+// record runs the job and stores the outcome in the run history.
+func (s *Scheduler) record(ctx context.Context, job Job, slot time.Time) error {
+	run := &model.JobRun{
+		Job:         job.Name,
+		Instance:    s.instance,
+		ScheduledAt: slot,
+	}
+	if err := s.runs.Insert(ctx, run); err != nil {
+		return err
+	}
+
+	run.Status = model.JobRunStatusSucceeded
+	if err := s.execute(ctx, job); err != nil {
+		run.Status = model.JobRunStatusFailed
+		run.Error = err.Error()
+		logging.FromContext(ctx).Error("scheduler: job failed", "err", err)
+	}
+	metrics.JobRuns.WithLabelValues(job.Name, run.Status).Inc()
+
+	// The outcome is stored even when the server is shutting down and ctx is cancelled.
+	return s.runs.Finish(context.WithoutCancel(ctx), run)
+}
+
+// execute calls the job, turning a panic into an error.
+func (s *Scheduler) execute(ctx context.Context, job Job) (err error) {
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(ctx).Error("scheduler: job panicked", "panic", r, "stack", string(debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) setNext(name string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.next[name] = t
+}
+
+// Status returns every registered job with its next run on this replica and its latest run on any replica.
+func (s *Scheduler) Status(ctx context.Context) ([]JobStatus, error) {
+	runs, err := s.runs.LastPerJob(ctx)
+	if err != nil {
+		return nil, err
+	}
+	last := make(map[string]*model.JobRun, len(runs))
+	for i := range runs {
+		last[runs[i].Job] = &runs[i]
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		out = append(out, JobStatus{
+			Name:      job.Name,
+			Schedule:  job.Schedule.String(),
+			NextRunAt: s.next[job.Name],
+			LastRun:   last[job.Name],
+		})
+	}
+	return out, nil
+}
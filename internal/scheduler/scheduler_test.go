@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRuns struct {
+	inserted []model.JobRun
+	finished []model.JobRun
+}
+
+func (f *fakeRuns) Insert(_ context.Context, run *model.JobRun) error {
+	run.ID = int64(len(f.inserted) + 1)
+	f.inserted = append(f.inserted, *run)
+	return nil
+}
+
+func (f *fakeRuns) Finish(_ context.Context, run *model.JobRun) error {
+	f.finished = append(f.finished, *run)
+	return nil
+}
+
+func (f *fakeRuns) Last(context.Context, string) (*model.JobRun, error) {
+	return nil, model.ErrNotFound
+}
+
+func (f *fakeRuns) LastPerJob(context.Context) ([]model.JobRun, error) {
+	return f.finished, nil
+}
+
+func (f *fakeRuns) DeleteOlderThan(context.Context, time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func Test_Schedule(t *testing.T) {
+	base := time.Date(2025, 3, 10, 14, 7, 30, 0, time.UTC)
+
+	t.Run("every", func(t *testing.T) {
+		assert.Equal(t, base.Add(time.Hour), Every(time.Hour).Next(base))
+	})
+	t.Run("cron", func(t *testing.T) {
+		s, err := Cron("30 3 * * *")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 3, 11, 3, 30, 0, 0, time.UTC), s.Next(base))
+		assert.Equal(t, "30 3 * * *", s.String())
+	})
+	t.Run("descriptor", func(t *testing.T) {
+		s, err := Cron("@hourly")
+		require.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 3, 10, 15, 0, 0, 0, time.UTC), s.Next(base))
+	})
+	t.Run("invalid cron", func(t *testing.T) {
+		_, err := Cron("61 * * * *")
+		require.Error(t, err)
+	})
+}
+
+func Test_Scheduler_Record(t *testing.T) {
+	slot := time.Now()
+	testCases := []struct {
+		name       string
+		run        func(ctx context.Context) error
+		wantStatus string
+		wantErr    string
+	}{
+		{
+			name:       "success",
+			run:        func(context.Context) error { return nil },
+			wantStatus: model.JobRunStatusSucceeded,
+		},
+		{
+			name:       "error",
+			run:        func(context.Context) error { return errors.New("boom") },
+			wantStatus: model.JobRunStatusFailed,
+			wantErr:    "boom",
+		},
+		{
+			name:       "panic",
+			run:        func(context.Context) error { panic("oops") },
+			wantStatus: model.JobRunStatusFailed,
+			wantErr:    "panic: oops",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			runs := &fakeRuns{}
+			s := New(nil, runs)
+			s.Add(Job{Name: "test", Schedule: Every(time.Minute), Run: tc.run})
+
+			err := s.record(context.Background(), s.jobs[0], slot)
+			require.NoError(t, err)
+			require.Len(t, runs.finished, 1)
+			assert.Equal(t, "test", runs.finished[0].Job)
+			assert.Equal(t, slot, runs.finished[0].ScheduledAt)
+			assert.Equal(t, tc.wantStatus, runs.finished[0].Status)
+			assert.Equal(t, tc.wantErr, runs.finished[0].Error)
+
+			status, err := s.Status(context.Background())
+			require.NoError(t, err)
+			require.Len(t, status, 1)
+			assert.Equal(t, "every 1m0s", status[0].Schedule)
+			assert.Equal(t, tc.wantStatus, status[0].LastRun.Status)
+		})
+	}
+}
+
+func Test_Scheduler_Timeout(t *testing.T) {
+	s := New(nil, &fakeRuns{})
+	err := s.execute(context.Background(), Job{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func Test_Scheduler_RunSlot_WithoutDB(t *testing.T) {
+	runs := memory.NewJobRunRepository(memory.NewStore())
+	s := New(nil, runs)
+	calls := 0
+	s.Add(Job{Name: "test", Schedule: Every(time.Minute), Run: func(context.Context) error {
+		calls++
+		return nil
+	}})
+
+	slot := time.Now().Truncate(time.Second)
+	s.runSlot(context.Background(), s.jobs[0], slot)
+	// A slot that was already run is skipped.
+	s.runSlot(context.Background(), s.jobs[0], slot)
+	assert.Equal(t, 1, calls)
+
+	last, err := runs.Last(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, model.JobRunStatusSucceeded, last.Status)
+}
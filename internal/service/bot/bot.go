@@ -0,0 +1,172 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/egress"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/Rasulikus/chat/internal/service/webhook"
+)
+
+// HeaderBotCommand names the invoked command on HTTP callbacks; the body is signed like outgoing webhooks.
+const HeaderBotCommand = "X-Chat-Command"
+
+var _ service.BotService = (*Service)(nil)
+
+type Service struct {
+	botRepo repository.BotRepository
+	client  *http.Client
+}
+
+func NewService(botRepo repository.BotRepository) *Service {
+	return &Service{
+		botRepo: botRepo,
+		client:  egress.Client(10 * time.Second),
+	}
+}
+
+// Create registers a new bot and returns it with its API key and callback secret.
+// Only hashes of the API key are stored, so it cannot be shown again.
+// The callback URL is held to the same address rules as webhook URLs.
+func (s *Service) Create(ctx context.Context, in service.CreateBotInput) (*model.Bot, error) {
+	if in.CallbackURL != "" {
+		if err := webhook.ValidateURL(ctx, "callback_url", in.CallbackURL); err != nil {
+			return nil, err
+		}
+	}
+
+	apiKey, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := &model.Bot{
+		Name:           in.Name,
+		APIKeyHash:     hashKey(apiKey),
+		CallbackURL:    in.CallbackURL,
+		CallbackSecret: secret,
+	}
+	err = s.botRepo.Insert(ctx, bot)
+	if err != nil {
+		return nil, err
+	}
+	bot.APIKey = apiKey
+	return bot, nil
+}
+
+// Authenticate resolves an API key to its bot and returns model.ErrUnauthorized for unknown keys.
+func (s *Service) Authenticate(ctx context.Context, apiKey string) (*model.Bot, error) {
+	if apiKey == "" {
+		return nil, model.ErrUnauthorized
+	}
+	bot, err := s.botRepo.GetByAPIKeyHash(ctx, hashKey(apiKey))
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, model.ErrUnauthorized
+		}
+		return nil, err
+	}
+	bot.CallbackSecret = ""
+	return bot, nil
+}
+
+// SetCommands replaces the commands registered by the bot.
+func (s *Service) SetCommands(ctx context.Context, botID int64, commands []service.BotCommandInput) ([]model.BotCommand, error) {
+	rows := make([]model.BotCommand, 0, len(commands))
+	for _, c := range commands {
+		rows = append(rows, model.BotCommand{
+			Name:        c.Name,
+			Description: c.Description,
+		})
+	}
+	if err := s.botRepo.ReplaceCommands(ctx, botID, rows); err != nil {
+		return nil, err
+	}
+	return s.botRepo.ListCommands(ctx, &botID)
+}
+
+// ListCommands returns the commands of one bot, or of every bot when botID is nil.
+func (s *Service) ListCommands(ctx context.Context, botID *int64) ([]model.BotCommand, error) {
+	return s.botRepo.ListCommands(ctx, botID)
+}
+
+// FindCommand returns a bot-registered command by name with its owning bot loaded.
+func (s *Service) FindCommand(ctx context.Context, name string) (*model.BotCommand, error) {
+	return s.botRepo.GetCommandByName(ctx, name)
+}
+
+// Callback POSTs the invocation to the bot's callback URL and decodes its reply.
+// An empty response body means the bot has nothing to say.
+func (s *Service) Callback(ctx context.Context, bot *model.Bot, inv model.CommandInvocation) (*model.CommandReply, error) {
+	if bot.CallbackURL == "" {
+		return nil, fmt.Errorf("bot %s has no callback url", bot.Name)
+	}
+
+	body, err := json.Marshal(inv)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, bot.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderBotCommand, inv.Command)
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(bot.CallbackSecret, ts, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("bot %s callback: unexpected status %d", bot.Name, resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	reply := new(model.CommandReply)
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return reply, nil
+	}
+	if err = json.Unmarshal(raw, reply); err != nil {
+		return nil, fmt.Errorf("bot %s callback: decode reply: %w", bot.Name, err)
+	}
+	return reply, nil
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
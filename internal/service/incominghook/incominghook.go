@@ -0,0 +1,109 @@
+package incominghook
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/Rasulikus/chat/internal/service"
+)
+
+var _ service.IncomingWebhookService = (*Service)(nil)
+
+type Service struct {
+	hookRepo       repository.IncomingWebhookRepository
+	roomRepo       repository.RoomRepository
+	messageService service.MessageService
+}
+
+func NewService(hookRepo repository.IncomingWebhookRepository, roomRepo repository.RoomRepository, messageService service.MessageService) *Service {
+	return &Service{
+		hookRepo:       hookRepo,
+		roomRepo:       roomRepo,
+		messageService: messageService,
+	}
+}
+
+// Create generates a posting token for the room and persists only its hash.
+// The plain token is returned once in the Token field.
+func (s *Service) Create(ctx context.Context, in service.CreateIncomingWebhookInput) (*model.IncomingWebhook, error) {
+	if _, err := s.roomRepo.GetByID(ctx, in.RoomID); err != nil {
+		return nil, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &model.IncomingWebhook{
+		RoomID:    in.RoomID,
+		Name:      in.Name,
+		TokenHash: hashToken(token),
+	}
+	err = s.hookRepo.Insert(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+	hook.Token = token
+	return hook, nil
+}
+
+// ListByRoom returns the incoming webhooks of a room.
+func (s *Service) ListByRoom(ctx context.Context, roomID int64) ([]model.IncomingWebhook, error) {
+	return s.hookRepo.ListByRoom(ctx, roomID)
+}
+
+// Delete revokes an incoming webhook that belongs to the given room.
+func (s *Service) Delete(ctx context.Context, roomID, id int64) error {
+	hook, err := s.hookRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hook.RoomID != roomID {
+		return model.ErrNotFound
+	}
+	return s.hookRepo.Delete(ctx, id)
+}
+
+// Post resolves the token to its room and creates a message there.
+// The webhook name is used as the nick unless the caller overrides it.
+func (s *Service) Post(ctx context.Context, in service.PostIncomingWebhookInput) (*model.Message, error) {
+	hook, err := s.hookRepo.GetByTokenHash(ctx, hashToken(in.Token))
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.roomRepo.GetByID(ctx, hook.RoomID); err != nil {
+		return nil, err
+	}
+
+	nick := in.Nick
+	if nick == "" {
+		nick = hook.Name
+	}
+
+	return s.messageService.Create(ctx, service.CreateMessageInput{
+		RoomID:      hook.RoomID,
+		Kind:        model.MessageKindBot,
+		Nick:        nick,
+		Text:        in.Text,
+		Attachments: in.Attachments,
+	})
+}
+
+func generateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
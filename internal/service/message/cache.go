@@ -0,0 +1,190 @@
+package message
+
+import (
+	"cmp"
+	"container/list"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/metrics"
+	"github.com/Rasulikus/chat/internal/model"
+)
+
+// HistoryCache keeps the latest messages of recently read rooms in memory, so the first history page that every joining client
+// asks for is served without a database query. Rooms are evicted least recently used first, and entries are reloaded after ttl.
+// Concurrent misses on one room wait for a single load. A nil HistoryCache caches nothing.
+type HistoryCache struct {
+	mu      sync.Mutex
+	rooms   int
+	size    int
+	ttl     time.Duration
+	lru     *list.List // of *historyEntry, most recently used first
+	entries map[int64]*list.Element
+	loads   map[int64]*historyLoad
+}
+
+type historyEntry struct {
+	roomID int64
+	// messages are the newest messages of the room, newest first.
+	messages []model.Message
+	// complete is set when messages hold the whole history of the room.
+	complete bool
+	loadedAt time.Time
+}
+
+// historyLoad is a database read in flight; it is marked stale when the room changes meanwhile, so its result is not cached.
+type historyLoad struct {
+	done  chan struct{}
+	stale bool
+}
+
+// NewHistoryCache returns a cache of the size latest messages of up to rooms rooms, or nil when rooms is 0.
+// A ttl of 0 keeps entries until they are evicted.
+func NewHistoryCache(rooms, size int, ttl time.Duration) *HistoryCache {
+	if rooms <= 0 {
+		return nil
+	}
+	return &HistoryCache{
+		rooms:   rooms,
+		size:    size,
+		ttl:     ttl,
+		lru:     list.New(),
+		entries: make(map[int64]*list.Element),
+		loads:   make(map[int64]*historyLoad),
+	}
+}
+
+// latest returns up to n newest messages of a room, newest first. On a miss it reads the messages with load, which is called
+// with a limit above both n and the cached size, and caches them. Pages larger than the cached size are served only for rooms whose
+// whole history fits in the cache.
+func (c *HistoryCache) latest(ctx context.Context, roomID int64, n int, load func(ctx context.Context, limit int) ([]model.Message, error)) ([]model.Message, error) {
+	if c == nil {
+		return load(ctx, n)
+	}
+
+	var l *historyLoad
+	for {
+		c.mu.Lock()
+		if messages, ok := c.lookup(roomID, n); ok {
+			c.mu.Unlock()
+			metrics.HistoryCacheRequests.WithLabelValues("hit").Inc()
+			return messages, nil
+		}
+		inFlight, ok := c.loads[roomID]
+		if !ok {
+			l = &historyLoad{done: make(chan struct{})}
+			c.loads[roomID] = l
+			c.mu.Unlock()
+			break
+		}
+		c.mu.Unlock()
+
+		// Another request is reading this room; its result is checked again once it is cached, or the load is retried here if it failed.
+		select {
+		case <-inFlight.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	metrics.HistoryCacheRequests.WithLabelValues("miss").Inc()
+
+	messages, err := load(ctx, max(n, c.size+1))
+
+	c.mu.Lock()
+	delete(c.loads, roomID)
+	close(l.done)
+	if err == nil && !l.stale {
+		complete := len(messages) <= c.size
+		c.store(&historyEntry{
+			roomID:   roomID,
+			messages: slices.Clone(messages[:min(len(messages), c.size)]),
+			complete: complete,
+			loadedAt: time.Now(),
+		})
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return messages[:min(len(messages), n)], nil
+}
+
+// lookup returns a copy of the n newest cached messages of a room if the entry is fresh and holds enough of them.
+func (c *HistoryCache) lookup(roomID int64, n int) ([]model.Message, bool) {
+	e, ok := c.entries[roomID]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*historyEntry)
+	if c.ttl > 0 && time.Since(entry.loadedAt) > c.ttl {
+		c.remove(e)
+		return nil, false
+	}
+	if len(entry.messages) < n && !entry.complete {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return slices.Clone(entry.messages[:min(len(entry.messages), n)]), true
+}
+
+func (c *HistoryCache) store(entry *historyEntry) {
+	if e, ok := c.entries[entry.roomID]; ok {
+		c.remove(e)
+	}
+	c.entries[entry.roomID] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.rooms {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *HistoryCache) remove(e *list.Element) {
+	delete(c.entries, e.Value.(*historyEntry).roomID)
+	c.lru.Remove(e)
+}
+
+// add puts a newly stored message into the entry of its room, if the room is cached. Messages can arrive out of ID order
+// when inserts race, so the message is placed by its ID.
+func (c *HistoryCache) add(message *model.Message) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if l, ok := c.loads[message.RoomID]; ok {
+		l.stale = true
+	}
+	e, ok := c.entries[message.RoomID]
+	if !ok {
+		return
+	}
+	entry := e.Value.(*historyEntry)
+	i, found := slices.BinarySearchFunc(entry.messages, message.ID, func(m model.Message, id int64) int {
+		return cmp.Compare(id, m.ID)
+	})
+	if found || i == len(entry.messages) && !entry.complete {
+		return
+	}
+	entry.messages = slices.Insert(entry.messages, i, *message)
+	if len(entry.messages) > c.size {
+		entry.messages = slices.Delete(entry.messages, c.size, len(entry.messages))
+		entry.complete = false
+	}
+}
+
+// invalidate drops every entry, for changes such as retention purges that can touch the history of any room.
+func (c *HistoryCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, l := range c.loads {
+		l.stale = true
+	}
+	c.lru.Init()
+	clear(c.entries)
+}
@@ -2,7 +2,10 @@ package message
 
 import (
 	"context"
+	"slices"
 
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/metrics"
 	"github.com/Rasulikus/chat/internal/model"
 	"github.com/Rasulikus/chat/internal/repository"
 	"github.com/Rasulikus/chat/internal/service"
@@ -10,40 +13,101 @@ import (
 
 var _ service.MessageService = (*Service)(nil)
 
+// historyOrder is the ordering message cursors are issued for: history is walked from the newest message.
+const historyOrder = "id desc"
+
 type Service struct {
 	messageRepo repository.MessageRepository
+	activity    service.ActivityRecorder
+	cursors     *cursor.Codec
+	history     *HistoryCache
 }
 
-func NewService(messageRepo repository.MessageRepository) *Service {
+// NewService returns a message service serving the latest page of room history from history; a nil cache reads every page from the repository.
+func NewService(messageRepo repository.MessageRepository, activity service.ActivityRecorder, cursors *cursor.Codec, history *HistoryCache) *Service {
 	return &Service{
 		messageRepo: messageRepo,
+		activity:    activity,
+		cursors:     cursors,
+		history:     history,
 	}
 }
 
-// Create creates a new message and persists it in the repository.
+// Create creates a new message and persists it in the repository, counting it as activity of the room and adding it to the cached history.
+// User and bot messages require a nick; system messages are stored without one.
 func (s *Service) Create(ctx context.Context, in service.CreateMessageInput) (*model.Message, error) {
+	kind := in.Kind
+	if kind == "" {
+		kind = model.MessageKindUser
+	}
+	switch kind {
+	case model.MessageKindUser, model.MessageKindBot:
+		if in.Nick == "" {
+			return nil, model.ErrBadRequest
+		}
+	case model.MessageKindSystem:
+		in.Nick = ""
+	default:
+		return nil, model.ErrBadRequest
+	}
+
 	message := &model.Message{
-		Nick:   in.Nick,
-		Text:   in.Text,
-		RoomID: in.RoomID,
+		Kind:        kind,
+		Nick:        in.Nick,
+		Text:        in.Text,
+		Attachments: in.Attachments,
+		RoomID:      in.RoomID,
 	}
 	err := s.messageRepo.Insert(ctx, message)
 	if err != nil {
 		return nil, err
 	}
+	s.history.add(message)
+	s.activity.Touch(message.RoomID)
+	metrics.MessagesCreated.WithLabelValues(kind).Inc()
 	return message, nil
 }
 
-// ListByRoom returns messages for a specific room with optional pagination by beforeID and limit.
-func (s *Service) ListByRoom(ctx context.Context, roomID int64, beforeID *int64, limit int) ([]model.Message, error) {
-	if limit < 0 || limit > 100 {
-		limit = 50
+// ListByRoom returns a page of room history in chronological order along with the cursors of the neighbouring pages.
+// A legacy beforeID continues with the messages older than it. The latest page is served from the history cache when the room is in it.
+func (s *Service) ListByRoom(ctx context.Context, in service.ListMessagesInput) (*service.MessagePage, error) {
+	if in.Limit <= 0 || in.Limit > 100 {
+		in.Limit = 50
+	}
+
+	var after *cursor.Cursor
+	switch {
+	case in.Cursor != "":
+		var err error
+		after, err = s.cursors.DecodeFor(in.Cursor, historyOrder)
+		if err != nil {
+			return nil, err
+		}
+	case in.BeforeID != nil:
+		after = &cursor.Cursor{Order: historyOrder, ID: *in.BeforeID}
+	}
+
+	var messages []model.Message
+	var err error
+	if after == nil {
+		messages, err = s.history.latest(ctx, in.RoomID, in.Limit+1, func(ctx context.Context, limit int) ([]model.Message, error) {
+			return s.messageRepo.ListByRoom(ctx, in.RoomID, nil, limit)
+		})
+	} else {
+		messages, err = s.messageRepo.ListByRoom(ctx, in.RoomID, after, in.Limit+1)
 	}
-	messages, err := s.messageRepo.ListByRoom(ctx, roomID, beforeID, limit)
 	if err != nil {
 		return nil, err
 	}
-	return messages, nil
+
+	page := &service.MessagePage{}
+	messages, page.NextCursor, page.PrevCursor = cursor.Page(messages, in.Limit, after, func(m model.Message) cursor.Cursor {
+		return cursor.Cursor{Order: historyOrder, ID: m.ID}
+	}, s.cursors)
+	slices.Reverse(messages)
+	page.Messages = messages
+
+	return page, nil
 }
 
 // GetByID returns a single message by its ID.
@@ -54,3 +118,40 @@ func (s *Service) GetByID(ctx context.Context, id int64) (*model.Message, error)
 	}
 	return message, nil
 }
+
+// PurgeRetention deletes messages that fall outside the retention policy of their room, batchSize rows at a time
+// so no single statement holds locks for long. It stops early when ctx is cancelled and returns the number of deleted messages.
+// The history cache is dropped when anything was deleted.
+func (s *Service) PurgeRetention(ctx context.Context, batchSize int) (int64, error) {
+	var total int64
+	defer func() {
+		if total > 0 {
+			s.history.invalidate()
+		}
+	}()
+	policies := []struct {
+		name  string
+		purge func(ctx context.Context, limit int) (int64, error)
+	}{
+		{name: "age", purge: s.messageRepo.DeleteExpired},
+		{name: "count", purge: s.messageRepo.DeleteOverflow},
+	}
+
+	for _, p := range policies {
+		for {
+			if err := ctx.Err(); err != nil {
+				return total, err
+			}
+			n, err := p.purge(ctx, batchSize)
+			if err != nil {
+				return total, err
+			}
+			metrics.MessagesPurged.WithLabelValues(p.name).Add(float64(n))
+			total += n
+			if n < int64(batchSize) {
+				break
+			}
+		}
+	}
+	return total, nil
+}
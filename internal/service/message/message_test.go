@@ -0,0 +1,246 @@
+package message
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/Rasulikus/chat/internal/repository/memory"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeActivity struct {
+	touched []int64
+}
+
+func (f *fakeActivity) Touch(roomID int64) {
+	f.touched = append(f.touched, roomID)
+}
+
+// countingRepo counts history reads and holds them while block is open.
+type countingRepo struct {
+	repository.MessageRepository
+	lists atomic.Int32
+	block chan struct{}
+}
+
+func (r *countingRepo) ListByRoom(ctx context.Context, roomID int64, after *cursor.Cursor, limit int) ([]model.Message, error) {
+	r.lists.Add(1)
+	if r.block != nil {
+		<-r.block
+	}
+	return r.MessageRepository.ListByRoom(ctx, roomID, after, limit)
+}
+
+type testSuite struct {
+	ctx      context.Context
+	store    *memory.Store
+	repo     *countingRepo
+	activity *fakeActivity
+	service  *Service
+	room     *model.Room
+}
+
+func setupTestSuite(t *testing.T, history *HistoryCache) *testSuite {
+	t.Helper()
+	cursors, err := cursor.NewCodec("secret")
+	require.NoError(t, err)
+
+	ts := &testSuite{
+		ctx:      context.Background(),
+		store:    memory.NewStore(),
+		activity: &fakeActivity{},
+		room:     &model.Room{Name: "general"},
+	}
+	require.NoError(t, memory.NewRoomRepository(ts.store).Insert(ts.ctx, ts.room))
+	ts.repo = &countingRepo{MessageRepository: memory.NewMessageRepository(ts.store)}
+	ts.service = NewService(ts.repo, ts.activity, cursors, history)
+	return ts
+}
+
+func (ts *testSuite) create(t *testing.T, roomID int64) *model.Message {
+	t.Helper()
+	message, err := ts.service.Create(ts.ctx, service.CreateMessageInput{Nick: "alice", Text: "hi", RoomID: roomID})
+	require.NoError(t, err)
+	return message
+}
+
+func pageIDs(page *service.MessagePage) []int64 {
+	var got []int64
+	for _, m := range page.Messages {
+		got = append(got, m.ID)
+	}
+	return got
+}
+
+func Test_Service_Create(t *testing.T) {
+	ts := setupTestSuite(t, nil)
+
+	testCases := []struct {
+		name    string
+		in      service.CreateMessageInput
+		wantErr error
+	}{
+		{name: "user", in: service.CreateMessageInput{Nick: "alice", Text: "hi", RoomID: ts.room.ID}},
+		{name: "system drops nick", in: service.CreateMessageInput{Kind: model.MessageKindSystem, Nick: "alice", Text: "topic changed", RoomID: ts.room.ID}},
+		{name: "user without nick", in: service.CreateMessageInput{Text: "hi", RoomID: ts.room.ID}, wantErr: model.ErrBadRequest},
+		{name: "unknown kind", in: service.CreateMessageInput{Kind: "robot", Nick: "r2", Text: "beep", RoomID: ts.room.ID}, wantErr: model.ErrBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts.activity.touched = nil
+			message, err := ts.service.Create(ts.ctx, tc.in)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				assert.Empty(t, ts.activity.touched)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotZero(t, message.ID)
+			if message.Kind == model.MessageKindSystem {
+				assert.Empty(t, message.Nick)
+			}
+			assert.Equal(t, []int64{ts.room.ID}, ts.activity.touched)
+		})
+	}
+}
+
+func Test_Service_ListByRoom(t *testing.T) {
+	ts := setupTestSuite(t, nil)
+	var ids []int64
+	for range 5 {
+		ids = append(ids, ts.create(t, ts.room.ID).ID)
+	}
+
+	latest, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, ids[3:], pageIDs(latest))
+	assert.NotEmpty(t, latest.NextCursor)
+	assert.Empty(t, latest.PrevCursor)
+
+	older, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, Limit: 2, Cursor: latest.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, ids[1:3], pageIDs(older))
+
+	newer, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, Limit: 2, Cursor: older.PrevCursor})
+	require.NoError(t, err)
+	assert.Equal(t, ids[3:], pageIDs(newer))
+
+	_, err = ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, Cursor: "forged"})
+	require.ErrorIs(t, err, model.ErrBadRequest)
+}
+
+func Test_Service_PurgeRetention(t *testing.T) {
+	ts := setupTestSuite(t, NewHistoryCache(10, 10, 0))
+	capped := &model.Room{Name: "capped", RetentionMaxMessages: 1}
+	require.NoError(t, memory.NewRoomRepository(ts.store).Insert(ts.ctx, capped))
+	for range 5 {
+		ts.create(t, capped.ID)
+	}
+	_, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: capped.ID})
+	require.NoError(t, err)
+
+	purged, err := ts.service.PurgeRetention(ts.ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), purged)
+
+	page, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: capped.ID})
+	require.NoError(t, err)
+	assert.Len(t, page.Messages, 1)
+}
+
+func Test_Service_ListByRoom_HistoryCache(t *testing.T) {
+	t.Run("latest page is read once", func(t *testing.T) {
+		ts := setupTestSuite(t, NewHistoryCache(10, 10, 0))
+		var ids []int64
+		for range 3 {
+			ids = append(ids, ts.create(t, ts.room.ID).ID)
+		}
+
+		for range 3 {
+			page, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, Limit: 2})
+			require.NoError(t, err)
+			assert.Equal(t, ids[1:], pageIDs(page))
+			assert.NotEmpty(t, page.NextCursor)
+		}
+		assert.EqualValues(t, 1, ts.repo.lists.Load())
+
+		ids = append(ids, ts.create(t, ts.room.ID).ID)
+		page, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID})
+		require.NoError(t, err)
+		assert.Equal(t, ids, pageIDs(page))
+		assert.Empty(t, page.NextCursor)
+		assert.EqualValues(t, 1, ts.repo.lists.Load())
+
+		_, err = ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, BeforeID: &ids[1]})
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, ts.repo.lists.Load())
+	})
+
+	t.Run("only the newest messages are kept", func(t *testing.T) {
+		ts := setupTestSuite(t, NewHistoryCache(10, 3, 0))
+		var ids []int64
+		for range 5 {
+			ids = append(ids, ts.create(t, ts.room.ID).ID)
+		}
+
+		page, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, ids[3:], pageIDs(page))
+		ids = append(ids, ts.create(t, ts.room.ID).ID)
+
+		page, err = ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, ids[4:], pageIDs(page))
+		assert.NotEmpty(t, page.NextCursor)
+		assert.EqualValues(t, 1, ts.repo.lists.Load())
+
+		page, err = ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID, Limit: 5})
+		require.NoError(t, err)
+		assert.Equal(t, ids[1:], pageIDs(page))
+		assert.EqualValues(t, 2, ts.repo.lists.Load())
+	})
+
+	t.Run("least recently read room is evicted", func(t *testing.T) {
+		ts := setupTestSuite(t, NewHistoryCache(1, 10, 0))
+		other := &model.Room{Name: "other"}
+		require.NoError(t, memory.NewRoomRepository(ts.store).Insert(ts.ctx, other))
+
+		for _, roomID := range []int64{ts.room.ID, ts.room.ID, other.ID, ts.room.ID} {
+			_, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: roomID})
+			require.NoError(t, err)
+		}
+		assert.EqualValues(t, 3, ts.repo.lists.Load())
+	})
+
+	t.Run("concurrent misses share one read", func(t *testing.T) {
+		ts := setupTestSuite(t, NewHistoryCache(10, 10, 0))
+		message := ts.create(t, ts.room.ID)
+		ts.repo.block = make(chan struct{})
+
+		var wg sync.WaitGroup
+		read := func() {
+			defer wg.Done()
+			page, err := ts.service.ListByRoom(ts.ctx, service.ListMessagesInput{RoomID: ts.room.ID})
+			assert.NoError(t, err)
+			assert.Equal(t, []int64{message.ID}, pageIDs(page))
+		}
+		wg.Add(1)
+		go read()
+		require.Eventually(t, func() bool { return ts.repo.lists.Load() == 1 }, time.Second, time.Millisecond)
+		for range 5 {
+			wg.Add(1)
+			go read()
+		}
+		close(ts.repo.block)
+		wg.Wait()
+		assert.EqualValues(t, 1, ts.repo.lists.Load())
+	})
+}
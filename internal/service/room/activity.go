@@ -0,0 +1,80 @@
+package room
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/Rasulikus/chat/internal/service"
+)
+
+var _ service.ActivityRecorder = (*ActivityRecorder)(nil)
+
+// ActivityRecorder coalesces room activity in memory and writes it with one batched UPDATE per flush,
+// so busy rooms cost one write per interval instead of one per message.
+type ActivityRecorder struct {
+	roomRepo repository.RoomRepository
+
+	mu      sync.Mutex
+	pending map[int64]time.Time
+}
+
+func NewActivityRecorder(roomRepo repository.RoomRepository) *ActivityRecorder {
+	return &ActivityRecorder{
+		roomRepo: roomRepo,
+		pending:  make(map[int64]time.Time),
+	}
+}
+
+// Touch records activity in a room now; it is written on the next flush.
+func (a *ActivityRecorder) Touch(roomID int64) {
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.pending[roomID] = now
+}
+
+// Flush writes the recorded activity. On failure the activity is kept and written by the next flush.
+func (a *ActivityRecorder) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	batch := a.pending
+	a.pending = make(map[int64]time.Time, len(batch))
+	a.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	if _, err := a.roomRepo.TouchActivityBatch(ctx, batch); err != nil {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		for id, at := range batch {
+			if at.After(a.pending[id]) {
+				a.pending[id] = at
+			}
+		}
+		return err
+	}
+	return nil
+}
+
+// Run flushes the recorded activity every interval until ctx is cancelled.
+// Activity recorded after that is written by a final Flush when the server shuts down.
+func (a *ActivityRecorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Flush(ctx); err != nil {
+				logging.FromContext(ctx).Error("room activity: flush", "err", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package room
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRoomRepo records the activity batches written to it and fails while err is set.
+type fakeRoomRepo struct {
+	repository.RoomRepository
+
+	batches []map[int64]time.Time
+	err     error
+}
+
+func (r *fakeRoomRepo) TouchActivityBatch(_ context.Context, activity map[int64]time.Time) (int64, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	r.batches = append(r.batches, activity)
+	return int64(len(activity)), nil
+}
+
+func Test_ActivityRecorder(t *testing.T) {
+	repo := &fakeRoomRepo{}
+	a := NewActivityRecorder(repo)
+
+	t.Run("coalesces touches per room", func(t *testing.T) {
+		a.Touch(1)
+		a.Touch(2)
+		before := time.Now()
+		a.Touch(1)
+
+		require.NoError(t, a.Flush(t.Context()))
+		require.Len(t, repo.batches, 1)
+		assert.Len(t, repo.batches[0], 2)
+		assert.False(t, repo.batches[0][1].Before(before), "the latest touch wins")
+	})
+
+	t.Run("empty flush writes nothing", func(t *testing.T) {
+		require.NoError(t, a.Flush(t.Context()))
+		assert.Len(t, repo.batches, 1)
+	})
+
+	t.Run("failed flush is retried by the next one", func(t *testing.T) {
+		a.Touch(3)
+		repo.err = errors.New("db down")
+		assert.Error(t, a.Flush(t.Context()))
+
+		a.Touch(4)
+		repo.err = nil
+		require.NoError(t, a.Flush(t.Context()))
+		require.Len(t, repo.batches, 2)
+		assert.Contains(t, repo.batches[1], int64(3))
+		assert.Contains(t, repo.batches[1], int64(4))
+	})
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"time"
 
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/logging"
 	"github.com/Rasulikus/chat/internal/model"
 	"github.com/Rasulikus/chat/internal/repository"
 	"github.com/Rasulikus/chat/internal/service"
@@ -14,12 +16,21 @@ import (
 var _ service.RoomService = (*Service)(nil)
 
 type Service struct {
-	roomRepo repository.RoomRepository
+	roomRepo       repository.RoomRepository
+	tx             repository.Transactor
+	webhookService service.WebhookService
+	cursors        *cursor.Codec
+	// restoreWindow is how long a soft-deleted room can be restored before it is purged.
+	restoreWindow time.Duration
 }
 
-func NewService(roomRepo repository.RoomRepository) *Service {
+func NewService(roomRepo repository.RoomRepository, tx repository.Transactor, webhookService service.WebhookService, cursors *cursor.Codec, restoreWindow time.Duration) *Service {
 	return &Service{
-		roomRepo: roomRepo,
+		roomRepo:       roomRepo,
+		tx:             tx,
+		webhookService: webhookService,
+		cursors:        cursors,
+		restoreWindow:  restoreWindow,
 	}
 }
 
@@ -40,6 +51,13 @@ func (s *Service) Create(ctx context.Context, in service.CreateRoomInput) (*mode
 		Name:         in.Name,
 		PasswordHash: hashedPassword,
 		HasPassword:  hasPassword,
+		Topic:        in.Topic,
+		Description:  in.Description,
+		AvatarURL:    in.AvatarURL,
+		Metadata:     in.Metadata,
+
+		RetentionDays:        in.RetentionDays,
+		RetentionMaxMessages: in.RetentionMaxMessages,
 	}
 
 	err = s.roomRepo.Insert(ctx, room)
@@ -49,23 +67,80 @@ func (s *Service) Create(ctx context.Context, in service.CreateRoomInput) (*mode
 	return room, nil
 }
 
-// List returns a list of rooms with optional pagination and ordering, and marks which rooms are password protected.
-func (s *Service) List(ctx context.Context, limit int, order string, beforeID *int64) ([]model.Room, error) {
-	if limit == 0 {
-		limit = 20
+// List returns a page of rooms matching the filter, marks which rooms are password protected,
+// and returns the cursors of the neighbouring pages along with the total count if requested.
+func (s *Service) List(ctx context.Context, in service.ListRoomsInput) (*service.RoomPage, error) {
+	if in.Limit == 0 {
+		in.Limit = 20
 	}
-	rooms, err := s.roomRepo.List(ctx, limit, order, beforeID)
+	order, err := model.ParseRoomOrder(in.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := s.listCursor(ctx, order, in)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms, err := s.roomRepo.List(ctx, in.Filter, order, after, in.Limit+1)
 	if err != nil {
 		return nil, err
 	}
 
+	page := &service.RoomPage{}
+	rooms, page.NextCursor, page.PrevCursor = cursor.Page(rooms, in.Limit, after, func(room model.Room) cursor.Cursor {
+		return roomCursor(order, &room)
+	}, s.cursors)
 	for i := 0; i < len(rooms); i++ {
 		if rooms[i].PasswordHash != nil {
 			rooms[i].HasPassword = true
 		}
 	}
+	page.Rooms = rooms
+
+	if in.WithTotal {
+		total, err := s.roomRepo.Count(ctx, in.Filter)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+// listCursor resolves where a listing starts: a cursor must belong to the same ordering,
+// and a legacy before_id is turned into the cursor of that room.
+func (s *Service) listCursor(ctx context.Context, order model.RoomOrder, in service.ListRoomsInput) (*cursor.Cursor, error) {
+	if in.Cursor != "" {
+		return s.cursors.DecodeFor(in.Cursor, order.String())
+	}
+
+	if in.BeforeID == nil {
+		return nil, nil
+	}
+	room, err := s.roomRepo.GetByID(ctx, *in.BeforeID)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil, model.ErrBadRequest
+		}
+		return nil, err
+	}
+	after := roomCursor(order, room)
+	return &after, nil
+}
 
-	return rooms, nil
+// roomCursor returns the position of room in a listing with the given order.
+func roomCursor(order model.RoomOrder, room *model.Room) cursor.Cursor {
+	c := cursor.Cursor{Order: order.String(), ID: room.ID}
+	switch order.Column {
+	case model.RoomOrderCreatedAt:
+		c.Key = room.CreatedAt
+	case model.RoomOrderLastActiveAt:
+		c.Key = room.LastActiveAt
+	}
+	return c
 }
 
 // GetByID returns a room by its ID and indicates whether it is password protected.
@@ -82,6 +157,54 @@ func (s *Service) GetByID(ctx context.Context, id int64) (*model.Room, error) {
 	return room, nil
 }
 
+// Update applies a partial update to a room, re-hashing the password if it changes.
+func (s *Service) Update(ctx context.Context, in service.UpdateRoomInput) (*model.Room, error) {
+	room, err := s.roomRepo.GetByID(ctx, in.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.Name != nil {
+		room.Name = *in.Name
+	}
+	if in.Password != nil {
+		room.PasswordHash = nil
+		if *in.Password != "" {
+			room.PasswordHash, err = bcrypt.GenerateFromPassword([]byte(*in.Password), bcrypt.DefaultCost)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if in.Topic != nil {
+		room.Topic = *in.Topic
+	}
+	if in.Description != nil {
+		room.Description = *in.Description
+	}
+	if in.AvatarURL != nil {
+		room.AvatarURL = *in.AvatarURL
+	}
+	if in.Metadata != nil {
+		room.Metadata = in.Metadata
+	}
+	if in.RetentionDays != nil {
+		room.RetentionDays = *in.RetentionDays
+	}
+	if in.RetentionMaxMessages != nil {
+		room.RetentionMaxMessages = *in.RetentionMaxMessages
+	}
+	if room.Metadata == nil {
+		room.Metadata = map[string]any{}
+	}
+
+	if err = s.roomRepo.Update(ctx, room); err != nil {
+		return nil, err
+	}
+	room.HasPassword = room.PasswordHash != nil
+	return room, nil
+}
+
 // TouchActivity updates the activity timestamp of a room by its ID.
 func (s *Service) TouchActivity(ctx context.Context, roomID int64) error {
 	return s.roomRepo.TouchActivity(ctx, roomID)
@@ -93,15 +216,88 @@ func (s *Service) SoftDeleteInactiveOlderThan(ctx context.Context, olderThan tim
 	return s.roomRepo.SoftDeleteInactiveOlderThan(ctx, olderThan)
 }
 
-// SoftDelete performs a soft delete of a room by its ID.
+// SoftDelete performs a soft delete of a room by its ID and notifies the room webhooks.
 func (s *Service) SoftDelete(ctx context.Context, id int64) error {
 	err := s.roomRepo.SoftDelete(ctx, id)
 	if err != nil {
 		return err
 	}
+
+	err = s.webhookService.Dispatch(ctx, model.WebhookEvent{
+		Event:  model.WebhookEventRoomDeleted,
+		RoomID: id,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("room service: webhook Dispatch", "err", err, "room_id", id)
+	}
 	return nil
 }
 
+// Restore undoes the soft delete of a room within the restore window. Protected rooms require their password;
+// rooms without one have nothing proving the caller's claim to them and return model.ErrForbidden, leaving them to ForceRestore.
+func (s *Service) Restore(ctx context.Context, id int64, password string) (*model.Room, error) {
+	return s.restore(ctx, id, &password)
+}
+
+// ForceRestore undoes the soft delete of a room within the restore window without checking its password.
+func (s *Service) ForceRestore(ctx context.Context, id int64) (*model.Room, error) {
+	return s.restore(ctx, id, nil)
+}
+
+// restore checks the password only when one is given, and notifies the room webhooks.
+// The deleted room is read locked, so the window and password checks, the restore and the read of the restored
+// room run in one transaction that a concurrent purge or restore waits for. Webhooks are notified after it commits,
+// since a transaction that is retried must not notify them twice.
+func (s *Service) restore(ctx context.Context, id int64, password *string) (*model.Room, error) {
+	var room *model.Room
+	err := s.tx.InTx(ctx, func(ctx context.Context) error {
+		deleted, err := s.roomRepo.GetDeletedByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if time.Since(deleted.DeletedAt) > s.restoreWindow {
+			return model.ErrNotFound
+		}
+		if password != nil && deleted.PasswordHash == nil {
+			return model.ErrForbidden
+		}
+		if password != nil {
+			err = bcrypt.CompareHashAndPassword(deleted.PasswordHash, []byte(*password))
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return model.ErrWrongPassword
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = s.roomRepo.Restore(ctx, id); err != nil {
+			return err
+		}
+		room, err = s.GetByID(ctx, id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.webhookService.Dispatch(ctx, model.WebhookEvent{
+		Event:  model.WebhookEventRoomRestored,
+		RoomID: id,
+		Room:   room,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("room service: webhook Dispatch", "err", err, "room_id", id)
+	}
+	return room, nil
+}
+
+// PurgeDeleted permanently deletes rooms soft deleted longer than olderThan, together with their messages.
+// It returns the number of purged rooms.
+func (s *Service) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return s.roomRepo.HardDeleteDeletedOlderThan(ctx, olderThan)
+}
+
 // CheckPassword verifies a plain-text password against the stored room password hash and returns whether they match.
 func (s *Service) CheckPassword(ctx context.Context, id int64, password string) (bool, error) {
 	room, err := s.roomRepo.GetByID(ctx, id)
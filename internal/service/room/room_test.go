@@ -0,0 +1,311 @@
+package room
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository/memory"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWebhooks records the events dispatched to room webhooks.
+type fakeWebhooks struct {
+	service.WebhookService
+	events []model.WebhookEvent
+}
+
+func (f *fakeWebhooks) Dispatch(_ context.Context, event model.WebhookEvent) error {
+	f.events = append(f.events, event)
+	return nil
+}
+
+type testSuite struct {
+	ctx      context.Context
+	repo     *memory.RoomRepository
+	webhooks *fakeWebhooks
+	service  *Service
+}
+
+func setupTestSuite(t *testing.T) *testSuite {
+	t.Helper()
+	cursors, err := cursor.NewCodec("secret")
+	require.NoError(t, err)
+
+	ts := &testSuite{
+		ctx:      context.Background(),
+		repo:     memory.NewRoomRepository(memory.NewStore()),
+		webhooks: &fakeWebhooks{},
+	}
+	ts.service = NewService(ts.repo, memory.NewTransactor(), ts.webhooks, cursors, time.Hour)
+	return ts
+}
+
+// deleted creates a room and soft deletes it.
+func (ts *testSuite) deleted(t *testing.T, in service.CreateRoomInput) *model.Room {
+	t.Helper()
+	room, err := ts.service.Create(ts.ctx, in)
+	require.NoError(t, err)
+	require.NoError(t, ts.service.SoftDelete(ts.ctx, room.ID))
+	return room
+}
+
+func Test_Service_Restore(t *testing.T) {
+	testCases := []struct {
+		name     string
+		password string
+		given    string
+		wantErr  error
+	}{
+		{name: "right password", password: "secret", given: "secret"},
+		{name: "wrong password", password: "secret", given: "guess", wantErr: model.ErrWrongPassword},
+		{name: "no password", wantErr: model.ErrForbidden},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := setupTestSuite(t)
+			room := ts.deleted(t, service.CreateRoomInput{Name: "general", Password: tc.password})
+
+			restored, err := ts.service.Restore(ts.ctx, room.ID, tc.given)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				_, err = ts.service.GetByID(ts.ctx, room.ID)
+				assert.ErrorIs(t, err, model.ErrNotFound)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, room.ID, restored.ID)
+			assert.True(t, restored.HasPassword)
+		})
+	}
+}
+
+func Test_Service_ForceRestore(t *testing.T) {
+	for _, password := range []string{"", "secret"} {
+		ts := setupTestSuite(t)
+		room := ts.deleted(t, service.CreateRoomInput{Name: "general", Password: password})
+
+		restored, err := ts.service.ForceRestore(ts.ctx, room.ID)
+		require.NoError(t, err)
+		assert.Equal(t, room.ID, restored.ID)
+	}
+}
+
+func Test_Service_RestoreDispatchesEvent(t *testing.T) {
+	ts := setupTestSuite(t)
+	room := ts.deleted(t, service.CreateRoomInput{Name: "general"})
+
+	_, err := ts.service.ForceRestore(ts.ctx, room.ID)
+	require.NoError(t, err)
+
+	require.Len(t, ts.webhooks.events, 2)
+	assert.Equal(t, model.WebhookEventRoomDeleted, ts.webhooks.events[0].Event)
+	restored := ts.webhooks.events[1]
+	assert.Equal(t, model.WebhookEventRoomRestored, restored.Event)
+	assert.Equal(t, room.ID, restored.RoomID)
+	require.NotNil(t, restored.Room)
+	assert.Equal(t, "general", restored.Room.Name)
+}
+
+func Test_Service_RestoreAfterWindow(t *testing.T) {
+	ts := setupTestSuite(t)
+	ts.service.restoreWindow = 0
+	room := ts.deleted(t, service.CreateRoomInput{Name: "general"})
+
+	_, err := ts.service.ForceRestore(ts.ctx, room.ID)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_Service_Create(t *testing.T) {
+	ts := setupTestSuite(t)
+
+	t.Run("with password", func(t *testing.T) {
+		room, err := ts.service.Create(ts.ctx, service.CreateRoomInput{Name: "private", Password: "secret", Topic: "plans"})
+		require.NoError(t, err)
+		assert.NotZero(t, room.ID)
+		assert.True(t, room.HasPassword)
+		assert.NotEqual(t, []byte("secret"), room.PasswordHash)
+
+		stored, err := ts.repo.GetByID(ts.ctx, room.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "private", stored.Name)
+		assert.Equal(t, "plans", stored.Topic)
+		assert.Equal(t, room.PasswordHash, stored.PasswordHash)
+	})
+
+	t.Run("without password", func(t *testing.T) {
+		room, err := ts.service.Create(ts.ctx, service.CreateRoomInput{Name: "general"})
+		require.NoError(t, err)
+		assert.False(t, room.HasPassword)
+		assert.Nil(t, room.PasswordHash)
+	})
+}
+
+func Test_Service_GetByID(t *testing.T) {
+	ts := setupTestSuite(t)
+	protected, err := ts.service.Create(ts.ctx, service.CreateRoomInput{Name: "private", Password: "secret"})
+	require.NoError(t, err)
+	open, err := ts.service.Create(ts.ctx, service.CreateRoomInput{Name: "general"})
+	require.NoError(t, err)
+
+	room, err := ts.service.GetByID(ts.ctx, protected.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "private", room.Name)
+	assert.True(t, room.HasPassword)
+
+	room, err = ts.service.GetByID(ts.ctx, open.ID)
+	require.NoError(t, err)
+	assert.False(t, room.HasPassword)
+
+	_, err = ts.service.GetByID(ts.ctx, open.ID+100)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	deleted := ts.deleted(t, service.CreateRoomInput{Name: "gone"})
+	_, err = ts.service.GetByID(ts.ctx, deleted.ID)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func Test_Service_Update(t *testing.T) {
+	name, password, empty, topic := "renamed", "changed", "", "release day"
+
+	testCases := []struct {
+		name         string
+		in           service.UpdateRoomInput
+		wantName     string
+		wantTopic    string
+		wantPassword string
+	}{
+		{name: "rename", in: service.UpdateRoomInput{Name: &name}, wantName: "renamed", wantTopic: "plans", wantPassword: "secret"},
+		{name: "change topic", in: service.UpdateRoomInput{Topic: &topic}, wantName: "general", wantTopic: "release day", wantPassword: "secret"},
+		{name: "change password", in: service.UpdateRoomInput{Password: &password}, wantName: "general", wantTopic: "plans", wantPassword: "changed"},
+		{name: "remove password", in: service.UpdateRoomInput{Password: &empty}, wantName: "general", wantTopic: "plans"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := setupTestSuite(t)
+			room, err := ts.service.Create(ts.ctx, service.CreateRoomInput{Name: "general", Password: "secret", Topic: "plans"})
+			require.NoError(t, err)
+
+			tc.in.ID = room.ID
+			updated, err := ts.service.Update(ts.ctx, tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantName, updated.Name)
+			assert.Equal(t, tc.wantTopic, updated.Topic)
+			assert.Equal(t, tc.wantPassword != "", updated.HasPassword)
+			assert.NotNil(t, updated.Metadata)
+
+			stored, err := ts.service.GetByID(ts.ctx, room.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantName, stored.Name)
+			assert.Equal(t, tc.wantTopic, stored.Topic)
+			ok, err := ts.service.CheckPassword(ts.ctx, room.ID, tc.wantPassword)
+			require.NoError(t, err)
+			assert.True(t, ok)
+		})
+	}
+
+	t.Run("unknown room", func(t *testing.T) {
+		ts := setupTestSuite(t)
+		_, err := ts.service.Update(ts.ctx, service.UpdateRoomInput{ID: 1, Name: &name})
+		assert.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
+
+func Test_Service_List(t *testing.T) {
+	ts := setupTestSuite(t)
+	var ids []int64
+	for _, in := range []service.CreateRoomInput{
+		{Name: "alpha"},
+		{Name: "beta", Password: "secret"},
+		{Name: "gamma"},
+		{Name: "delta"},
+		{Name: "epsilon"},
+	} {
+		room, err := ts.service.Create(ts.ctx, in)
+		require.NoError(t, err)
+		ids = append(ids, room.ID)
+	}
+	ts.deleted(t, service.CreateRoomInput{Name: "zeta"})
+
+	roomIDs := func(rooms []model.Room) []int64 {
+		var out []int64
+		for _, r := range rooms {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+
+	first, err := ts.service.List(ts.ctx, service.ListRoomsInput{Limit: 2, Order: "id asc", WithTotal: true})
+	require.NoError(t, err)
+	assert.Equal(t, ids[:2], roomIDs(first.Rooms))
+	assert.False(t, first.Rooms[0].HasPassword)
+	assert.True(t, first.Rooms[1].HasPassword)
+	assert.Empty(t, first.PrevCursor)
+	require.NotEmpty(t, first.NextCursor)
+	require.NotNil(t, first.Total)
+	assert.EqualValues(t, 5, *first.Total)
+
+	second, err := ts.service.List(ts.ctx, service.ListRoomsInput{Limit: 2, Order: "id asc", Cursor: first.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, ids[2:4], roomIDs(second.Rooms))
+	assert.NotEmpty(t, second.PrevCursor)
+	assert.Nil(t, second.Total)
+
+	last, err := ts.service.List(ts.ctx, service.ListRoomsInput{Limit: 2, Order: "id asc", Cursor: second.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, ids[4:], roomIDs(last.Rooms))
+	assert.Empty(t, last.NextCursor)
+
+	t.Run("legacy before_id", func(t *testing.T) {
+		page, err := ts.service.List(ts.ctx, service.ListRoomsInput{Order: "id asc", BeforeID: &ids[2]})
+		require.NoError(t, err)
+		assert.Equal(t, ids[3:], roomIDs(page.Rooms))
+	})
+
+	t.Run("cursor of another order", func(t *testing.T) {
+		_, err := ts.service.List(ts.ctx, service.ListRoomsInput{Order: "id desc", Cursor: first.NextCursor})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown order", func(t *testing.T) {
+		_, err := ts.service.List(ts.ctx, service.ListRoomsInput{Order: "name"})
+		assert.ErrorIs(t, err, model.ErrBadRequest)
+	})
+}
+
+func Test_Service_CheckPassword(t *testing.T) {
+	ts := setupTestSuite(t)
+	protected, err := ts.service.Create(ts.ctx, service.CreateRoomInput{Name: "private", Password: "secret"})
+	require.NoError(t, err)
+	open, err := ts.service.Create(ts.ctx, service.CreateRoomInput{Name: "general"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		roomID   int64
+		password string
+		wantOK   bool
+		wantErr  error
+	}{
+		{name: "right password", roomID: protected.ID, password: "secret", wantOK: true},
+		{name: "wrong password", roomID: protected.ID, password: "guess"},
+		{name: "missing password", roomID: protected.ID},
+		{name: "room without password", roomID: open.ID, password: "anything", wantOK: true},
+		{name: "unknown room", roomID: open.ID + 100, wantErr: model.ErrNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ok, err := ts.service.CheckPassword(ts.ctx, tc.roomID, tc.password)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantOK, ok)
+		})
+	}
+}
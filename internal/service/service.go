@@ -8,34 +8,151 @@ import (
 )
 
 type CreateRoomInput struct {
-	Name     string
-	Password string
+	Name        string
+	Password    string
+	Topic       string
+	Description string
+	AvatarURL   string
+	Metadata    map[string]any
+	// RetentionDays and RetentionMaxMessages of zero keep messages forever.
+	RetentionDays        int
+	RetentionMaxMessages int
 }
 
+// UpdateRoomInput describes a partial room update; nil fields are left unchanged.
+// An empty Password removes the password.
 type UpdateRoomInput struct {
-	ID       int64
-	Name     *string
-	Password *string
+	ID          int64
+	Name        *string
+	Password    *string
+	Topic       *string
+	Description *string
+	AvatarURL   *string
+	Metadata    map[string]any
+	// RetentionDays and RetentionMaxMessages set to zero remove the limit.
+	RetentionDays        *int
+	RetentionMaxMessages *int
+}
+
+// ListRoomsInput selects a page of rooms. Cursor continues a previous listing in the same Order;
+// BeforeID is the legacy form of a cursor and continues after the room with that ID.
+type ListRoomsInput struct {
+	Limit     int
+	Order     string
+	Cursor    string
+	BeforeID  *int64
+	Filter    model.RoomFilter
+	WithTotal bool
+}
+
+// RoomPage is one page of a room listing. NextCursor is empty on the last page, PrevCursor on the first one,
+// and Total is only set when requested.
+type RoomPage struct {
+	Rooms      []model.Room
+	NextCursor string
+	PrevCursor string
+	Total      *int
 }
 
 type RoomService interface {
 	Create(ctx context.Context, in CreateRoomInput) (*model.Room, error)
 	GetByID(ctx context.Context, id int64) (*model.Room, error)
-	List(ctx context.Context, limit int, order string, beforeID *int64) ([]model.Room, error)
+	List(ctx context.Context, in ListRoomsInput) (*RoomPage, error)
+	Update(ctx context.Context, in UpdateRoomInput) (*model.Room, error)
 	TouchActivity(ctx context.Context, id int64) error
 	SoftDeleteInactiveOlderThan(ctx context.Context, olderThan time.Duration) (int64, error)
 	SoftDelete(ctx context.Context, id int64) error
+	Restore(ctx context.Context, id int64, password string) (*model.Room, error)
+	ForceRestore(ctx context.Context, id int64) (*model.Room, error)
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
 	CheckPassword(ctx context.Context, id int64, password string) (bool, error)
 }
 
 type CreateMessageInput struct {
 	RoomID int64
-	Nick   string
-	Text   string
+	// Kind defaults to model.MessageKindUser.
+	Kind        string
+	Nick        string
+	Text        string
+	Attachments []model.Attachment
+}
+
+// ListMessagesInput selects a page of room history, from the newest message unless Cursor
+// or the legacy BeforeID continues an earlier page.
+type ListMessagesInput struct {
+	RoomID   int64
+	Limit    int
+	Cursor   string
+	BeforeID *int64
+}
+
+// MessagePage is one page of room history in chronological order.
+// NextCursor leads to older messages and PrevCursor to newer ones; each is empty at its end of the history.
+type MessagePage struct {
+	Messages   []model.Message
+	NextCursor string
+	PrevCursor string
+}
+
+// ActivityRecorder records room activity that is written to the database later, in batches.
+type ActivityRecorder interface {
+	Touch(roomID int64)
 }
 
 type MessageService interface {
 	Create(ctx context.Context, in CreateMessageInput) (*model.Message, error)
 	GetByID(ctx context.Context, id int64) (*model.Message, error)
-	ListByRoom(ctx context.Context, roomID int64, beforeID *int64, limit int) ([]model.Message, error)
+	ListByRoom(ctx context.Context, in ListMessagesInput) (*MessagePage, error)
+	PurgeRetention(ctx context.Context, batchSize int) (int64, error)
+}
+
+type CreateWebhookInput struct {
+	RoomID int64
+	URL    string
+	Events []string
+}
+
+type WebhookService interface {
+	Create(ctx context.Context, in CreateWebhookInput) (*model.Webhook, error)
+	ListByRoom(ctx context.Context, roomID int64) ([]model.Webhook, error)
+	Delete(ctx context.Context, roomID, id int64) error
+	Dispatch(ctx context.Context, event model.WebhookEvent) error
+}
+
+type CreateIncomingWebhookInput struct {
+	RoomID int64
+	Name   string
+}
+
+type PostIncomingWebhookInput struct {
+	Token       string
+	Nick        string
+	Text        string
+	Attachments []model.Attachment
+}
+
+type IncomingWebhookService interface {
+	Create(ctx context.Context, in CreateIncomingWebhookInput) (*model.IncomingWebhook, error)
+	ListByRoom(ctx context.Context, roomID int64) ([]model.IncomingWebhook, error)
+	Delete(ctx context.Context, roomID, id int64) error
+	Post(ctx context.Context, in PostIncomingWebhookInput) (*model.Message, error)
+}
+
+type CreateBotInput struct {
+	Name        string
+	CallbackURL string
+}
+
+type BotCommandInput struct {
+	Name        string
+	Description string
+}
+
+type BotService interface {
+	Create(ctx context.Context, in CreateBotInput) (*model.Bot, error)
+	Authenticate(ctx context.Context, apiKey string) (*model.Bot, error)
+	SetCommands(ctx context.Context, botID int64, commands []BotCommandInput) ([]model.BotCommand, error)
+	ListCommands(ctx context.Context, botID *int64) ([]model.BotCommand, error)
+	FindCommand(ctx context.Context, name string) (*model.BotCommand, error)
+	Callback(ctx context.Context, bot *model.Bot, inv model.CommandInvocation) (*model.CommandReply, error)
 }
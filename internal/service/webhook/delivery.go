@@ -0,0 +1,178 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/egress"
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+)
+
+const (
+	HeaderEvent     = "X-Chat-Event"
+	HeaderDelivery  = "X-Chat-Delivery"
+	HeaderTimestamp = "X-Chat-Timestamp"
+	HeaderSignature = "X-Chat-Signature"
+)
+
+type DelivererOptions struct {
+	// BatchSize is the maximum number of deliveries claimed per poll.
+	BatchSize int
+	// PollInterval is how often the queue is checked for due deliveries.
+	PollInterval time.Duration
+	// Timeout bounds a single HTTP attempt.
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts after which a delivery is dead-lettered.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry; it doubles on every further attempt.
+	BaseBackoff time.Duration
+	// MaxBackoff caps the delay between attempts.
+	MaxBackoff time.Duration
+}
+
+func DefaultDelivererOptions() DelivererOptions {
+	return DelivererOptions{
+		BatchSize:    20,
+		PollInterval: time.Second,
+		Timeout:      10 * time.Second,
+		MaxAttempts:  8,
+		BaseBackoff:  10 * time.Second,
+		MaxBackoff:   time.Hour,
+	}
+}
+
+// Deliverer drains the webhook delivery queue and POSTs signed payloads to subscribers.
+type Deliverer struct {
+	repo   repository.WebhookRepository
+	client *http.Client
+	opts   DelivererOptions
+}
+
+// NewDeliverer returns a deliverer whose requests cannot reach loopback, private, link-local or metadata addresses,
+// whatever the webhook URL resolves to at the time of the attempt.
+func NewDeliverer(repo repository.WebhookRepository, opts DelivererOptions) *Deliverer {
+	return &Deliverer{
+		repo:   repo,
+		client: egress.Client(opts.Timeout),
+		opts:   opts,
+	}
+}
+
+// Run polls the delivery queue until ctx is cancelled.
+func (d *Deliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := d.DeliverDue(ctx); err != nil {
+				logging.FromContext(ctx).Error("webhook deliverer", "err", err)
+			}
+		}
+	}
+}
+
+// DeliverDue claims one batch of due deliveries, attempts them concurrently, and records the outcome.
+// It returns the number of deliveries attempted.
+func (d *Deliverer) DeliverDue(ctx context.Context) (int, error) {
+	// The lease only has to outlive one attempt because the batch is sent concurrently.
+	deliveries, err := d.repo.ClaimDueDeliveries(ctx, d.opts.BatchSize, 2*d.opts.Timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for i := range deliveries {
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery) {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}(&deliveries[i])
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *Deliverer) deliver(ctx context.Context, delivery *model.WebhookDelivery) {
+	err := d.send(ctx, delivery)
+	if err == nil {
+		if err = d.repo.MarkDelivered(ctx, delivery.ID); err != nil {
+			logging.FromContext(ctx).Error("webhook deliverer: mark delivered", "err", err, "delivery_id", delivery.ID)
+		}
+		return
+	}
+
+	dead := delivery.Attempts >= d.opts.MaxAttempts
+	if err = d.repo.MarkFailed(ctx, delivery.ID, err.Error(), d.backoff(delivery.Attempts), dead); err != nil {
+		logging.FromContext(ctx).Error("webhook deliverer: mark failed", "err", err, "delivery_id", delivery.ID)
+	}
+	if dead {
+		logging.FromContext(ctx).Warn("webhook deliverer: delivery dead", "delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "attempts", delivery.Attempts)
+	}
+}
+
+func (d *Deliverer) send(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if delivery.Webhook == nil {
+		return fmt.Errorf("webhook %d not found", delivery.WebhookID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Webhook.Secret, ts, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns the delay before the next attempt, doubling from BaseBackoff up to MaxBackoff.
+func (d *Deliverer) backoff(attempts int) time.Duration {
+	delay := d.opts.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= d.opts.MaxBackoff {
+			return d.opts.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Sign computes the value of the X-Chat-Signature header: an HMAC-SHA256 over "<timestamp>.<body>".
+// Receivers recompute it with their webhook secret to authenticate the request.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
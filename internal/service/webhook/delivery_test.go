@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRepo is an in-memory delivery queue that records how each delivery ended.
+type fakeRepo struct {
+	repository.WebhookRepository
+
+	mu        sync.Mutex
+	due       []model.WebhookDelivery
+	delivered []int64
+	failed    map[int64]string
+	dead      map[int64]bool
+	retryIn   map[int64]time.Duration
+}
+
+func newFakeRepo(deliveries ...model.WebhookDelivery) *fakeRepo {
+	return &fakeRepo{
+		due:     deliveries,
+		failed:  make(map[int64]string),
+		dead:    make(map[int64]bool),
+		retryIn: make(map[int64]time.Duration),
+	}
+}
+
+func (r *fakeRepo) ClaimDueDeliveries(_ context.Context, limit int, _ time.Duration) ([]model.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := min(limit, len(r.due))
+	claimed := r.due[:n]
+	r.due = r.due[n:]
+	for i := range claimed {
+		claimed[i].Attempts++
+	}
+	return claimed, nil
+}
+
+func (r *fakeRepo) MarkDelivered(_ context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delivered = append(r.delivered, id)
+	return nil
+}
+
+func (r *fakeRepo) MarkFailed(_ context.Context, id int64, lastErr string, retryIn time.Duration, dead bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failed[id] = lastErr
+	r.dead[id] = dead
+	r.retryIn[id] = retryIn
+	return nil
+}
+
+// newTestDeliverer returns a deliverer allowed to reach the loopback test servers the real one refuses.
+func newTestDeliverer(repo repository.WebhookRepository, opts DelivererOptions) *Deliverer {
+	d := NewDeliverer(repo, opts)
+	d.client = &http.Client{Timeout: opts.Timeout}
+	return d
+}
+
+func testOptions() DelivererOptions {
+	opts := DefaultDelivererOptions()
+	opts.Timeout = time.Second
+	opts.MaxAttempts = 3
+	return opts
+}
+
+func Test_Deliverer_SignedDelivery(t *testing.T) {
+	const secret = "topsecret"
+	payload := []byte(`{"event":"message","room_id":1}`)
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	repo := newFakeRepo(model.WebhookDelivery{
+		ID:      7,
+		Event:   model.WebhookEventMessage,
+		Payload: payload,
+		Webhook: &model.Webhook{URL: srv.URL, Secret: secret},
+	})
+	d := newTestDeliverer(repo, testOptions())
+
+	n, err := d.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []int64{7}, repo.delivered)
+
+	r := <-got
+	assert.Equal(t, payload, r.body)
+	assert.Equal(t, model.WebhookEventMessage, r.header.Get(HeaderEvent))
+	assert.Equal(t, "7", r.header.Get(HeaderDelivery))
+	ts, err := strconv.ParseInt(r.header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Sign(secret, ts, payload), r.header.Get(HeaderSignature))
+}
+
+func Test_Deliverer_RetryAndDeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	hook := &model.Webhook{URL: srv.URL, Secret: "s"}
+	repo := newFakeRepo(
+		model.WebhookDelivery{ID: 1, Payload: []byte(`{}`), Webhook: hook},
+		model.WebhookDelivery{ID: 2, Payload: []byte(`{}`), Webhook: hook, Attempts: 2},
+	)
+	opts := testOptions()
+	d := newTestDeliverer(repo, opts)
+
+	_, err := d.DeliverDue(context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, repo.delivered)
+	assert.Contains(t, repo.failed[1], "500")
+	assert.False(t, repo.dead[1])
+	assert.Equal(t, opts.BaseBackoff, repo.retryIn[1])
+	assert.True(t, repo.dead[2])
+}
+
+func Test_Deliverer_Backoff(t *testing.T) {
+	opts := testOptions()
+	opts.BaseBackoff = time.Second
+	opts.MaxBackoff = 5 * time.Second
+	d := NewDeliverer(newFakeRepo(), opts)
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 4*time.Second, d.backoff(3))
+	assert.Equal(t, 5*time.Second, d.backoff(4))
+	assert.Equal(t, 5*time.Second, d.backoff(10))
+}
+
+func Test_Deliverer_RefusesLocalAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	repo := newFakeRepo(model.WebhookDelivery{ID: 1, Payload: []byte(`{}`), Webhook: &model.Webhook{URL: srv.URL, Secret: "s"}})
+	d := NewDeliverer(repo, testOptions())
+
+	_, err := d.DeliverDue(context.Background())
+	require.NoError(t, err)
+	assert.False(t, called)
+	assert.Empty(t, repo.delivered)
+	assert.Contains(t, repo.failed[1], "not publicly routable")
+}
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/egress"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository"
+	"github.com/Rasulikus/chat/internal/service"
+)
+
+var _ service.WebhookService = (*Service)(nil)
+
+type Service struct {
+	webhookRepo repository.WebhookRepository
+	roomRepo    repository.RoomRepository
+}
+
+func NewService(webhookRepo repository.WebhookRepository, roomRepo repository.RoomRepository) *Service {
+	return &Service{
+		webhookRepo: webhookRepo,
+		roomRepo:    roomRepo,
+	}
+}
+
+// Create validates the subscription, generates a signing secret, and persists a new webhook for the room.
+func (s *Service) Create(ctx context.Context, in service.CreateWebhookInput) (*model.Webhook, error) {
+	if err := ValidateURL(ctx, "url", in.URL); err != nil {
+		return nil, err
+	}
+	events, err := normalizeEvents(in.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.roomRepo.GetByID(ctx, in.RoomID); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &model.Webhook{
+		RoomID: in.RoomID,
+		URL:    in.URL,
+		Secret: secret,
+		Events: events,
+	}
+	err = s.webhookRepo.Insert(ctx, hook)
+	if err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// ListByRoom returns the webhooks of a room without their signing secrets.
+func (s *Service) ListByRoom(ctx context.Context, roomID int64) ([]model.Webhook, error) {
+	hooks, err := s.webhookRepo.ListByRoom(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+// Delete removes a webhook that belongs to the given room.
+func (s *Service) Delete(ctx context.Context, roomID, id int64) error {
+	hook, err := s.webhookRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hook.RoomID != roomID {
+		return model.ErrNotFound
+	}
+	return s.webhookRepo.Delete(ctx, id)
+}
+
+// Dispatch enqueues a delivery of the event for every webhook of the room subscribed to it.
+func (s *Service) Dispatch(ctx context.Context, event model.WebhookEvent) error {
+	hooks, err := s.webhookRepo.ListByRoom(ctx, event.RoomID)
+	if err != nil {
+		return err
+	}
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]model.WebhookDelivery, 0, len(hooks))
+	for _, hook := range hooks {
+		if !hook.Subscribed(event.Event) {
+			continue
+		}
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookID: hook.ID,
+			Event:     event.Event,
+			Payload:   payload,
+			Status:    model.WebhookDeliveryPending,
+		})
+	}
+	return s.webhookRepo.InsertDeliveries(ctx, deliveries)
+}
+
+// ValidateURL checks a URL the server will POST to, reporting a problem as a validation error of field.
+// Besides being an absolute http(s) URL, it must not resolve to a loopback, private, link-local or metadata address.
+func ValidateURL(ctx context.Context, field, raw string) error {
+	err := egress.CheckURL(ctx, raw)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, egress.ErrInvalidURL):
+		return &model.ValidationError{Fields: map[string]string{field: "must be an absolute http(s) URL"}}
+	case errors.Is(err, egress.ErrForbiddenAddress):
+		return &model.ValidationError{Fields: map[string]string{field: "must not point to a local or private network address"}}
+	default:
+		return &model.ValidationError{Fields: map[string]string{field: "host does not resolve"}}
+	}
+}
+
+func normalizeEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return slices.Clone(model.WebhookEvents), nil
+	}
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		if !slices.Contains(model.WebhookEvents, e) {
+			return nil, &model.ValidationError{Fields: map[string]string{"events": "unknown event type " + e}}
+		}
+		if !slices.Contains(out, e) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
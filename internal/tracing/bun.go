@@ -0,0 +1,42 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var _ bun.QueryHook = (*QueryHook)(nil)
+
+// QueryHook records a client span for every bun query as a child of the span in the query context.
+type QueryHook struct{}
+
+func (QueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	ctx, _ = Tracer().Start(ctx, "db "+event.Operation(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(event.Operation()),
+		),
+	)
+	return ctx
+}
+
+func (QueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(semconv.DBQueryText(event.Query))
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { _ = provider.Shutdown(context.Background()) })
+	return rec
+}
+
+func Test_Gin(t *testing.T) {
+	rec := setupRecorder(t)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Gin())
+	router.GET("/rooms/:id", func(c *gin.Context) {
+		_, span := Tracer().Start(c.Request.Context(), "child")
+		span.End()
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/rooms/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := rec.Ended()
+	require.Len(t, spans, 2)
+	child, server := spans[0], spans[1]
+
+	assert.Equal(t, "GET /rooms/:id", server.Name())
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent().SpanID().String())
+	assert.Contains(t, server.Attributes(), semconv.HTTPResponseStatusCode(http.StatusInternalServerError))
+	assert.Equal(t, codes.Error, server.Status().Code)
+	assert.Equal(t, server.SpanContext().SpanID(), child.Parent().SpanID())
+}
+
+func Test_QueryHook(t *testing.T) {
+	rec := setupRecorder(t)
+	ctx, parent := Tracer().Start(context.Background(), "parent")
+
+	hook := QueryHook{}
+	event := &bun.QueryEvent{Query: "SELECT 1"}
+	hook.AfterQuery(hook.BeforeQuery(ctx, event), event)
+
+	event = &bun.QueryEvent{Query: "DELETE FROM rooms", Err: errors.New("boom")}
+	hook.AfterQuery(hook.BeforeQuery(ctx, event), event)
+	parent.End()
+
+	spans := rec.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "db SELECT", spans[0].Name())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), semconv.DBQueryText("SELECT 1"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "db DELETE", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
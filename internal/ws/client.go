@@ -1,53 +1,93 @@
 package ws
 
 import (
+	"cmp"
 	"context"
-	"log"
+	"log/slog"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/metrics"
 	"github.com/Rasulikus/chat/internal/model"
 	"github.com/Rasulikus/chat/internal/service"
+	"github.com/Rasulikus/chat/internal/tracing"
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Options tunes every client of a hub.
+type Options struct {
+	// SendBuffer is the number of outgoing events queued before the client is disconnected as too slow.
+	SendBuffer int
+	// HistoryPageSize is the number of messages per load_history page.
+	HistoryPageSize int
+}
+
 type Client struct {
+	// ID identifies the connection in logs.
+	ID     string
 	Nick   string
 	RoomID int64
+	// Bot is set when the connection is authenticated with a bot API key.
+	Bot *model.Bot
 
 	hub            *Hub
 	conn           *websocket.Conn
 	roomService    service.RoomService
 	messageService service.MessageService
+	webhookService service.WebhookService
+	commands       *CommandRouter
 
-	ctx       context.Context
-	cancel    context.CancelFunc
-	closeOnce sync.Once
+	historyPageSize int
+	connectedAt     time.Time
+	log             *slog.Logger
+	ctx             context.Context
+	cancel          context.CancelFunc
+	closeOnce       sync.Once
 
 	send chan OutgoingEvent
 }
 
-// NewClient constructs a new WebSocket client bound to a hub and room/message services.
-func NewClient(h *Hub, conn *websocket.Conn, roomService service.RoomService, messageService service.MessageService) *Client {
-	ctx, cancel := context.WithCancel(context.Background())
+// NewClient constructs a new WebSocket client bound to a hub, room/message/webhook services, and a command router.
+// The client context keeps the values of ctx, such as the request logger and trace, but outlives the upgrade request.
+func NewClient(ctx context.Context, h *Hub, conn *websocket.Conn, roomService service.RoomService, messageService service.MessageService, webhookService service.WebhookService, commands *CommandRouter, opts Options) *Client {
+	id := logging.NewID()
+	l := logging.FromContext(ctx).With("conn_id", id)
+	ctx, cancel := context.WithCancel(logging.WithContext(context.WithoutCancel(ctx), l))
 	return &Client{
-		hub:            h,
-		conn:           conn,
-		roomService:    roomService,
-		messageService: messageService,
-		ctx:            ctx,
-		cancel:         cancel,
-		send:           make(chan OutgoingEvent, 32),
+		ID:              id,
+		hub:             h,
+		conn:            conn,
+		roomService:     roomService,
+		messageService:  messageService,
+		webhookService:  webhookService,
+		commands:        commands,
+		historyPageSize: opts.HistoryPageSize,
+		connectedAt:     time.Now(),
+		log:             l,
+		ctx:             ctx,
+		cancel:          cancel,
+		send:            make(chan OutgoingEvent, opts.SendBuffer),
 	}
 }
 
 // Start launches the client read and write loops in separate goroutines.
+// Bot clients also become the target of their bot's command invocations.
 func (c *Client) Start() {
+	if c.Bot != nil {
+		c.hub.attachBot(c)
+	}
 	go c.readLoop()
 	go c.writeLoop()
 }
 
-// handleTypeMessage processes an incoming message event, persists it, and broadcasts it to the room.
-func (c *Client) handleTypeMessage(in IncomingEvent) {
+// handleTypeMessage processes an incoming message event, persists it, broadcasts it to the room, and notifies the room webhooks.
+// Slash commands are routed to the command router instead and never persisted as typed.
+func (c *Client) handleTypeMessage(ctx context.Context, in IncomingEvent) {
 	if c.RoomID == 0 || c.Nick == "" {
 		c.Send(OutgoingEvent{
 			Type: EventTypeError,
@@ -56,13 +96,25 @@ func (c *Client) handleTypeMessage(in IncomingEvent) {
 		return
 	}
 
-	msg, err := c.messageService.Create(c.ctx, service.CreateMessageInput{
+	if c.commands.Dispatch(ctx, c, in.Text) {
+		return
+	}
+	if strings.HasPrefix(in.Text, "//") {
+		in.Text = in.Text[1:]
+	}
+
+	kind := model.MessageKindUser
+	if c.Bot != nil {
+		kind = model.MessageKindBot
+	}
+	msg, err := c.messageService.Create(ctx, service.CreateMessageInput{
 		RoomID: c.RoomID,
+		Kind:   kind,
 		Nick:   c.Nick,
 		Text:   in.Text,
 	})
 	if err != nil {
-		log.Println("ws: message service Create err:", err)
+		c.log.Error("ws: message service Create", "err", err, "room_id", c.RoomID, "nick", c.Nick)
 		c.Send(OutgoingEvent{
 			Type: EventTypeError,
 			Text: model.ErrBadRequest.Error(),
@@ -76,10 +128,16 @@ func (c *Client) handleTypeMessage(in IncomingEvent) {
 		Nick:    c.Nick,
 		Message: msg,
 	})
+	c.dispatchWebhook(ctx, model.WebhookEvent{
+		Event:   model.WebhookEventMessage,
+		RoomID:  c.RoomID,
+		Nick:    c.Nick,
+		Message: msg,
+	})
 }
 
 // handleTypeHistory processes a history request event and sends recent messages back to the client.
-func (c *Client) handleTypeHistory(in IncomingEvent) {
+func (c *Client) handleTypeHistory(ctx context.Context, in IncomingEvent) {
 	if c.RoomID == 0 || c.Nick == "" {
 		c.Send(OutgoingEvent{
 			Type: EventTypeError,
@@ -88,9 +146,14 @@ func (c *Client) handleTypeHistory(in IncomingEvent) {
 		return
 	}
 
-	msgs, err := c.messageService.ListByRoom(c.ctx, c.RoomID, in.BeforeID, 50)
+	page, err := c.messageService.ListByRoom(ctx, service.ListMessagesInput{
+		RoomID:   c.RoomID,
+		Limit:    c.historyPageSize,
+		Cursor:   in.Cursor,
+		BeforeID: in.BeforeID,
+	})
 	if err != nil {
-		log.Println("ws: message service ListByRoom err:", err)
+		c.log.Error("ws: message service ListByRoom", "err", err, "room_id", c.RoomID)
 		c.Send(OutgoingEvent{
 			Type: EventTypeError,
 			Text: model.ErrBadRequest.Error(),
@@ -98,18 +161,20 @@ func (c *Client) handleTypeHistory(in IncomingEvent) {
 		return
 	}
 	c.Send(OutgoingEvent{
-		Type:     EventTypeHistory,
-		RoomID:   c.RoomID,
-		Nick:     c.Nick,
-		Messages: msgs,
+		Type:       EventTypeHistory,
+		RoomID:     c.RoomID,
+		Nick:       c.Nick,
+		Messages:   page.Messages,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
 	})
 }
 
-// handleTypeJoin processes a join event, validates the password, registers the client in the hub, and broadcasts the join.
-func (c *Client) handleTypeJoin(in IncomingEvent) {
-	ok, err := c.roomService.CheckPassword(c.ctx, in.RoomID, in.Password)
+// handleTypeJoin processes a join event, validates the password, registers the client in the hub, broadcasts the join, and notifies the room webhooks.
+func (c *Client) handleTypeJoin(ctx context.Context, in IncomingEvent) {
+	ok, err := c.roomService.CheckPassword(ctx, in.RoomID, in.Password)
 	if err != nil {
-		log.Println("ws: room service CheckPassword err:", err)
+		c.log.Error("ws: room service CheckPassword", "err", err, "room_id", in.RoomID)
 	}
 
 	if !ok {
@@ -121,8 +186,12 @@ func (c *Client) handleTypeJoin(in IncomingEvent) {
 		return
 	}
 
-	if err = c.roomService.TouchActivity(c.ctx, in.RoomID); err != nil {
-		log.Println("ws: room service TouchActivity err:", err)
+	if c.Bot != nil {
+		in.Nick = c.Bot.Name
+	}
+
+	if err = c.roomService.TouchActivity(ctx, in.RoomID); err != nil {
+		c.log.Error("ws: room service TouchActivity", "err", err, "room_id", in.RoomID)
 	}
 
 	c.RoomID = in.RoomID
@@ -135,11 +204,42 @@ func (c *Client) handleTypeJoin(in IncomingEvent) {
 		RoomID: in.RoomID,
 		Nick:   in.Nick,
 	})
+	c.dispatchWebhook(ctx, model.WebhookEvent{
+		Event:  model.WebhookEventJoin,
+		RoomID: in.RoomID,
+		Nick:   in.Nick,
+	})
+}
+
+// dispatchWebhook enqueues the event for the room webhooks; failures are logged and never reach the client.
+func (c *Client) dispatchWebhook(ctx context.Context, event model.WebhookEvent) {
+	if err := c.webhookService.Dispatch(ctx, event); err != nil {
+		c.log.Error("ws: webhook service Dispatch", "err", err, "room_id", event.RoomID, "event", event.Event)
+	}
+}
+
+// handleTypeCommandReply forwards a bot's answer to the client that invoked its command.
+func (c *Client) handleTypeCommandReply(ctx context.Context, in IncomingEvent) {
+	if c.Bot == nil {
+		c.sendError(model.ErrForbidden.Error())
+		return
+	}
+
+	err := c.commands.Reply(ctx, c, in.InvocationID, model.CommandReply{
+		Text:      in.Text,
+		Ephemeral: in.Ephemeral,
+	})
+	if err != nil {
+		c.sendError("unknown or expired invocation " + in.InvocationID)
+	}
 }
 
 // readLoop continuously reads incoming events from the WebSocket connection, validates, and dispatches them.
 func (c *Client) readLoop() {
 	defer func() {
+		if c.Bot != nil {
+			c.hub.detachBot(c)
+		}
 		c.hub.unregister <- c
 		c.Close()
 	}()
@@ -148,27 +248,49 @@ func (c *Client) readLoop() {
 		var in IncomingEvent
 
 		if err := c.conn.ReadJSON(&in); err != nil {
-			log.Println("ws: readjson err:", err)
+			level := slog.LevelWarn
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				level = slog.LevelDebug
+			}
+			c.log.Log(c.ctx, level, "ws: read", "err", err)
 			return
 		}
-		if err := in.Validate(); err != nil {
-			c.Send(OutgoingEvent{
-				Type:   "error",
-				RoomID: in.RoomID,
-				Text:   err.Error(),
-			})
-			continue
-		}
-		switch in.Type {
-		case EventTypeMessage:
-			c.handleTypeMessage(in)
-		case EventTypeHistory:
-			c.handleTypeHistory(in)
-		case EventTypeJoin:
-			c.handleTypeJoin(in)
-		default:
-			log.Println("ws: unknown event type:", in.Type)
-		}
+		c.handleEvent(in)
+	}
+}
+
+// handleEvent validates and dispatches one incoming event inside its own span,
+// a child of the trace the connection was upgraded in.
+func (c *Client) handleEvent(in IncomingEvent) {
+	ctx, span := tracing.Tracer().Start(c.ctx, "ws "+in.Type,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("ws.conn_id", c.ID),
+			attribute.Int64("ws.room_id", cmp.Or(in.RoomID, c.RoomID)),
+		),
+	)
+	defer span.End()
+
+	if err := in.Validate(); err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		c.Send(OutgoingEvent{
+			Type:   "error",
+			RoomID: in.RoomID,
+			Text:   err.Error(),
+		})
+		return
+	}
+	switch in.Type {
+	case EventTypeMessage:
+		c.handleTypeMessage(ctx, in)
+	case EventTypeHistory:
+		c.handleTypeHistory(ctx, in)
+	case EventTypeJoin:
+		c.handleTypeJoin(ctx, in)
+	case EventTypeCommandReply:
+		c.handleTypeCommandReply(ctx, in)
+	default:
+		c.log.Warn("ws: unknown event type", "type", in.Type)
 	}
 }
 
@@ -182,12 +304,9 @@ func (c *Client) writeLoop() {
 		select {
 		case <-c.ctx.Done():
 			return
-		case event, ok := <-c.send:
-			if !ok {
-				return
-			}
+		case event := <-c.send:
 			if err := c.conn.WriteJSON(event); err != nil {
-				log.Println("ws: writejson err:", err)
+				c.log.Warn("ws: write", "err", err)
 				return
 			}
 		}
@@ -195,22 +314,44 @@ func (c *Client) writeLoop() {
 }
 
 // Send enqueues an outgoing event into the client send buffer or closes the client if the buffer is full.
+// Events sent to a closed client are dropped.
 func (c *Client) Send(event OutgoingEvent) {
 	select {
+	case <-c.ctx.Done():
+		return
 	case c.send <- event:
 	default:
-		log.Printf("ws: send buffer full for nick=%s room=%d, closing client", c.Nick, c.RoomID)
+		c.log.Warn("ws: send buffer full, closing client", "nick", c.Nick, "room_id", c.RoomID)
+		metrics.WSSendOverflowDisconnects.Inc()
 		c.Close()
 	}
 }
 
-// Close shuts down the client once, cancelling its context, closing the send channel, and closing the WebSocket connection.
+// sendError reports a failed request back to the client.
+func (c *Client) sendError(text string) {
+	c.Send(OutgoingEvent{
+		Type:   EventTypeError,
+		RoomID: c.RoomID,
+		Text:   text,
+	})
+}
+
+// Kick sends a close frame with the reason and shuts the client down.
+func (c *Client) Kick(reason string) {
+	msg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+	if err := c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second)); err != nil {
+		c.log.Warn("ws: kick close frame", "err", err)
+	}
+	c.Close()
+}
+
+// Close shuts down the client once, cancelling its context and closing the WebSocket connection.
+// The send channel stays open so concurrent senders never panic; the write loop exits on the context.
 func (c *Client) Close() {
 	c.closeOnce.Do(func() {
 		c.cancel()
-		close(c.send)
 		if err := c.conn.Close(); err != nil {
-			log.Println("ws: close err:", err)
+			c.log.Debug("ws: close", "err", err)
 		}
 	})
 }
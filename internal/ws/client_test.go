@@ -0,0 +1,88 @@
+package ws
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_MessageKind(t *testing.T) {
+	ts := setupTestSuite(t, defaultPendingTTL)
+	r := ts.room(t)
+	alice := ts.join(t, "", r.ID, "alice")
+	_, dice := ts.setupBot(t, r.ID)
+	alice.expect(EventTypeJoin)
+
+	alice.send(IncomingEvent{Type: EventTypeMessage, Text: "hi"})
+	msg := dice.expect(EventTypeMessage)
+	assert.Equal(t, model.MessageKindUser, msg.Message.Kind)
+	assert.Equal(t, "alice", msg.Message.Nick)
+	alice.expect(EventTypeMessage)
+
+	dice.send(IncomingEvent{Type: EventTypeMessage, Text: "hello"})
+	msg = alice.expect(EventTypeMessage)
+	assert.Equal(t, model.MessageKindBot, msg.Message.Kind)
+	assert.Equal(t, "dice", msg.Message.Nick)
+}
+
+func Test_Client_JoinMessageHistory(t *testing.T) {
+	ts := setupTestSuite(t, defaultPendingTTL)
+	r, err := ts.rooms.Create(ts.ctx, service.CreateRoomInput{Name: "private", Password: "secret"})
+	require.NoError(t, err)
+
+	alice := ts.dial(t, "")
+	alice.send(IncomingEvent{Type: EventTypeHistory})
+	assert.Equal(t, model.ErrUnauthorized.Error(), alice.expect(EventTypeError).Text)
+
+	alice.send(IncomingEvent{Type: EventTypeJoin, RoomID: r.ID, Nick: "alice", Password: "guess"})
+	assert.Equal(t, model.ErrWrongPassword.Error(), alice.expect(EventTypeError).Text)
+
+	alice.send(IncomingEvent{Type: EventTypeJoin, RoomID: r.ID, Nick: "alice", Password: "secret"})
+	join := alice.expect(EventTypeJoin)
+	assert.Equal(t, r.ID, join.RoomID)
+	assert.Equal(t, "alice", join.Nick)
+
+	bob := ts.dial(t, "")
+	bob.send(IncomingEvent{Type: EventTypeJoin, RoomID: r.ID, Nick: "bob", Password: "secret"})
+	bob.expect(EventTypeJoin)
+	assert.Equal(t, "bob", alice.expect(EventTypeJoin).Nick)
+
+	for i := 1; i <= 5; i++ {
+		text := fmt.Sprintf("message %d", i)
+		alice.send(IncomingEvent{Type: EventTypeMessage, Text: text})
+		for _, c := range []*testConn{alice, bob} {
+			msg := c.expect(EventTypeMessage)
+			assert.Equal(t, text, msg.Message.Text)
+			assert.Equal(t, "alice", msg.Message.Nick)
+			assert.Equal(t, r.ID, msg.Message.RoomID)
+		}
+	}
+
+	texts := func(messages []model.Message) []string {
+		var out []string
+		for _, m := range messages {
+			out = append(out, m.Text)
+		}
+		return out
+	}
+
+	// The first page holds the newest HistoryPageSize messages; each page lists its messages oldest first.
+	bob.send(IncomingEvent{Type: EventTypeHistory})
+	page := bob.expect(EventTypeHistory)
+	assert.Equal(t, r.ID, page.RoomID)
+	assert.Equal(t, []string{"message 3", "message 4", "message 5"}, texts(page.Messages))
+	require.NotEmpty(t, page.NextCursor)
+
+	bob.send(IncomingEvent{Type: EventTypeHistory, Cursor: page.NextCursor})
+	page = bob.expect(EventTypeHistory)
+	assert.Equal(t, []string{"message 1", "message 2"}, texts(page.Messages))
+	assert.Empty(t, page.NextCursor)
+	assert.NotEmpty(t, page.PrevCursor)
+
+	bob.send(IncomingEvent{Type: EventTypeHistory, Cursor: "garbage"})
+	assert.Equal(t, model.ErrBadRequest.Error(), bob.expect(EventTypeError).Text)
+}
@@ -0,0 +1,352 @@
+package ws
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/service"
+)
+
+const (
+	// defaultPendingTTL bounds how long a WebSocket bot may take to answer an invocation.
+	defaultPendingTTL = 30 * time.Second
+	// callbackTimeout bounds an HTTP callback to a bot.
+	callbackTimeout = 15 * time.Second
+	maxNickLen      = 30
+)
+
+type builtinCommand struct {
+	usage string
+	run   func(ctx context.Context, c *Client, args string)
+}
+
+type pendingInvocation struct {
+	invoker *Client
+	bot     *model.Bot
+	inv     model.CommandInvocation
+}
+
+// CommandRouter intercepts "/command args" messages before they are persisted and routes them
+// to a built-in handler or to the bot that registered the command.
+type CommandRouter struct {
+	hub         *Hub
+	publisher   *Publisher
+	roomService service.RoomService
+	botService  service.BotService
+
+	builtins map[string]builtinCommand
+
+	mu         sync.Mutex
+	pending    map[string]pendingInvocation
+	pendingTTL time.Duration
+}
+
+// NewCommandRouter constructs a router with the built-in commands registered.
+func NewCommandRouter(hub *Hub, publisher *Publisher, roomService service.RoomService, botService service.BotService) *CommandRouter {
+	r := &CommandRouter{
+		hub:         hub,
+		publisher:   publisher,
+		roomService: roomService,
+		botService:  botService,
+		pending:     make(map[string]pendingInvocation),
+		pendingTTL:  defaultPendingTTL,
+	}
+	r.builtins = map[string]builtinCommand{
+		"help":  {usage: "/help - list available commands", run: r.cmdHelp},
+		"me":    {usage: "/me <action> - describe what you are doing", run: r.cmdMe},
+		"nick":  {usage: "/nick <nick> - change your nick", run: r.cmdNick},
+		"topic": {usage: "/topic [text] - show the room topic, or change it (room operator only)", run: r.cmdTopic},
+		"kick":  {usage: "/kick <nick> - disconnect a user (room operator only)", run: r.cmdKick},
+	}
+	return r
+}
+
+// ParseCommand splits "/name args" into its parts. A leading "//" escapes the slash,
+// so such text is not a command.
+func ParseCommand(text string) (name, args string, ok bool) {
+	text = strings.TrimSpace(text)
+	if len(text) < 2 || text[0] != '/' || text[1] == '/' {
+		return "", "", false
+	}
+	name, args, _ = strings.Cut(text[1:], " ")
+	return strings.ToLower(name), strings.TrimSpace(args), name != ""
+}
+
+// Dispatch runs the command contained in text on behalf of the client.
+// It reports whether text was a command; if not, the caller should handle it as a regular message.
+func (r *CommandRouter) Dispatch(ctx context.Context, c *Client, text string) bool {
+	name, args, ok := ParseCommand(text)
+	if !ok {
+		return false
+	}
+
+	if cmd, ok := r.builtins[name]; ok {
+		cmd.run(ctx, c, args)
+		return true
+	}
+
+	r.dispatchToBot(ctx, c, name, args)
+	return true
+}
+
+// dispatchToBot forwards the invocation to the bot that registered the command,
+// preferring its live WebSocket connection over its HTTP callback.
+func (r *CommandRouter) dispatchToBot(ctx context.Context, c *Client, name, args string) {
+	cmd, err := r.botService.FindCommand(ctx, name)
+	if err != nil {
+		if !errors.Is(err, model.ErrNotFound) {
+			c.log.Error("ws: bot service FindCommand", "err", err, "command", name)
+		}
+		c.sendError(fmt.Sprintf("unknown command /%s, try /help", name))
+		return
+	}
+
+	id, err := newInvocationID()
+	if err != nil {
+		c.log.Error("ws: invocation id", "err", err)
+		c.sendError(model.ErrBadRequest.Error())
+		return
+	}
+	inv := model.CommandInvocation{
+		ID:      id,
+		Command: name,
+		Args:    args,
+		RoomID:  c.RoomID,
+		Nick:    c.Nick,
+	}
+
+	if botClient := r.hub.botClient(cmd.BotID); botClient != nil {
+		r.track(pendingInvocation{invoker: c, bot: cmd.Bot, inv: inv})
+		botClient.Send(OutgoingEvent{
+			Type:    EventTypeCommand,
+			RoomID:  inv.RoomID,
+			Nick:    inv.Nick,
+			Command: &inv,
+		})
+		return
+	}
+
+	if cmd.Bot.CallbackURL == "" {
+		c.sendError(fmt.Sprintf("bot %s is offline", cmd.Bot.Name))
+		return
+	}
+
+	go func() {
+		// The callback outlives the event that triggered it but keeps its trace and logger.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), callbackTimeout)
+		defer cancel()
+
+		reply, err := r.botService.Callback(ctx, cmd.Bot, inv)
+		if err != nil {
+			c.log.Warn("ws: bot service Callback", "err", err, "bot_id", cmd.Bot.ID, "command", name)
+			c.sendError(fmt.Sprintf("bot %s did not respond", cmd.Bot.Name))
+			return
+		}
+		r.deliverReply(ctx, c, cmd.Bot, inv, *reply)
+	}()
+}
+
+// Reply delivers the answer of a WebSocket bot to the invocation it was sent.
+func (r *CommandRouter) Reply(ctx context.Context, botClient *Client, invocationID string, reply model.CommandReply) error {
+	r.mu.Lock()
+	p, ok := r.pending[invocationID]
+	if ok && p.bot.ID == botClient.Bot.ID {
+		delete(r.pending, invocationID)
+	}
+	r.mu.Unlock()
+
+	if !ok || p.bot.ID != botClient.Bot.ID {
+		return model.ErrNotFound
+	}
+	r.deliverReply(ctx, p.invoker, p.bot, p.inv, reply)
+	return nil
+}
+
+// track remembers a WebSocket invocation until the bot replies or pendingTTL passes.
+func (r *CommandRouter) track(p pendingInvocation) {
+	r.mu.Lock()
+	r.pending[p.inv.ID] = p
+	r.mu.Unlock()
+
+	time.AfterFunc(r.pendingTTL, func() {
+		r.mu.Lock()
+		delete(r.pending, p.inv.ID)
+		r.mu.Unlock()
+	})
+}
+
+// deliverReply shows an ephemeral reply to the invoking user only, or posts a public one to the room as the bot.
+func (r *CommandRouter) deliverReply(ctx context.Context, invoker *Client, bot *model.Bot, inv model.CommandInvocation, reply model.CommandReply) {
+	if strings.TrimSpace(reply.Text) == "" {
+		return
+	}
+
+	// Ephemeral replies go to the invoking connection: the nick may have changed hands since the invocation.
+	if reply.Ephemeral {
+		invoker.Send(OutgoingEvent{
+			Type:   EventTypeSystem,
+			RoomID: inv.RoomID,
+			Nick:   bot.Name,
+			Text:   reply.Text,
+		})
+		return
+	}
+
+	_, err := r.publisher.Publish(ctx, service.CreateMessageInput{
+		RoomID: inv.RoomID,
+		Kind:   model.MessageKindBot,
+		Nick:   bot.Name,
+		Text:   reply.Text,
+	})
+	if err != nil {
+		invoker.log.Error("ws: publish bot reply", "err", err, "bot_id", bot.ID, "room_id", inv.RoomID)
+		invoker.sendError(model.ErrBadRequest.Error())
+	}
+}
+
+// reply sends command output to the invoking client only, as a system notice that is never stored.
+func (r *CommandRouter) reply(c *Client, text string) {
+	c.Send(OutgoingEvent{
+		Type:   EventTypeSystem,
+		RoomID: c.RoomID,
+		Text:   text,
+	})
+}
+
+func (r *CommandRouter) cmdHelp(ctx context.Context, c *Client, _ string) {
+	lines := make([]string, 0, len(r.builtins))
+	for _, cmd := range r.builtins {
+		lines = append(lines, cmd.usage)
+	}
+	sort.Strings(lines)
+
+	commands, err := r.botService.ListCommands(ctx, nil)
+	if err != nil {
+		c.log.Error("ws: bot service ListCommands", "err", err)
+	}
+	for _, cmd := range commands {
+		if _, shadowed := r.builtins[cmd.Name]; shadowed {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("/%s - %s", cmd.Name, cmd.Description))
+	}
+
+	r.reply(c, strings.Join(lines, "\n"))
+}
+
+func (r *CommandRouter) cmdMe(ctx context.Context, c *Client, args string) {
+	if args == "" {
+		c.sendError(r.builtins["me"].usage)
+		return
+	}
+	_, err := r.publisher.Publish(ctx, service.CreateMessageInput{
+		RoomID: c.RoomID,
+		Nick:   c.Nick,
+		Text:   fmt.Sprintf("* %s %s", c.Nick, args),
+	})
+	if err != nil {
+		c.log.Error("ws: publish /me", "err", err, "room_id", c.RoomID)
+		c.sendError(model.ErrBadRequest.Error())
+	}
+}
+
+func (r *CommandRouter) cmdNick(_ context.Context, c *Client, args string) {
+	if c.Bot != nil {
+		c.sendError("bots cannot change their nick")
+		return
+	}
+	if args == "" || len(args) > maxNickLen || strings.ContainsAny(args, " \t") {
+		c.sendError(r.builtins["nick"].usage)
+		return
+	}
+
+	old := c.Nick
+	if !r.hub.rename(c, args) {
+		c.sendError("nick " + args + " is already taken")
+		return
+	}
+	r.hub.Broadcast(OutgoingEvent{
+		Type:    EventTypeNick,
+		RoomID:  c.RoomID,
+		Nick:    args,
+		OldNick: old,
+	})
+}
+
+func (r *CommandRouter) cmdTopic(ctx context.Context, c *Client, args string) {
+	if args == "" {
+		room, err := r.roomService.GetByID(ctx, c.RoomID)
+		if err != nil {
+			c.log.Error("ws: room service GetByID", "err", err, "room_id", c.RoomID)
+			c.sendError(model.ErrBadRequest.Error())
+			return
+		}
+		topic := room.Topic
+		if topic == "" {
+			topic = "no topic is set"
+		}
+		r.reply(c, topic)
+		return
+	}
+
+	if !r.hub.isOp(c) {
+		c.sendError(model.ErrForbidden.Error())
+		return
+	}
+
+	in := service.UpdateRoomInput{
+		ID:    c.RoomID,
+		Topic: &args,
+	}
+	room, err := r.roomService.Update(ctx, in)
+	if err != nil {
+		c.log.Error("ws: room service Update", "err", err, "room_id", c.RoomID)
+		c.sendError(model.ErrBadRequest.Error())
+		return
+	}
+	r.publisher.RoomUpdated(ctx, room, in, c.Nick)
+}
+
+func (r *CommandRouter) cmdKick(ctx context.Context, c *Client, args string) {
+	if args == "" {
+		c.sendError(r.builtins["kick"].usage)
+		return
+	}
+	if !r.hub.isOp(c) {
+		c.sendError(model.ErrForbidden.Error())
+		return
+	}
+
+	targets := r.hub.clientsByNick(c.RoomID, args)
+	if len(targets) == 0 {
+		c.sendError("no such nick " + args)
+		return
+	}
+
+	r.hub.Broadcast(OutgoingEvent{
+		Type:   EventTypeKick,
+		RoomID: c.RoomID,
+		Nick:   args,
+		Text:   "kicked by " + c.Nick,
+	})
+	for _, t := range targets {
+		t.Kick("kicked by " + c.Nick)
+	}
+	r.publisher.Notice(ctx, c.RoomID, fmt.Sprintf("%s was kicked by %s", args, c.Nick))
+}
+
+func newInvocationID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
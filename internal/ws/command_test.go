@@ -0,0 +1,320 @@
+package ws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseCommand(t *testing.T) {
+	testCases := []struct {
+		text     string
+		wantName string
+		wantArgs string
+		wantOK   bool
+	}{
+		{text: "/help", wantName: "help", wantOK: true},
+		{text: "/ME  waves ", wantName: "me", wantArgs: "waves", wantOK: true},
+		{text: "/topic new topic", wantName: "topic", wantArgs: "new topic", wantOK: true},
+		{text: "  /nick bob", wantName: "nick", wantArgs: "bob", wantOK: true},
+		{text: "//me waves"},
+		{text: "/"},
+		{text: "hello /help"},
+		{text: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			name, args, ok := ParseCommand(tc.text)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantName, name)
+			assert.Equal(t, tc.wantArgs, args)
+		})
+	}
+}
+
+func Test_CommandRouter_Builtins(t *testing.T) {
+	testCases := []struct {
+		name string
+		// as is the nick sending text; alice joined first and is the room operator.
+		as   string
+		text string
+		want func(t *testing.T, ev OutgoingEvent)
+	}{
+		{
+			name: "me",
+			as:   "alice",
+			text: "/me waves",
+			want: func(t *testing.T, ev OutgoingEvent) {
+				require.Equal(t, EventTypeMessage, ev.Type)
+				assert.Equal(t, "* alice waves", ev.Message.Text)
+				assert.Equal(t, model.MessageKindUser, ev.Message.Kind)
+			},
+		},
+		{
+			name: "me without action",
+			as:   "alice",
+			text: "/me",
+			want: wantError("/me <action> - describe what you are doing"),
+		},
+		{
+			name: "nick",
+			as:   "bob",
+			text: "/nick carol",
+			want: func(t *testing.T, ev OutgoingEvent) {
+				require.Equal(t, EventTypeNick, ev.Type)
+				assert.Equal(t, "carol", ev.Nick)
+				assert.Equal(t, "bob", ev.OldNick)
+			},
+		},
+		{
+			name: "nick with spaces",
+			as:   "bob",
+			text: "/nick bob smith",
+			want: wantError("/nick <nick> - change your nick"),
+		},
+		{
+			name: "nick taken",
+			as:   "bob",
+			text: "/nick alice",
+			want: wantError("nick alice is already taken"),
+		},
+		{
+			name: "topic unset",
+			as:   "bob",
+			text: "/topic",
+			want: func(t *testing.T, ev OutgoingEvent) {
+				require.Equal(t, EventTypeSystem, ev.Type)
+				assert.Equal(t, "no topic is set", ev.Text)
+			},
+		},
+		{
+			name: "topic change",
+			as:   "alice",
+			text: "/topic release day",
+			want: func(t *testing.T, ev OutgoingEvent) {
+				require.Equal(t, EventTypeRoomUpdated, ev.Type)
+				assert.Equal(t, "alice", ev.Nick)
+				assert.Equal(t, "release day", ev.Room.Topic)
+			},
+		},
+		{
+			name: "topic change by non-operator",
+			as:   "bob",
+			text: "/topic release day",
+			want: wantError(model.ErrForbidden.Error()),
+		},
+		{
+			name: "kick",
+			as:   "alice",
+			text: "/kick bob",
+			want: func(t *testing.T, ev OutgoingEvent) {
+				require.Equal(t, EventTypeKick, ev.Type)
+				assert.Equal(t, "bob", ev.Nick)
+				assert.Equal(t, "kicked by alice", ev.Text)
+			},
+		},
+		{
+			name: "kick by non-operator",
+			as:   "bob",
+			text: "/kick alice",
+			want: wantError(model.ErrForbidden.Error()),
+		},
+		{
+			name: "kick unknown nick",
+			as:   "alice",
+			text: "/kick dave",
+			want: wantError("no such nick dave"),
+		},
+		{
+			name: "unknown command",
+			as:   "alice",
+			text: "/frobnicate now",
+			want: wantError("unknown command /frobnicate, try /help"),
+		},
+		{
+			name: "escaped slash",
+			as:   "alice",
+			text: "//me waves",
+			want: func(t *testing.T, ev OutgoingEvent) {
+				require.Equal(t, EventTypeMessage, ev.Type)
+				assert.Equal(t, "/me waves", ev.Message.Text)
+				assert.Equal(t, "alice", ev.Message.Nick)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := setupTestSuite(t, defaultPendingTTL)
+			r := ts.room(t)
+			conns := map[string]*testConn{
+				"alice": ts.join(t, "", r.ID, "alice"),
+			}
+			conns["bob"] = ts.join(t, "", r.ID, "bob")
+			conns["alice"].expect(EventTypeJoin)
+
+			conns[tc.as].send(IncomingEvent{Type: EventTypeMessage, Text: tc.text})
+			tc.want(t, conns[tc.as].next())
+		})
+	}
+}
+
+func wantError(text string) func(t *testing.T, ev OutgoingEvent) {
+	return func(t *testing.T, ev OutgoingEvent) {
+		require.Equal(t, EventTypeError, ev.Type)
+		assert.Equal(t, text, ev.Text)
+	}
+}
+
+func Test_CommandRouter_KickDisconnects(t *testing.T) {
+	ts := setupTestSuite(t, defaultPendingTTL)
+	r := ts.room(t)
+	alice := ts.join(t, "", r.ID, "alice")
+	bob := ts.join(t, "", r.ID, "bob")
+	alice.expect(EventTypeJoin)
+
+	alice.send(IncomingEvent{Type: EventTypeMessage, Text: "/kick bob"})
+	alice.expect(EventTypeKick)
+	notice := alice.expect(EventTypeMessage)
+	assert.Equal(t, model.MessageKindSystem, notice.Message.Kind)
+	assert.Equal(t, "bob was kicked by alice", notice.Message.Text)
+
+	err := bob.closed()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "err: %v", err)
+}
+
+// setupBot registers a bot handling /roll and connects it to the room.
+func (ts *testSuite) setupBot(t *testing.T, roomID int64) (*model.Bot, *testConn) {
+	t.Helper()
+	b, err := ts.bots.Create(ts.ctx, service.CreateBotInput{Name: "dice"})
+	require.NoError(t, err)
+	_, err = ts.bots.SetCommands(ts.ctx, b.ID, []service.BotCommandInput{{Name: "roll", Description: "roll dice"}})
+	require.NoError(t, err)
+	return b, ts.join(t, b.APIKey, roomID, "dice")
+}
+
+func Test_CommandRouter_BotOverWebSocket(t *testing.T) {
+	t.Run("public reply", func(t *testing.T) {
+		ts := setupTestSuite(t, defaultPendingTTL)
+		r := ts.room(t)
+		alice := ts.join(t, "", r.ID, "alice")
+		_, dice := ts.setupBot(t, r.ID)
+		alice.expect(EventTypeJoin)
+
+		alice.send(IncomingEvent{Type: EventTypeMessage, Text: "/roll 2d6"})
+		cmd := dice.expect(EventTypeCommand)
+		require.NotNil(t, cmd.Command)
+		assert.Equal(t, "roll", cmd.Command.Command)
+		assert.Equal(t, "2d6", cmd.Command.Args)
+		assert.Equal(t, "alice", cmd.Command.Nick)
+		assert.Equal(t, r.ID, cmd.Command.RoomID)
+
+		dice.send(IncomingEvent{Type: EventTypeCommandReply, InvocationID: cmd.Command.ID, Text: "7"})
+		msg := alice.expect(EventTypeMessage)
+		assert.Equal(t, model.MessageKindBot, msg.Message.Kind)
+		assert.Equal(t, "dice", msg.Message.Nick)
+		assert.Equal(t, "7", msg.Message.Text)
+		dice.expect(EventTypeMessage)
+
+		// An invocation is answered once.
+		dice.send(IncomingEvent{Type: EventTypeCommandReply, InvocationID: cmd.Command.ID, Text: "8"})
+		assert.Equal(t, "unknown or expired invocation "+cmd.Command.ID, dice.expect(EventTypeError).Text)
+	})
+
+	t.Run("ephemeral reply", func(t *testing.T) {
+		ts := setupTestSuite(t, defaultPendingTTL)
+		r := ts.room(t)
+		alice := ts.join(t, "", r.ID, "alice")
+		_, dice := ts.setupBot(t, r.ID)
+		alice.expect(EventTypeJoin)
+
+		alice.send(IncomingEvent{Type: EventTypeMessage, Text: "/roll"})
+		cmd := dice.expect(EventTypeCommand)
+		dice.send(IncomingEvent{Type: EventTypeCommandReply, InvocationID: cmd.Command.ID, Text: "4", Ephemeral: true})
+		notice := alice.expect(EventTypeSystem)
+		assert.Equal(t, "dice", notice.Nick)
+		assert.Equal(t, "4", notice.Text)
+	})
+
+	t.Run("ephemeral reply after a rename", func(t *testing.T) {
+		ts := setupTestSuite(t, defaultPendingTTL)
+		r := ts.room(t)
+		alice := ts.join(t, "", r.ID, "alice")
+		carol := ts.join(t, "", r.ID, "carol")
+		_, dice := ts.setupBot(t, r.ID)
+		alice.expect(EventTypeJoin)
+		alice.expect(EventTypeJoin)
+		carol.expect(EventTypeJoin)
+
+		alice.send(IncomingEvent{Type: EventTypeMessage, Text: "/roll"})
+		cmd := dice.expect(EventTypeCommand)
+		alice.send(IncomingEvent{Type: EventTypeMessage, Text: "/nick alicia"})
+		alice.expect(EventTypeNick)
+		carol.expect(EventTypeNick)
+		carol.send(IncomingEvent{Type: EventTypeMessage, Text: "/nick alice"})
+		alice.expect(EventTypeNick)
+		carol.expect(EventTypeNick)
+
+		dice.send(IncomingEvent{Type: EventTypeCommandReply, InvocationID: cmd.Command.ID, Text: "4", Ephemeral: true})
+		assert.Equal(t, "4", alice.expect(EventTypeSystem).Text)
+
+		// carol now holds the invoker's old nick; her next event is the public message, not the private reply.
+		dice.send(IncomingEvent{Type: EventTypeMessage, Text: "done"})
+		assert.Equal(t, "done", carol.expect(EventTypeMessage).Message.Text)
+	})
+
+	t.Run("unknown invocation", func(t *testing.T) {
+		ts := setupTestSuite(t, defaultPendingTTL)
+		r := ts.room(t)
+		_, dice := ts.setupBot(t, r.ID)
+
+		dice.send(IncomingEvent{Type: EventTypeCommandReply, InvocationID: "0123456789abcdef", Text: "7"})
+		assert.Equal(t, "unknown or expired invocation 0123456789abcdef", dice.expect(EventTypeError).Text)
+	})
+
+	t.Run("expired invocation", func(t *testing.T) {
+		ts := setupTestSuite(t, 10*time.Millisecond)
+		r := ts.room(t)
+		alice := ts.join(t, "", r.ID, "alice")
+		_, dice := ts.setupBot(t, r.ID)
+		alice.expect(EventTypeJoin)
+
+		alice.send(IncomingEvent{Type: EventTypeMessage, Text: "/roll"})
+		cmd := dice.expect(EventTypeCommand)
+		require.Eventually(t, func() bool {
+			ts.commands.mu.Lock()
+			defer ts.commands.mu.Unlock()
+			_, ok := ts.commands.pending[cmd.Command.ID]
+			return !ok
+		}, time.Second, 5*time.Millisecond)
+
+		dice.send(IncomingEvent{Type: EventTypeCommandReply, InvocationID: cmd.Command.ID, Text: "7"})
+		assert.Equal(t, "unknown or expired invocation "+cmd.Command.ID, dice.expect(EventTypeError).Text)
+	})
+
+	t.Run("reply from a user", func(t *testing.T) {
+		ts := setupTestSuite(t, defaultPendingTTL)
+		r := ts.room(t)
+		alice := ts.join(t, "", r.ID, "alice")
+
+		alice.send(IncomingEvent{Type: EventTypeCommandReply, InvocationID: "0123456789abcdef", Text: "7"})
+		assert.Equal(t, model.ErrForbidden.Error(), alice.expect(EventTypeError).Text)
+	})
+
+	t.Run("offline bot", func(t *testing.T) {
+		ts := setupTestSuite(t, defaultPendingTTL)
+		r := ts.room(t)
+		alice := ts.join(t, "", r.ID, "alice")
+		b, err := ts.bots.Create(ts.ctx, service.CreateBotInput{Name: "dice"})
+		require.NoError(t, err)
+		_, err = ts.bots.SetCommands(ts.ctx, b.ID, []service.BotCommandInput{{Name: "roll"}})
+		require.NoError(t, err)
+
+		alice.send(IncomingEvent{Type: EventTypeMessage, Text: "/roll"})
+		assert.Equal(t, "bot dice is offline", alice.expect(EventTypeError).Text)
+	})
+}
@@ -13,24 +13,41 @@ const (
 	EventTypeMessage = "message"
 	EventTypeHistory = "load_history"
 	EventTypeError   = "error"
+	EventTypeCommand = "command"
+	// EventTypeCommandReply is sent by WebSocket bots to answer a "command" event.
+	EventTypeCommandReply = "command_reply"
+	EventTypeNick         = "nick"
+	EventTypeRoomUpdated  = "room_updated"
+	EventTypeKick         = "kick"
+	// EventTypeSystem is a notice for one user only, such as command output; it is never stored.
+	EventTypeSystem = "system"
 )
 
 type IncomingEvent struct {
-	Type     string `json:"type"`
-	RoomID   int64  `json:"room_id,omitempty"`
-	Nick     string `json:"nick,omitempty"`
-	Text     string `json:"text,omitempty"`
-	BeforeID *int64 `json:"before_id,omitempty"`
-	Password string `json:"password,omitempty"`
+	Type         string `json:"type"`
+	RoomID       int64  `json:"room_id,omitempty"`
+	Nick         string `json:"nick,omitempty"`
+	Text         string `json:"text,omitempty"`
+	BeforeID     *int64 `json:"before_id,omitempty"`
+	Cursor       string `json:"cursor,omitempty"`
+	Password     string `json:"password,omitempty"`
+	InvocationID string `json:"invocation_id,omitempty"`
+	Ephemeral    bool   `json:"ephemeral,omitempty"`
 }
 
 type OutgoingEvent struct {
-	Type     string          `json:"type"`
-	RoomID   int64           `json:"room_id,omitempty"`
-	Message  *model.Message  `json:"message,omitempty"`
-	Messages []model.Message `json:"messages,omitempty"`
-	Nick     string          `json:"nick,omitempty"`
-	Text     string          `json:"text,omitempty"`
+	Type     string                   `json:"type"`
+	RoomID   int64                    `json:"room_id,omitempty"`
+	Message  *model.Message           `json:"message,omitempty"`
+	Messages []model.Message          `json:"messages,omitempty"`
+	Nick     string                   `json:"nick,omitempty"`
+	OldNick  string                   `json:"old_nick,omitempty"`
+	Text     string                   `json:"text,omitempty"`
+	Command  *model.CommandInvocation `json:"command,omitempty"`
+	Room     *model.Room              `json:"room,omitempty"`
+	// NextCursor and PrevCursor continue a "load_history" page towards older and newer messages.
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
 }
 
 var (
@@ -46,6 +63,8 @@ func (e *IncomingEvent) Validate() error {
 		return e.validateMessage()
 	case EventTypeHistory:
 		return e.validateLoadHistory()
+	case EventTypeCommandReply:
+		return e.validateCommandReply()
 	default:
 		return ErrUnknownType
 	}
@@ -71,3 +90,13 @@ func (e *IncomingEvent) validateMessage() error {
 func (e *IncomingEvent) validateLoadHistory() error {
 	return nil
 }
+
+func (e *IncomingEvent) validateCommandReply() error {
+	if e.InvocationID == "" {
+		return fmt.Errorf("%w: invocation_id is required for command_reply", ErrBadPayload)
+	}
+	if strings.TrimSpace(e.Text) == "" {
+		return fmt.Errorf("%w: text is required for command_reply", ErrBadPayload)
+	}
+	return nil
+}
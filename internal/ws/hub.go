@@ -1,37 +1,73 @@
 package ws
 
-import "sync"
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
 
 type Broadcast struct {
 	RoomID int64
 	Event  OutgoingEvent
 }
 
+// Direct is an event addressed to the clients of one user in a room.
+type Direct struct {
+	RoomID int64
+	Nick   string
+	Event  OutgoingEvent
+}
+
 type Hub struct {
 	mu    sync.RWMutex
 	rooms map[int64]*RoomRuntime
+	bots  map[int64]*Client
 
 	register   chan *Client
 	unregister chan *Client
 	broadcast  chan Broadcast
+	direct     chan Direct
+	ping       chan chan struct{}
+}
+
+// ClientInfo describes a live connection joined to a room.
+type ClientInfo struct {
+	ID          string    `json:"id"`
+	Nick        string    `json:"nick"`
+	BotID       *int64    `json:"bot_id,omitempty"`
+	Op          bool      `json:"op"`
+	ConnectedAt time.Time `json:"connected_at"`
+}
+
+// RoomSnapshot lists the clients of a room with live connections.
+type RoomSnapshot struct {
+	ID      int64        `json:"id"`
+	Clients []ClientInfo `json:"clients"`
 }
 
 type RoomRuntime struct {
 	ID      int64
 	clients map[*Client]struct{}
+	// op is the client allowed to moderate the room: the first one to join, then whoever is left.
+	op *Client
 }
 
 // NewHub creates a new Hub instance with initialized room map and internal channels.
 func NewHub() *Hub {
 	return &Hub{
 		rooms:      make(map[int64]*RoomRuntime),
+		bots:       make(map[int64]*Client),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		broadcast:  make(chan Broadcast),
+		direct:     make(chan Direct),
+		ping:       make(chan chan struct{}),
 	}
 }
 
-// Run starts the hub event loop and processes client registration, unregistration, and broadcast requests.
+// Run starts the hub event loop and processes client registration, unregistration, broadcast, and direct send requests.
 func (h *Hub) Run() {
 	for {
 		select {
@@ -41,10 +77,30 @@ func (h *Hub) Run() {
 			h.removeClient(c)
 		case b := <-h.broadcast:
 			h.broadcastToRoom(b)
+		case d := <-h.direct:
+			h.sendToUser(d)
+		case reply := <-h.ping:
+			close(reply)
 		}
 	}
 }
 
+// Ping waits for the event loop to answer, reporting a stuck or stopped loop as a ctx error.
+func (h *Hub) Ping(ctx context.Context) error {
+	reply := make(chan struct{})
+	select {
+	case h.ping <- reply:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-reply:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // addClient registers a client in the corresponding room runtime, creating the room if it does not exist.
 func (h *Hub) addClient(c *Client) {
 	h.mu.Lock()
@@ -59,6 +115,9 @@ func (h *Hub) addClient(c *Client) {
 		h.rooms[c.RoomID] = room
 	}
 	room.clients[c] = struct{}{}
+	if room.op == nil && c.Bot == nil {
+		room.op = c
+	}
 }
 
 // removeClient unregisters a client from its room and removes the room if it becomes empty.
@@ -73,6 +132,16 @@ func (h *Hub) removeClient(c *Client) {
 	delete(room.clients, c)
 	if len(room.clients) == 0 {
 		delete(h.rooms, c.RoomID)
+		return
+	}
+	if room.op == c {
+		room.op = nil
+		for other := range room.clients {
+			if other.Bot == nil {
+				room.op = other
+				break
+			}
+		}
 	}
 }
 
@@ -91,6 +160,35 @@ func (h *Hub) broadcastToRoom(b Broadcast) {
 	}
 }
 
+// sendToUser sends an event to every client of the room connected under the given nick.
+func (h *Hub) sendToUser(d Direct) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	room, ok := h.rooms[d.RoomID]
+	if !ok {
+		return
+	}
+
+	for c := range room.clients {
+		if c.Nick == d.Nick {
+			c.Send(d.Event)
+		}
+	}
+}
+
+// SendTo enqueues an outgoing event to be dispatched only to the clients of one user in a room.
+func (h *Hub) SendTo(roomID int64, nick string, event OutgoingEvent) {
+	if roomID == 0 || nick == "" {
+		return
+	}
+	h.direct <- Direct{
+		RoomID: roomID,
+		Nick:   nick,
+		Event:  event,
+	}
+}
+
 // Broadcast enqueues an outgoing event to be dispatched to all clients in the specified room.
 func (h *Hub) Broadcast(event OutgoingEvent) {
 	if event.RoomID == 0 {
@@ -101,3 +199,189 @@ func (h *Hub) Broadcast(event OutgoingEvent) {
 		Event:  event,
 	}
 }
+
+// attachBot makes the client the live connection of its bot, replacing a previous one.
+func (h *Hub) attachBot(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.bots[c.Bot.ID] = c
+}
+
+// detachBot forgets the client if it is still the live connection of its bot.
+func (h *Hub) detachBot(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.bots[c.Bot.ID] == c {
+		delete(h.bots, c.Bot.ID)
+	}
+}
+
+// botClient returns the live WebSocket connection of a bot, or nil if it is not connected.
+func (h *Hub) botClient(botID int64) *Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.bots[botID]
+}
+
+// rename changes the nick of a client; other goroutines read nicks under the hub lock.
+// It reports false, leaving the nick unchanged, when another client of the room uses the nick,
+// since /kick and direct notices find users by nick.
+func (h *Hub) rename(c *Client, nick string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if room, ok := h.rooms[c.RoomID]; ok {
+		for other := range room.clients {
+			if other != c && other.Nick == nick {
+				return false
+			}
+		}
+	}
+	c.Nick = nick
+	return true
+}
+
+// clientsByNick returns the clients of a room using the given nick.
+func (h *Hub) clientsByNick(roomID int64, nick string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	room, ok := h.rooms[roomID]
+	if !ok {
+		return nil
+	}
+	var out []*Client
+	for c := range room.clients {
+		if c.Nick == nick {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// isOp reports whether the client moderates its room.
+func (h *Hub) isOp(c *Client) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	room, ok := h.rooms[c.RoomID]
+	return ok && room.op == c
+}
+
+// Stats returns the number of clients joined to a room and the number of rooms with clients.
+func (h *Hub) Stats() (clients, rooms int) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, room := range h.rooms {
+		clients += len(room.clients)
+	}
+	return clients, len(h.rooms)
+}
+
+// OnlineCounts returns the number of connected clients in every room that has any.
+func (h *Hub) OnlineCounts() map[int64]int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	counts := make(map[int64]int, len(h.rooms))
+	for id, room := range h.rooms {
+		counts[id] = len(room.clients)
+	}
+	return counts
+}
+
+// Rooms returns the rooms with live connections and their clients, ordered by room and nick.
+func (h *Hub) Rooms() []RoomSnapshot {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rooms := make([]RoomSnapshot, 0, len(h.rooms))
+	for id, room := range h.rooms {
+		snap := RoomSnapshot{ID: id, Clients: make([]ClientInfo, 0, len(room.clients))}
+		for c := range room.clients {
+			info := ClientInfo{
+				ID:          c.ID,
+				Nick:        c.Nick,
+				Op:          room.op == c,
+				ConnectedAt: c.connectedAt,
+			}
+			if c.Bot != nil {
+				info.BotID = &c.Bot.ID
+			}
+			snap.Clients = append(snap.Clients, info)
+		}
+		slices.SortFunc(snap.Clients, func(a, b ClientInfo) int {
+			return cmp.Or(cmp.Compare(a.Nick, b.Nick), cmp.Compare(a.ID, b.ID))
+		})
+		rooms = append(rooms, snap)
+	}
+	slices.SortFunc(rooms, func(a, b RoomSnapshot) int { return cmp.Compare(a.ID, b.ID) })
+	return rooms
+}
+
+// Disconnect kicks the joined client or connected bot with the given connection ID.
+// It reports whether such a client was found.
+func (h *Hub) Disconnect(id, reason string) bool {
+	h.mu.RLock()
+	var target *Client
+	for _, room := range h.rooms {
+		for c := range room.clients {
+			if c.ID == id {
+				target = c
+			}
+		}
+	}
+	for _, c := range h.bots {
+		if c.ID == id {
+			target = c
+		}
+	}
+	h.mu.RUnlock()
+
+	if target == nil {
+		return false
+	}
+	target.Kick(reason)
+	return true
+}
+
+// CloseRoom kicks every client of a room and returns how many there were.
+func (h *Hub) CloseRoom(roomID int64, reason string) int {
+	h.mu.RLock()
+	var targets []*Client
+	if room, ok := h.rooms[roomID]; ok {
+		for c := range room.clients {
+			targets = append(targets, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, c := range targets {
+		c.Kick(reason)
+	}
+	return len(targets)
+}
+
+// Announce sends a system notice to every client joined to a room and returns the number of recipients.
+// Announcements are not stored in room histories.
+func (h *Hub) Announce(text string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	n := 0
+	for id, room := range h.rooms {
+		for c := range room.clients {
+			c.Send(OutgoingEvent{
+				Type:   EventTypeSystem,
+				RoomID: id,
+				Text:   text,
+			})
+			n++
+		}
+	}
+	return n
+}
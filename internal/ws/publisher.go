@@ -0,0 +1,113 @@
+package ws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Rasulikus/chat/internal/logging"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/service"
+)
+
+// Publisher fans room activity that does not originate from a socket read, such as bot replies,
+// HTTP posts and room edits, out to live clients and webhooks the same way socket messages are.
+type Publisher struct {
+	hub            *Hub
+	messageService service.MessageService
+	webhookService service.WebhookService
+}
+
+// NewPublisher constructs a publisher bound to a hub and message/webhook services.
+func NewPublisher(hub *Hub, messageService service.MessageService, webhookService service.WebhookService) *Publisher {
+	return &Publisher{
+		hub:            hub,
+		messageService: messageService,
+		webhookService: webhookService,
+	}
+}
+
+// Publish persists a message, then announces it.
+func (p *Publisher) Publish(ctx context.Context, in service.CreateMessageInput) (*model.Message, error) {
+	msg, err := p.messageService.Create(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	p.Announce(ctx, msg)
+	return msg, nil
+}
+
+// Announce broadcasts an already persisted message to the room and notifies the room webhooks.
+func (p *Publisher) Announce(ctx context.Context, msg *model.Message) {
+	p.hub.Broadcast(OutgoingEvent{
+		Type:    EventTypeMessage,
+		RoomID:  msg.RoomID,
+		Nick:    msg.Nick,
+		Message: msg,
+	})
+	p.dispatch(ctx, model.WebhookEvent{
+		Event:   model.WebhookEventMessage,
+		RoomID:  msg.RoomID,
+		Nick:    msg.Nick,
+		Message: msg,
+	})
+}
+
+// Notice persists a system message in the room and announces it.
+func (p *Publisher) Notice(ctx context.Context, roomID int64, text string) {
+	_, err := p.Publish(ctx, service.CreateMessageInput{
+		RoomID: roomID,
+		Kind:   model.MessageKindSystem,
+		Text:   text,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("ws: publish system message", "err", err, "room_id", roomID)
+	}
+}
+
+// RoomUpdated broadcasts the new room state to its clients as "room_updated", notifies the room webhooks,
+// and leaves system messages in the history for renames and topic changes.
+func (p *Publisher) RoomUpdated(ctx context.Context, room *model.Room, in service.UpdateRoomInput, by string) {
+	p.hub.Broadcast(OutgoingEvent{
+		Type:   EventTypeRoomUpdated,
+		RoomID: room.ID,
+		Nick:   by,
+		Room:   room,
+	})
+	p.dispatch(ctx, model.WebhookEvent{
+		Event:  model.WebhookEventRoomUpdated,
+		RoomID: room.ID,
+		Nick:   by,
+		Room:   room,
+	})
+
+	if in.Name != nil {
+		p.Notice(ctx, room.ID, fmt.Sprintf("%s renamed the room to %s", actor(by), room.Name))
+	}
+	if in.Topic != nil {
+		if room.Topic == "" {
+			p.Notice(ctx, room.ID, fmt.Sprintf("%s cleared the topic", actor(by)))
+		} else {
+			p.Notice(ctx, room.ID, fmt.Sprintf("%s changed the topic to: %s", actor(by), room.Topic))
+		}
+	}
+}
+
+// RoomRestored leaves a system message in the history of a restored room, so returning clients see what happened to it.
+// The room webhooks are notified by the room service itself.
+func (p *Publisher) RoomRestored(ctx context.Context, room *model.Room) {
+	p.Notice(ctx, room.ID, "the room was restored")
+}
+
+func (p *Publisher) dispatch(ctx context.Context, event model.WebhookEvent) {
+	if err := p.webhookService.Dispatch(ctx, event); err != nil {
+		logging.FromContext(ctx).Error("ws: webhook service Dispatch", "err", err, "room_id", event.RoomID, "event", event.Event)
+	}
+}
+
+// actor names who made a change in system messages; changes made over the REST API have no nick.
+func actor(nick string) string {
+	if nick == "" {
+		return "someone"
+	}
+	return nick
+}
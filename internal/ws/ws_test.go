@@ -0,0 +1,151 @@
+package ws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rasulikus/chat/internal/cursor"
+	"github.com/Rasulikus/chat/internal/model"
+	"github.com/Rasulikus/chat/internal/repository/memory"
+	"github.com/Rasulikus/chat/internal/service"
+	"github.com/Rasulikus/chat/internal/service/bot"
+	"github.com/Rasulikus/chat/internal/service/message"
+	"github.com/Rasulikus/chat/internal/service/room"
+	"github.com/Rasulikus/chat/internal/service/webhook"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// testSuite runs a hub with memory-backed services behind a WebSocket test server.
+type testSuite struct {
+	ctx      context.Context
+	hub      *Hub
+	rooms    *room.Service
+	messages *message.Service
+	bots     *bot.Service
+	commands *CommandRouter
+	server   *httptest.Server
+}
+
+// setupTestSuite starts the server; pendingTTL is set before any connection can dispatch a command.
+func setupTestSuite(t *testing.T, pendingTTL time.Duration) *testSuite {
+	t.Helper()
+	cursors, err := cursor.NewCodec("secret")
+	require.NoError(t, err)
+
+	store := memory.NewStore()
+	roomRepo := memory.NewRoomRepository(store)
+	webhookService := webhook.NewService(memory.NewWebhookRepository(store), roomRepo)
+
+	ts := &testSuite{
+		ctx:      context.Background(),
+		hub:      NewHub(),
+		rooms:    room.NewService(roomRepo, memory.NewTransactor(), webhookService, cursors, time.Hour),
+		messages: message.NewService(memory.NewMessageRepository(store), room.NewActivityRecorder(roomRepo), cursors, nil),
+		bots:     bot.NewService(memory.NewBotRepository(store)),
+	}
+	go ts.hub.Run()
+	ts.commands = NewCommandRouter(ts.hub, NewPublisher(ts.hub, ts.messages, webhookService), ts.rooms, ts.bots)
+	ts.commands.pendingTTL = pendingTTL
+
+	upgrader := websocket.Upgrader{}
+	ts.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b *model.Bot
+		if key := r.URL.Query().Get("api_key"); key != "" {
+			var err error
+			if b, err = ts.bots.Authenticate(r.Context(), key); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c := NewClient(r.Context(), ts.hub, conn, ts.rooms, ts.messages, webhookService, ts.commands, Options{
+			SendBuffer:      16,
+			HistoryPageSize: 3,
+		})
+		c.Bot = b
+		c.Start()
+	}))
+	t.Cleanup(ts.server.Close)
+	return ts
+}
+
+func (ts *testSuite) room(t *testing.T) *model.Room {
+	t.Helper()
+	r, err := ts.rooms.Create(ts.ctx, service.CreateRoomInput{Name: "general"})
+	require.NoError(t, err)
+	return r
+}
+
+// testConn is the test side of a WebSocket connection.
+type testConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// dial connects to the server, as a bot when apiKey is set.
+func (ts *testSuite) dial(t *testing.T, apiKey string) *testConn {
+	t.Helper()
+	u := "ws" + strings.TrimPrefix(ts.server.URL, "http")
+	if apiKey != "" {
+		u += "?api_key=" + url.QueryEscape(apiKey)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(u, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		_ = conn.Close()
+	})
+	return &testConn{t: t, conn: conn}
+}
+
+// join connects and joins the room, waiting for its own join event so the hub has registered the client.
+func (ts *testSuite) join(t *testing.T, apiKey string, roomID int64, nick string) *testConn {
+	t.Helper()
+	c := ts.dial(t, apiKey)
+	c.send(IncomingEvent{Type: EventTypeJoin, RoomID: roomID, Nick: nick})
+	c.expect(EventTypeJoin)
+	return c
+}
+
+func (c *testConn) send(in IncomingEvent) {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.WriteJSON(in))
+}
+
+// next returns the next event sent to the connection.
+func (c *testConn) next() OutgoingEvent {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	var ev OutgoingEvent
+	require.NoError(c.t, c.conn.ReadJSON(&ev))
+	return ev
+}
+
+// expect returns the next event, which must be of type typ.
+func (c *testConn) expect(typ string) OutgoingEvent {
+	c.t.Helper()
+	ev := c.next()
+	require.Equal(c.t, typ, ev.Type, "event: %+v", ev)
+	return ev
+}
+
+// closed reads until the server closes the connection and returns the close error.
+func (c *testConn) closed() error {
+	c.t.Helper()
+	require.NoError(c.t, c.conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+	for {
+		var ev OutgoingEvent
+		if err := c.conn.ReadJSON(&ev); err != nil {
+			return err
+		}
+	}
+}
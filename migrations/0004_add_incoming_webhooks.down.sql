@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS incoming_webhooks;
+
+ALTER TABLE messages
+    DROP COLUMN IF EXISTS attachments;
@@ -0,0 +1,12 @@
+ALTER TABLE messages
+    ADD COLUMN attachments JSONB;
+
+CREATE TABLE IF NOT EXISTS incoming_webhooks(
+    id BIGSERIAL PRIMARY KEY,
+    room_id BIGINT NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    name VARCHAR(30) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS incoming_webhooks_room_id_idx ON incoming_webhooks(room_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bot_commands;
+DROP TABLE IF EXISTS bots;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS bots(
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(30) NOT NULL UNIQUE,
+    api_key_hash VARCHAR(64) NOT NULL UNIQUE,
+    callback_url TEXT,
+    callback_secret VARCHAR(64) NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS bot_commands(
+    id BIGSERIAL PRIMARY KEY,
+    bot_id BIGINT NOT NULL REFERENCES bots(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL UNIQUE,
+    description VARCHAR(200) NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS bot_commands_bot_id_idx ON bot_commands(bot_id);
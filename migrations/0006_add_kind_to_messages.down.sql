@@ -0,0 +1,6 @@
+UPDATE messages SET nick = kind WHERE nick IS NULL;
+
+ALTER TABLE messages
+    DROP CONSTRAINT IF EXISTS messages_user_nick_check,
+    DROP COLUMN IF EXISTS kind,
+    ALTER COLUMN nick SET NOT NULL;
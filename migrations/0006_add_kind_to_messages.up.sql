@@ -0,0 +1,4 @@
+ALTER TABLE messages
+    ADD COLUMN kind VARCHAR(16) NOT NULL DEFAULT 'user',
+    ALTER COLUMN nick DROP NOT NULL,
+    ADD CONSTRAINT messages_user_nick_check CHECK (kind <> 'user' OR nick IS NOT NULL);
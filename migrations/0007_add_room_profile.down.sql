@@ -0,0 +1,5 @@
+ALTER TABLE rooms
+    DROP COLUMN IF EXISTS metadata,
+    DROP COLUMN IF EXISTS avatar_url,
+    DROP COLUMN IF EXISTS description,
+    DROP COLUMN IF EXISTS topic;
@@ -0,0 +1,5 @@
+ALTER TABLE rooms
+    ADD COLUMN topic VARCHAR(250),
+    ADD COLUMN description VARCHAR(1000),
+    ADD COLUMN avatar_url TEXT,
+    ADD COLUMN metadata JSONB NOT NULL DEFAULT '{}';
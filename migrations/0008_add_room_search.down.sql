@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS rooms_last_active_at_id_idx;
+DROP INDEX IF EXISTS rooms_created_at_id_idx;
+DROP INDEX IF EXISTS rooms_name_trgm_idx;
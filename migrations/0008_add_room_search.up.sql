@@ -0,0 +1,5 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS rooms_name_trgm_idx ON rooms USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS rooms_created_at_id_idx ON rooms (created_at, id);
+CREATE INDEX IF NOT EXISTS rooms_last_active_at_id_idx ON rooms (last_active_at, id);
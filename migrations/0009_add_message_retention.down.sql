@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS messages_room_id_id_idx;
+
+ALTER TABLE rooms
+    DROP COLUMN IF EXISTS retention_max_messages,
+    DROP COLUMN IF EXISTS retention_days;
@@ -0,0 +1,5 @@
+ALTER TABLE rooms
+    ADD COLUMN retention_days INT CHECK (retention_days > 0),
+    ADD COLUMN retention_max_messages INT CHECK (retention_max_messages > 0);
+
+CREATE INDEX IF NOT EXISTS messages_room_id_id_idx ON messages (room_id, id);
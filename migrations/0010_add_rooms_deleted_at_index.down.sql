@@ -0,0 +1 @@
+DROP INDEX IF EXISTS rooms_deleted_at_idx;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS rooms_deleted_at_idx ON rooms (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS job_runs(
+    id BIGSERIAL PRIMARY KEY,
+    job VARCHAR(100) NOT NULL,
+    instance VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    error TEXT,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_started_at_idx ON job_runs (job, started_at DESC);
@@ -0,0 +1,14 @@
+// Package migrations embeds the SQL migrations so binaries can migrate the database without the source tree.
+package migrations
+
+import "embed"
+
+// FS holds the *.up.sql and *.down.sql files in golang-migrate naming.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// SQLiteFS holds the migrations of SQLite storage under sqlite/; they build the same schema in SQLite's dialect.
+//
+//go:embed sqlite/*.sql
+var SQLiteFS embed.FS
@@ -0,0 +1,9 @@
+-- Удаление всех таблиц
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS bot_commands;
+DROP TABLE IF EXISTS bots;
+DROP TABLE IF EXISTS incoming_webhooks;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
+DROP TABLE IF EXISTS messages;
+DROP TABLE IF EXISTS rooms;
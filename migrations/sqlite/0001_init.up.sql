@@ -0,0 +1,105 @@
+-- Схема SQLite совпадает со схемой Postgres после 0011_add_job_runs:
+-- JSONB и массивы хранятся как JSON в TEXT, время - как текст в UTC.
+CREATE TABLE IF NOT EXISTS rooms(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(30) NOT NULL,
+    password_hash BLOB,
+    topic VARCHAR(250),
+    description VARCHAR(1000),
+    avatar_url TEXT,
+    metadata TEXT NOT NULL DEFAULT '{}',
+    retention_days INTEGER CHECK (retention_days > 0),
+    retention_max_messages INTEGER CHECK (retention_max_messages > 0),
+    created_at   TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    updated_at   TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    deleted_at   TIMESTAMP,
+    last_active_at TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS rooms_created_at_id_idx ON rooms (created_at, id);
+CREATE INDEX IF NOT EXISTS rooms_last_active_at_id_idx ON rooms (last_active_at, id);
+CREATE INDEX IF NOT EXISTS rooms_deleted_at_idx ON rooms (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS messages(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind VARCHAR(16) NOT NULL DEFAULT 'user',
+    nick VARCHAR(30),
+    text TEXT NOT NULL,
+    attachments TEXT,
+    room_id INTEGER NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    created_at   TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    CONSTRAINT messages_user_nick_check CHECK (kind <> 'user' OR nick IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS messages_room_id_id_idx ON messages (room_id, id);
+
+CREATE TABLE IF NOT EXISTS webhooks(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret VARCHAR(64) NOT NULL,
+    events TEXT NOT NULL DEFAULT '[]',
+    created_at   TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    updated_at   TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS webhooks_room_id_idx ON webhooks(room_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event VARCHAR(32) NOT NULL,
+    payload TEXT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    last_error TEXT,
+    created_at   TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    updated_at   TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx
+    ON webhook_deliveries(next_attempt_at)
+    WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS incoming_webhooks(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_id INTEGER NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    name VARCHAR(30) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at   TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS incoming_webhooks_room_id_idx ON incoming_webhooks(room_id);
+
+CREATE TABLE IF NOT EXISTS bots(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(30) NOT NULL UNIQUE,
+    api_key_hash VARCHAR(64) NOT NULL UNIQUE,
+    callback_url TEXT,
+    callback_secret VARCHAR(64) NOT NULL,
+    created_at   TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS bot_commands(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bot_id INTEGER NOT NULL REFERENCES bots(id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL UNIQUE,
+    description VARCHAR(200) NOT NULL DEFAULT '',
+    created_at   TIMESTAMP NOT NULL DEFAULT current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS bot_commands_bot_id_idx ON bot_commands(bot_id);
+
+CREATE TABLE IF NOT EXISTS job_runs(
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    job VARCHAR(100) NOT NULL,
+    instance VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    error TEXT,
+    scheduled_at TIMESTAMP NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT current_timestamp,
+    finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS job_runs_job_started_at_idx ON job_runs (job, started_at DESC);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS job_runs_job_scheduled_at_idx;
+CREATE INDEX IF NOT EXISTS job_runs_job_started_at_idx ON job_runs (job, started_at DESC);